@@ -8,16 +8,20 @@ import (
 	"kingo/internal/auth"
 	"kingo/internal/clipboard"
 	"kingo/internal/config"
+	"kingo/internal/conversion"
+	"kingo/internal/converter"
 	"kingo/internal/downloader"
 	"kingo/internal/events"
 	"kingo/internal/handlers"
 	"kingo/internal/images"
 	"kingo/internal/launcher"
 	"kingo/internal/logger"
+	"kingo/internal/mediainfo"
 	"kingo/internal/pot"
 	"kingo/internal/roadmap"
 	"kingo/internal/storage"
 	"kingo/internal/telemetry"
+	"kingo/internal/transcription"
 	"kingo/internal/updater"
 	"kingo/internal/whisper"
 	"kingo/internal/youtube"
@@ -58,6 +62,12 @@ type App struct {
 	potProvider      *pot.Manager
 	youtube          *youtube.Client
 	downloadManager  *downloader.Manager
+	transcriptions   *transcription.Manager
+	conversions      *conversion.Manager
+	presets          *conversion.PresetStore
+	presetConverter  *conversion.PresetConverter
+	watermarks       *conversion.WatermarkStore
+	glossaries       *storage.GlossaryRepository
 	updater          *updater.Updater
 	imageClient      *images.Client
 	clipboardMonitor *clipboard.Monitor
@@ -71,6 +81,8 @@ type App struct {
 	systemHandler      *handlers.SystemHandler
 	converterHandler   *handlers.ConverterHandler
 	transcriberHandler *handlers.TranscriberHandler
+	searchHandler      *handlers.SearchHandler
+	glossaryHandler    *handlers.GlossaryHandler
 	whisperClient      *whisper.Client
 
 	// Stream proxy server for video trimmer preview
@@ -175,7 +187,8 @@ func (a *App) ServiceStartup(ctx context.Context, options application.ServiceOpt
 	a.youtube.SetContext(ctx)
 	a.youtube.SetOptionsProvider(a.potProvider)
 	a.youtube.SetAria2Path(paths.Aria2cPath())
-	a.youtube.SetSubtitleTranscriber(func(jobCtx context.Context, mediaPath, model, language string) ([]youtube.SubtitleCue, error) {
+	a.youtube.SetSubtitleTranscriber(func(jobCtx context.Context, req youtube.SubtitleTranscription) ([]youtube.SubtitleCue, error) {
+		model := req.Model
 		if model == "" {
 			models, err := a.whisperClient.ListModels()
 			if err != nil {
@@ -192,22 +205,74 @@ func (a *App) ServiceStartup(ctx context.Context, options application.ServiceOpt
 				}
 			}
 		}
-		result, err := a.whisperClient.TranscribeFileContext(jobCtx, mediaPath, model, language, "srt", false)
+		opts := whisper.TranscribeOptions{
+			Model:        model,
+			Language:     req.Language,
+			OutputFormat: "srt",
+			Task:         req.Task,
+			Speakers:     req.Speakers,
+			Prompt:       req.Prompt,
+		}
+		if len(req.GlossaryIDs) > 0 {
+			glossaries, err := a.glossaries.GetMany(req.GlossaryIDs)
+			if err != nil {
+				return nil, err
+			}
+			opts = transcription.ApplyGlossaries(opts, glossaries)
+		}
+		result, err := a.whisperClient.Transcribe(jobCtx, req.MediaPath, opts)
 		if err != nil {
 			return nil, err
 		}
 		cues := make([]youtube.SubtitleCue, 0, len(result.Segments))
 		for _, segment := range result.Segments {
-			cues = append(cues, youtube.SubtitleCue{Start: segment.Start, End: segment.End, Text: segment.Text})
+			cue := youtube.SubtitleCue{
+				Start:       segment.Start,
+				End:         segment.End,
+				Text:        segment.Text,
+				Translation: segment.Translation,
+				Speaker:     segment.Speaker,
+			}
+			for _, word := range segment.Words {
+				cue.Words = append(cue.Words, youtube.SubtitleWord{Start: word.Start, End: word.End, Text: word.Text})
+			}
+			cues = append(cues, cue)
 		}
 		return cues, nil
 	})
 
+	a.glossaries = storage.NewGlossaryRepository(db)
+
+	// Downloads may finish with a conversion preset, so presets are ready
+	// before the download queue restores its jobs.
+	ffmpegConverter := conversion.NewFFmpegConverter(a.paths)
+	a.presets = conversion.NewPresetStore(storage.NewConversionPresetRepository(db))
+	a.presetConverter = conversion.NewPresetConverter(a.presets, ffmpegConverter)
+	a.watermarks = conversion.NewWatermarkStore(storage.NewWatermarkPresetRepository(db))
+	a.youtube.SetPresetConverter(func(ctx context.Context, preset, inputPath, outputDir string) (string, error) {
+		output, err := a.presetConverter.ConvertWithPreset(ctx, preset, inputPath, outputDir, "", nil)
+		if err != nil {
+			return "", err
+		}
+		return output.OutputPath, nil
+	})
+
 	a.downloadManager = downloader.NewManager(a.downloadRepo, a.youtube, 3)
 	a.downloadManager.SetContext(ctx)
+	a.downloadManager.SetGlossaryRepository(a.glossaries)
 	a.downloadManager.Start()
 	logger.Log.Info().Msg("download manager started")
 
+	a.transcriptions = transcription.NewManager(storage.NewTranscriptionRepository(db), a.whisperClient, cfg.TranscriptionWorkers)
+	a.transcriptions.SetContext(ctx)
+	a.transcriptions.SetGlossaryRepository(a.glossaries)
+	a.transcriptions.SetWatchRepository(storage.NewTranscriptionWatchRepository(db))
+	a.transcriptions.Start()
+
+	a.conversions = conversion.NewManager(storage.NewConversionRepository(db), ffmpegConverter, cfg.ConversionWorkers)
+	a.conversions.SetContext(ctx)
+	a.conversions.Start()
+
 	a.updater = updater.NewUpdater(Version)
 	a.updater.SetContext(ctx)
 
@@ -267,10 +332,21 @@ func (a *App) initializeHandlers(ctx context.Context) {
 	a.converterHandler = handlers.NewConverterHandler(a.paths)
 	a.converterHandler.SetContext(ctx)
 	a.converterHandler.SetConsoleEmitter(a.consoleLog)
+	a.converterHandler.SetConversionManager(a.conversions)
+	a.converterHandler.SetConversionPresets(a.presets, a.presetConverter)
+	a.converterHandler.SetWatermarkPresets(a.watermarks)
 
 	a.transcriberHandler = handlers.NewTranscriberHandler(a.paths, a.whisperClient)
 	a.transcriberHandler.SetContext(ctx)
 	a.transcriberHandler.SetConsoleEmitter(a.consoleLog)
+	a.transcriberHandler.SetTranscriptionManager(a.transcriptions)
+	a.transcriberHandler.SetGlossaryRepository(a.glossaries)
+
+	a.searchHandler = handlers.NewSearchHandler(storage.NewSearchRepository(a.db))
+	a.searchHandler.SetContext(ctx)
+
+	a.glossaryHandler = handlers.NewGlossaryHandler(a.glossaries)
+	a.glossaryHandler.SetContext(ctx)
 }
 
 // consoleLog emits a user-friendly message to the frontend console.
@@ -416,6 +492,38 @@ func (a *App) GetVideoSubtitles(url string, language string) (*youtube.SubtitleR
 	return a.videoHandler.GetVideoSubtitles(url, language)
 }
 
+func (a *App) ExportSubtitles(opts youtube.SubtitleExportOptions) (string, error) {
+	return a.videoHandler.ExportSubtitles(opts)
+}
+
+func (a *App) ImportSubtitleFile(path string) (*youtube.SubtitleResult, error) {
+	return a.videoHandler.ImportSubtitleFile(path)
+}
+
+func (a *App) ShiftSubtitles(cues []youtube.SubtitleCue, offset, from, to float64) (*youtube.SubtitleEditResult, error) {
+	return a.videoHandler.ShiftSubtitles(cues, offset, from, to)
+}
+
+func (a *App) StretchSubtitles(cues []youtube.SubtitleCue, first, second youtube.SubtitleSyncPoint) (*youtube.SubtitleEditResult, error) {
+	return a.videoHandler.StretchSubtitles(cues, first, second)
+}
+
+func (a *App) SplitSubtitle(cues []youtube.SubtitleCue, index int, at float64) (*youtube.SubtitleEditResult, error) {
+	return a.videoHandler.SplitSubtitle(cues, index, at)
+}
+
+func (a *App) MergeSubtitles(cues []youtube.SubtitleCue, first, last int) (*youtube.SubtitleEditResult, error) {
+	return a.videoHandler.MergeSubtitles(cues, first, last)
+}
+
+func (a *App) WrapSubtitles(cues []youtube.SubtitleCue, options youtube.SubtitleWrapOptions) (*youtube.SubtitleEditResult, error) {
+	return a.videoHandler.WrapSubtitles(cues, options)
+}
+
+func (a *App) SelectSubtitleFile() (string, error) {
+	return a.converterHandler.SelectSubtitleFile()
+}
+
 func (a *App) UpdateYtDlp(channel string) (string, error) {
 	return a.videoHandler.UpdateYtDlp(channel)
 }
@@ -601,14 +709,42 @@ func (a *App) ConvertVideo(req handlers.VideoConvertRequest) (*handlers.Conversi
 	return a.converterHandler.ConvertVideo(req)
 }
 
+func (a *App) GetSupportedCodecs() ([]converter.CodecSupport, error) {
+	return a.converterHandler.GetSupportedCodecs()
+}
+
 func (a *App) CompressVideo(inputPath string, quality string, preset string) (*handlers.ConversionResult, error) {
 	return a.converterHandler.CompressVideo(inputPath, quality, preset)
 }
 
+func (a *App) CompressVideoToSize(inputPath string, targetSizeMB float64, preset string) (*handlers.ConversionResult, error) {
+	return a.converterHandler.CompressVideoToSize(inputPath, targetSizeMB, preset)
+}
+
+func (a *App) CompressVideoFile(req handlers.CompressVideoRequest) (*handlers.ConversionResult, error) {
+	return a.converterHandler.CompressVideoFile(req)
+}
+
 func (a *App) ExtractAudio(req handlers.AudioExtractRequest) (*handlers.ConversionResult, error) {
 	return a.converterHandler.ExtractAudio(req)
 }
 
+func (a *App) PreviewAudioSplit(req handlers.AudioSplitRequest) ([]converter.SplitPart, error) {
+	return a.converterHandler.PreviewAudioSplit(req)
+}
+
+func (a *App) SplitAudio(req handlers.AudioSplitRequest) (*handlers.ConversionResult, error) {
+	return a.converterHandler.SplitAudio(req)
+}
+
+func (a *App) ExportAnimation(req handlers.AnimationExportRequest) (*handlers.ConversionResult, error) {
+	return a.converterHandler.ExportAnimation(req)
+}
+
+func (a *App) ConcatMedia(req handlers.ConcatRequest) (*handlers.ConversionResult, error) {
+	return a.converterHandler.ConcatMedia(req)
+}
+
 func (a *App) ConvertImage(req handlers.ImageConvertRequest) (*handlers.ConversionResult, error) {
 	return a.converterHandler.ConvertImage(req)
 }
@@ -617,6 +753,18 @@ func (a *App) CompressImage(inputPath string, quality int) (*handlers.Conversion
 	return a.converterHandler.CompressImage(inputPath, quality)
 }
 
+func (a *App) CompressImageFile(req handlers.CompressImageRequest) (*handlers.ConversionResult, error) {
+	return a.converterHandler.CompressImageFile(req)
+}
+
+func (a *App) CancelConversion(id string) error {
+	return a.converterHandler.CancelConversion(id)
+}
+
+func (a *App) InspectMedia(path string) (*mediainfo.Info, error) {
+	return a.converterHandler.InspectMedia(path)
+}
+
 func (a *App) ReadImageThumbnail(inputPath string, maxSize int) (string, error) {
 	return a.converterHandler.ReadImageThumbnail(inputPath, maxSize)
 }
@@ -633,6 +781,70 @@ func (a *App) SelectImageFiles() ([]string, error) {
 	return a.converterHandler.SelectImageFiles()
 }
 
+func (a *App) QueueConversions(req handlers.ConversionBatchRequest) (*conversion.Batch, error) {
+	return a.converterHandler.QueueConversions(req)
+}
+
+func (a *App) CancelConversionBatch(batchID string) error {
+	return a.converterHandler.CancelConversionBatch(batchID)
+}
+
+func (a *App) GetConversionQueue() ([]*storage.Conversion, error) {
+	return a.converterHandler.GetConversionQueue()
+}
+
+func (a *App) GetConversionHistory(limit int) ([]*storage.Conversion, error) {
+	return a.converterHandler.GetConversionHistory(limit)
+}
+
+func (a *App) GetConversionBatch(batchID string) ([]*storage.Conversion, error) {
+	return a.converterHandler.GetConversionBatch(batchID)
+}
+
+func (a *App) GetConversionBatchStats(batchID string) (*conversion.BatchStats, error) {
+	return a.converterHandler.GetConversionBatchStats(batchID)
+}
+
+func (a *App) ClearConversionHistory() error {
+	return a.converterHandler.ClearConversionHistory()
+}
+
+func (a *App) ListConversionPresets() ([]conversion.Preset, error) {
+	return a.converterHandler.ListConversionPresets()
+}
+
+func (a *App) SaveConversionPreset(preset conversion.Preset) (*conversion.Preset, error) {
+	return a.converterHandler.SaveConversionPreset(preset)
+}
+
+func (a *App) DeleteConversionPreset(id string) error {
+	return a.converterHandler.DeleteConversionPreset(id)
+}
+
+func (a *App) ExportConversionPresets(ids []string, path string) (string, error) {
+	return a.converterHandler.ExportConversionPresets(ids, path)
+}
+
+func (a *App) ImportConversionPresets(path string) (*conversion.ImportResult, error) {
+	return a.converterHandler.ImportConversionPresets(path)
+}
+
+func (a *App) ConvertWithPreset(req handlers.PresetConvertRequest) (*handlers.ConversionResult, error) {
+	return a.converterHandler.ConvertWithPreset(req)
+}
+
+func (a *App) ListWatermarkPresets() ([]conversion.WatermarkPreset, error) {
+	return a.converterHandler.ListWatermarkPresets()
+}
+
+func (a *App) SaveWatermarkPreset(preset conversion.WatermarkPreset) (*conversion.WatermarkPreset, error) {
+	return a.converterHandler.SaveWatermarkPreset(preset)
+}
+
+func (a *App) DeleteWatermarkPreset(id string) error {
+	return a.converterHandler.DeleteWatermarkPreset(id)
+}
+
 func (a *App) GetVersion() string {
 	return Version
 }
@@ -659,6 +871,10 @@ func (a *App) DownloadWhisperModel(name string) error {
 	return a.transcriberHandler.DownloadWhisperModel(name)
 }
 
+func (a *App) ImportWhisperModel(path, name string) (*whisper.ModelInfo, error) {
+	return a.transcriberHandler.ImportWhisperModel(path, name)
+}
+
 func (a *App) DeleteWhisperModel(name string) error {
 	return a.transcriberHandler.DeleteWhisperModel(name)
 }
@@ -675,6 +891,94 @@ func (a *App) ExportTranscriptionDOCX(text string) error {
 	return a.transcriberHandler.ExportTranscriptionDOCX(text)
 }
 
+func (a *App) QueueTranscription(req handlers.TranscribeRequest) (*storage.Transcription, error) {
+	return a.transcriberHandler.QueueTranscription(req)
+}
+
+func (a *App) SelectTranscriptionFolder() (string, error) {
+	return a.transcriberHandler.SelectTranscriptionFolder()
+}
+
+func (a *App) TranscribeFolder(req handlers.TranscribeFolderRequest) (*transcription.BatchResult, error) {
+	return a.transcriberHandler.TranscribeFolder(req)
+}
+
+func (a *App) ListTranscriptionWatches() ([]*storage.TranscriptionWatch, error) {
+	return a.transcriberHandler.ListTranscriptionWatches()
+}
+
+func (a *App) SaveTranscriptionWatch(watch storage.TranscriptionWatch) (*storage.TranscriptionWatch, error) {
+	return a.transcriberHandler.SaveTranscriptionWatch(watch)
+}
+
+func (a *App) DeleteTranscriptionWatch(id string) error {
+	return a.transcriberHandler.DeleteTranscriptionWatch(id)
+}
+
+func (a *App) CancelTranscription(id string) error {
+	return a.transcriberHandler.CancelTranscription(id)
+}
+
+func (a *App) GetTranscriptionQueue() ([]*storage.Transcription, error) {
+	return a.transcriberHandler.GetTranscriptionQueue()
+}
+
+func (a *App) GetTranscriptionHistory(limit int) ([]*storage.Transcription, error) {
+	return a.transcriberHandler.GetTranscriptionHistory(limit)
+}
+
+func (a *App) GetTranscription(id string) (*storage.Transcription, error) {
+	return a.transcriberHandler.GetTranscription(id)
+}
+
+func (a *App) DeleteTranscription(id string) error {
+	return a.transcriberHandler.DeleteTranscription(id)
+}
+
+func (a *App) ClearTranscriptionHistory() error {
+	return a.transcriberHandler.ClearTranscriptionHistory()
+}
+
+func (a *App) ExportTranscription(id, format string) (string, error) {
+	return a.transcriberHandler.ExportTranscription(id, format)
+}
+
+func (a *App) ExportTranscript(req handlers.ExportTranscriptRequest) (string, error) {
+	return a.transcriberHandler.ExportTranscript(req)
+}
+
+// --- Search ---
+
+func (a *App) Search(query string, limit int) (*storage.SearchResults, error) {
+	return a.searchHandler.Search(query, limit)
+}
+
+// --- Glossaries ---
+
+func (a *App) ListGlossaries() ([]*storage.Glossary, error) {
+	return a.glossaryHandler.ListGlossaries()
+}
+
+func (a *App) SaveGlossary(glossary storage.Glossary) (*storage.Glossary, error) {
+	return a.glossaryHandler.SaveGlossary(glossary)
+}
+
+func (a *App) DeleteGlossary(id string) error {
+	return a.glossaryHandler.DeleteGlossary(id)
+}
+
+func (a *App) AttachGlossary(glossaryID, targetType, targetID string) error {
+	return a.glossaryHandler.AttachGlossary(glossaryID, targetType, targetID)
+}
+
+func (a *App) DetachGlossary(glossaryID, targetType, targetID string) error {
+	return a.glossaryHandler.DetachGlossary(glossaryID, targetType, targetID)
+}
+
+func (a *App) GetAttachedGlossaries(targetType, targetID string) ([]*storage.Glossary, error) {
+	return a.glossaryHandler.GetAttachedGlossaries(targetType, targetID)
+}
+
 // GetRoadmap fetches roadmap items from the configured source
 func (a *App) GetRoadmap(lang string) ([]roadmap.RoadmapItem, error) {
 	return a.roadmap.FetchRoadmap(lang)
@@ -691,6 +995,12 @@ func (a *App) ServiceShutdown() error {
 	if a.downloadManager != nil {
 		a.downloadManager.Stop()
 	}
+	if a.transcriptions != nil {
+		a.transcriptions.Stop()
+	}
+	if a.conversions != nil {
+		a.conversions.Stop()
+	}
 
 	// Stop clipboard monitor
 	if a.clipboardMonitor != nil {
@@ -36,6 +36,8 @@ type Config struct {
 	ClipboardMonitorEnabled bool            `json:"clipboardMonitorEnabled"`
 	AnonymousMode           bool            `json:"anonymousMode"`
 	Roadmap                 RoadmapConfig   `json:"roadmap"`
+	TranscriptionWorkers    int             `json:"transcriptionWorkers"` // Parallel whisper jobs (default 1)
+	ConversionWorkers       int             `json:"conversionWorkers"`    // Parallel FFmpeg jobs in the conversion queue, capped at the CPU count (default 2)
 
 	mu       sync.RWMutex
 	filePath string
@@ -51,6 +53,8 @@ func Default() *Config {
 			Quality: 100,
 		},
 		ClipboardMonitorEnabled: true,
+		TranscriptionWorkers:    1,
+		ConversionWorkers:       2,
 		Shortcuts: ShortcutsConfig{
 			FocusInput:    "Ctrl+L",
 			OpenSettings:  "Ctrl+,",
@@ -0,0 +1,253 @@
+// Package conversion runs converter jobs from a persistent queue, so many
+// files can be converted with one set of options.
+package conversion
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"kingo/internal/app"
+	"kingo/internal/converter"
+)
+
+// Kinds of conversion
+const (
+	KindVideo = "video" // Converts or, without a format, compresses a video
+	KindAudio = "audio" // Extracts the audio track
+	KindImage = "image" // Converts or, without a format, compresses an image
+)
+
+// Options is the preset applied to every file of a batch. Fields that do not
+// apply to the batch kind are ignored.
+type Options struct {
+	OutputDir  string `json:"outputDir"`            // Empty writes next to each input
+	OutputName string `json:"outputName,omitempty"` // File name without extension; AddBatch reserves one per file
+	Format     string `json:"format"`               // Target format; empty keeps the input format (video and image)
+
+	Quality    string `json:"quality"`    // Video: lossless, high, medium, low, tiny; audio: low, medium, high, best
+	CustomCRF  int    `json:"customCrf"`  // Video: on the codec's scale (0-51, AV1/VP9 0-63), overrides quality
+	Preset     string `json:"preset"`     // Video: ultrafast ... veryslow
+	Resolution string `json:"resolution"` // Video: e.g. "1920x1080"
+	KeepAudio  bool   `json:"keepAudio"`  // Video
+
+	TargetSizeMB float64 `json:"targetSizeMb"` // Video: two-pass encodes each file to fit this size
+
+	VideoCodec       string  `json:"videoCodec,omitempty"`       // Video: h264, hevc, av1, vp9; empty uses the container default
+	AudioCodec       string  `json:"audioCodec,omitempty"`       // Video: aac, mp3, opus, vorbis, flac
+	AudioBitrate     int     `json:"audioBitrate,omitempty"`     // Video: audio kbps, 0 = automatic
+	PixelFormat      string  `json:"pixelFormat,omitempty"`      // Video: e.g. yuv420p10le
+	TenBit           bool    `json:"tenBit,omitempty"`           // Video
+	FPS              float64 `json:"fps,omitempty"`              // Video: 0 keeps the source frame rate
+	KeyframeInterval float64 `json:"keyframeInterval,omitempty"` // Video: seconds, 0 = encoder default
+
+	CustomBitrate int `json:"customBitrate"` // Audio: kbps, overrides quality
+	Channels      int `json:"channels"`      // Audio: 1 = mono, 2 = stereo, 0 keeps the source
+
+	ImageQuality int `json:"imageQuality"` // Image: 0-100
+	Width        int `json:"width"`        // Image: 0 keeps the original
+	Height       int `json:"height"`       // Image: 0 keeps the original
+
+	Watermark *converter.Watermark `json:"watermark,omitempty"` // Video and image: logo or text overlay
+	Speed     *converter.Speed     `json:"speed,omitempty"`     // Video and audio: speed change or reverse
+}
+
+// Output is the result of converting one file.
+type Output struct {
+	OutputPath string
+	InputSize  int64
+	OutputSize int64
+}
+
+// Converter converts a single file. *FFmpegConverter implements it.
+type Converter interface {
+	Convert(ctx context.Context, kind, inputPath string, opts Options, onProgress converter.ProgressFunc) (*Output, error)
+}
+
+// Progress is the payload of converter:progress, for queued conversions and
+// direct calls alike.
+type Progress struct {
+	OperationID string  `json:"operationId"`
+	Percent     float64 `json:"percent"` // 0-100
+	Stage       string  `json:"stage"`
+	Seconds     float64 `json:"seconds"` // Media time processed so far
+	Speed       string  `json:"speed"`
+}
+
+// NewProgress tags converter progress with the operation it belongs to.
+func NewProgress(operationID string, progress converter.Progress) Progress {
+	return Progress{
+		OperationID: operationID,
+		Percent:     progress.Percent,
+		Stage:       progress.Stage,
+		Seconds:     progress.Seconds,
+		Speed:       progress.Speed,
+	}
+}
+
+// FFmpegConverter converts files with the bundled FFmpeg and avifenc.
+type FFmpegConverter struct {
+	paths *app.Paths
+}
+
+// NewFFmpegConverter creates a converter that resolves the binaries on every
+// call, so a dependency installed after startup is picked up.
+func NewFFmpegConverter(paths *app.Paths) *FFmpegConverter {
+	return &FFmpegConverter{paths: paths}
+}
+
+// Convert runs the converter function matching kind.
+func (c *FFmpegConverter) Convert(ctx context.Context, kind, inputPath string, opts Options, onProgress converter.ProgressFunc) (*Output, error) {
+	switch kind {
+	case KindVideo:
+		format := converter.VideoFormat(opts.Format)
+		if format == "" {
+			format = converter.VideoFormatOf(inputPath)
+		}
+		videoOpts := videoOptions(opts)
+		videoOpts.InputPath = inputPath
+		videoOpts.Format = format
+		videoOpts.KeepAudio = opts.KeepAudio || opts.Format == ""
+		videoOpts.FFmpegPath = c.paths.FFmpegPath()
+		videoOpts.OnProgress = onProgress
+		result, err := converter.ConvertVideo(ctx, videoOpts)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{OutputPath: result.OutputPath, InputSize: result.InputSize, OutputSize: result.OutputSize}, nil
+	case KindAudio:
+		result, err := converter.ExtractAudio(ctx, converter.AudioExtractOptions{
+			InputPath:     inputPath,
+			OutputDir:     opts.OutputDir,
+			Format:        converter.AudioFormat(opts.Format),
+			Quality:       converter.AudioQuality(opts.Quality),
+			CustomBitrate: opts.CustomBitrate,
+			Channels:      opts.Channels,
+			FFmpegPath:    c.paths.FFmpegPath(),
+			CustomName:    opts.OutputName,
+			OnProgress:    onProgress,
+			Speed:         opts.Speed,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &Output{OutputPath: result.OutputPath, InputSize: result.InputSize, OutputSize: result.OutputSize}, nil
+	case KindImage:
+		format := opts.Format
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(inputPath), ".")
+		}
+		result, err := converter.ConvertImage(ctx, converter.ImageConvertOptions{
+			InputPath:   inputPath,
+			OutputDir:   opts.OutputDir,
+			Format:      imageFormat(format),
+			Quality:     opts.ImageQuality,
+			Width:       opts.Width,
+			Height:      opts.Height,
+			FFmpegPath:  c.paths.FFmpegPath(),
+			AvifencPath: c.paths.AvifencPath(),
+			CustomName:  opts.OutputName,
+			OnProgress:  onProgress,
+			Watermark:   opts.Watermark,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &Output{OutputPath: result.OutputPath, InputSize: result.InputSize, OutputSize: result.OutputSize}, nil
+	default:
+		return nil, fmt.Errorf("unsupported conversion kind: %s", kind)
+	}
+}
+
+// outputTarget returns the folder, base name and extension Convert writes to
+// when opts.OutputName is empty, matching the converter defaults.
+func outputTarget(kind, inputPath string, opts Options) (dir, base, ext string) {
+	dir = opts.OutputDir
+	if dir == "" {
+		dir = filepath.Dir(inputPath)
+	}
+	base = strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	switch kind {
+	case KindVideo:
+		format := converter.VideoFormat(opts.Format)
+		if format == "" {
+			format = converter.VideoFormatOf(inputPath)
+		}
+		return dir, base + "_converted", string(format)
+	case KindAudio:
+		return dir, base, opts.Format
+	default:
+		format := opts.Format
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(inputPath), ".")
+		}
+		return dir, base + "_converted", string(imageFormat(format))
+	}
+}
+
+// imageFormat maps a format name or file extension to its output format.
+func imageFormat(name string) converter.ImageFormat {
+	switch name = strings.ToLower(name); name {
+	case "jpeg":
+		return converter.ImageFormatJPEG
+	case "tif":
+		return converter.ImageFormatTIFF
+	default:
+		return converter.ImageFormat(name)
+	}
+}
+
+// validateOptions rejects a batch whose kind or format is unknown before any
+// file is queued.
+func validateOptions(kind string, opts Options) error {
+	formats := map[string][]string{
+		KindVideo: {"", "mp4", "mkv", "webm", "avi", "mov"},
+		KindAudio: {"mp3", "aac", "m4a", "flac", "wav", "ogg", "opus"},
+		KindImage: {"", "jpg", "jpeg", "png", "webp", "avif", "bmp", "tiff"},
+	}
+	allowed, ok := formats[kind]
+	if !ok {
+		return fmt.Errorf("unsupported conversion kind: %s", kind)
+	}
+	if !slices.Contains(allowed, opts.Format) {
+		return fmt.Errorf("unsupported %s format: %s", kind, opts.Format)
+	}
+	switch kind {
+	case KindVideo:
+		return converter.ValidateVideoOptions(videoOptions(opts))
+	case KindAudio:
+		return opts.Speed.Validate()
+	case KindImage:
+		return opts.Watermark.Validate()
+	}
+	return nil
+}
+
+// videoOptions maps the video fields of opts; the caller fills in the input,
+// binaries and callbacks.
+func videoOptions(opts Options) converter.VideoConvertOptions {
+	return converter.VideoConvertOptions{
+		OutputDir:  opts.OutputDir,
+		Format:     converter.VideoFormat(opts.Format),
+		Quality:    converter.VideoQuality(opts.Quality),
+		CustomCRF:  opts.CustomCRF,
+		Preset:     opts.Preset,
+		Resolution: opts.Resolution,
+		KeepAudio:  opts.KeepAudio,
+		CustomName: opts.OutputName,
+
+		TargetSizeBytes: int64(opts.TargetSizeMB * 1e6),
+		AudioBitrate:    opts.AudioBitrate,
+
+		VideoCodec:       converter.VideoCodec(opts.VideoCodec),
+		AudioCodec:       converter.AudioCodec(opts.AudioCodec),
+		PixelFormat:      opts.PixelFormat,
+		TenBit:           opts.TenBit,
+		FPS:              opts.FPS,
+		KeyframeInterval: opts.KeyframeInterval,
+		Watermark:        opts.Watermark,
+		Speed:            opts.Speed,
+	}
+}
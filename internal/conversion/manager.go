@@ -0,0 +1,518 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"kingo/internal/converter"
+	"kingo/internal/events"
+	"kingo/internal/logger"
+	"kingo/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// Job represents a conversion in the queue
+type Job struct {
+	Conversion *storage.Conversion
+	Ctx        context.Context
+	Cancel     context.CancelFunc
+}
+
+// Batch is the set of conversions created by one AddBatch call. Files that
+// could not be queued are already failed.
+type Batch struct {
+	ID          string                `json:"id"`
+	Conversions []*storage.Conversion `json:"conversions"`
+}
+
+// BatchStats aggregates the results of a batch. Sizes and compression only
+// count completed files.
+type BatchStats struct {
+	BatchID     string  `json:"batchId"`
+	Total       int     `json:"total"`
+	Pending     int     `json:"pending"`
+	Processing  int     `json:"processing"`
+	Completed   int     `json:"completed"`
+	Failed      int     `json:"failed"`
+	Cancelled   int     `json:"cancelled"`
+	InputSize   int64   `json:"inputSize"`
+	OutputSize  int64   `json:"outputSize"`
+	Compression float64 `json:"compression"` // Percentage saved
+}
+
+// Done reports whether every file of the batch has finished.
+func (s BatchStats) Done() bool {
+	return s.Pending == 0 && s.Processing == 0
+}
+
+// Manager runs queued conversions with a bounded number of workers. Every
+// file is persisted, so the queue survives restarts and batch results can be
+// reviewed later.
+type Manager struct {
+	ctx       context.Context
+	repo      *storage.ConversionRepository
+	converter Converter
+	workers   int
+
+	// pending is unbounded because a single batch can hold thousands of
+	// images.
+	pending   []*Job
+	wake      chan struct{}
+	jobs      map[string]*Job
+	mu        sync.Mutex
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewManager creates a new conversion manager. FFmpeg already uses several
+// threads per file, so workers is capped at the CPU count.
+func NewManager(repo *storage.ConversionRepository, conv Converter, workers int) *Manager {
+	return &Manager{
+		ctx:       context.Background(),
+		repo:      repo,
+		converter: conv,
+		workers:   max(1, min(workers, runtime.NumCPU())),
+		wake:      make(chan struct{}, 1),
+		jobs:      make(map[string]*Job),
+		quit:      make(chan struct{}),
+	}
+}
+
+// SetContext sets the parent context of every job
+func (m *Manager) SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.ctx = ctx
+}
+
+// Start launches the workers and restores jobs from the previous session
+func (m *Manager) Start() {
+	m.startOnce.Do(func() {
+		logger.Log.Info().Int("workers", m.workers).Msg("conversion manager started")
+		m.wg.Add(m.workers)
+		for range m.workers {
+			go func() {
+				defer m.wg.Done()
+				m.workerLoop()
+			}()
+		}
+		m.restorePendingJobs()
+	})
+}
+
+// Stop cancels running jobs and waits for the workers to exit. Jobs still
+// waiting stay pending in the database and resume on the next start.
+func (m *Manager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.quit)
+
+		m.mu.Lock()
+		cancels := make([]context.CancelFunc, 0, len(m.jobs))
+		for _, job := range m.jobs {
+			cancels = append(cancels, job.Cancel)
+		}
+		m.mu.Unlock()
+		for _, cancel := range cancels {
+			cancel()
+		}
+
+		m.wg.Wait()
+		logger.Log.Info().Msg("conversion manager stopped")
+	})
+}
+
+func (m *Manager) workerLoop() {
+	for {
+		if job := m.next(); job != nil {
+			m.processJob(job)
+			continue
+		}
+		select {
+		case <-m.quit:
+			return
+		case <-m.wake:
+		}
+	}
+}
+
+// next pops the oldest waiting job, or returns nil when there is none or the
+// manager is stopping.
+func (m *Manager) next() *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.quit:
+		return nil
+	default:
+	}
+	if len(m.pending) == 0 {
+		return nil
+	}
+	job := m.pending[0]
+	m.pending[0] = nil
+	m.pending = m.pending[1:]
+	if len(m.pending) > 0 {
+		m.signal() // Another worker may be idle
+	}
+	return job
+}
+
+// signal wakes one idle worker.
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// AddBatch applies the same options to every input and queues them. An input
+// that cannot be queued is recorded as failed and the rest carry on.
+func (m *Manager) AddBatch(kind string, inputs []string, opts Options) (*Batch, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no files to convert")
+	}
+	if err := validateOptions(kind, opts); err != nil {
+		return nil, err
+	}
+	if opts.OutputDir != "" {
+		if info, err := os.Stat(opts.OutputDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("output folder not found: %s", opts.OutputDir)
+		}
+	}
+	options, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &Batch{ID: uuid.New().String(), Conversions: make([]*storage.Conversion, 0, len(inputs))}
+	queued := 0
+	claimed := map[string]bool{}
+	for _, input := range inputs {
+		record := &storage.Conversion{
+			BatchID:   batch.ID,
+			Kind:      kind,
+			InputPath: input,
+			Options:   options,
+			Status:    storage.ConversionPending,
+		}
+		if info, err := os.Stat(input); err != nil || info.IsDir() {
+			now := time.Now()
+			record.Status = storage.ConversionFailed
+			record.ErrorMessage = fmt.Sprintf("file not found: %s", input)
+			record.CompletedAt = &now
+		} else {
+			record.InputSize = info.Size()
+			// Workers run in parallel, so every file gets its own output name
+			// up front instead of racing for the same one.
+			fileOpts := opts
+			fileOpts.OutputName = reserveOutputName(kind, input, opts, claimed)
+			if record.Options, err = json.Marshal(fileOpts); err != nil {
+				return nil, err
+			}
+		}
+		if err := m.repo.Create(record); err != nil {
+			return nil, err
+		}
+		batch.Conversions = append(batch.Conversions, record)
+		m.emitEvent(events.ConversionAdded, record)
+		if record.Status == storage.ConversionPending {
+			m.enqueue(record)
+			queued++
+		}
+	}
+
+	logger.Log.Info().
+		Str("batchID", batch.ID).
+		Str("kind", kind).
+		Int("queued", queued).
+		Int("failed", len(inputs)-queued).
+		Msg("conversion batch queued")
+	if queued == 0 {
+		m.checkBatchDone(batch.ID)
+	}
+	return batch, nil
+}
+
+// reserveOutputName returns the name, without extension, under which input is
+// converted: the converter's default, or the first free "_2", "_3"... variant
+// when that file exists or another file of the batch claimed it. claimed
+// holds the lower-cased output paths taken or found on disk so far.
+func reserveOutputName(kind, input string, opts Options, claimed map[string]bool) string {
+	dir, base, ext := outputTarget(kind, input, opts)
+	if opts.OutputName != "" {
+		base = opts.OutputName
+	}
+	name := base
+	for n := 2; ; n++ {
+		path := filepath.Join(dir, name+"."+ext)
+		key := strings.ToLower(path) // Windows and macOS match names case-insensitively
+		if !claimed[key] {
+			_, err := os.Stat(path)
+			claimed[key] = true
+			if os.IsNotExist(err) {
+				return name
+			}
+		}
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+}
+
+// enqueue tracks a pending conversion and hands it to the workers.
+func (m *Manager) enqueue(record *storage.Conversion) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	job := &Job{Conversion: record, Ctx: ctx, Cancel: cancel}
+	m.mu.Lock()
+	m.jobs[record.ID] = job
+	m.pending = append(m.pending, job)
+	m.mu.Unlock()
+	m.signal()
+}
+
+// CancelJob cancels a queued or running conversion
+func (m *Manager) CancelJob(id string) error {
+	m.mu.Lock()
+	job, exists := m.jobs[id]
+	waiting := exists && m.removePending(id)
+	m.mu.Unlock()
+
+	if !exists {
+		record, err := m.repo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			return fmt.Errorf("conversion not found: %s", id)
+		}
+		return nil // Already finished
+	}
+	job.Cancel()
+	if waiting {
+		m.finishJob(job.Conversion, storage.ConversionCancelled, "")
+	}
+	return nil
+}
+
+// CancelBatch cancels every unfinished conversion of a batch
+func (m *Manager) CancelBatch(batchID string) error {
+	m.mu.Lock()
+	var ids []string
+	for id, job := range m.jobs {
+		if job.Conversion.BatchID == batchID {
+			ids = append(ids, id)
+		}
+	}
+	m.mu.Unlock()
+	for _, id := range ids {
+		if err := m.CancelJob(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removePending drops a waiting job from the queue. The caller holds mu.
+func (m *Manager) removePending(id string) bool {
+	for i, job := range m.pending {
+		if job.Conversion.ID == id {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetQueue returns pending and running conversions
+func (m *Manager) GetQueue() ([]*storage.Conversion, error) {
+	return m.repo.GetQueue()
+}
+
+// GetHistory returns finished conversions
+func (m *Manager) GetHistory(limit int) ([]*storage.Conversion, error) {
+	return m.repo.GetHistory(limit)
+}
+
+// GetBatch returns every conversion of a batch
+func (m *Manager) GetBatch(batchID string) ([]*storage.Conversion, error) {
+	return m.repo.GetBatch(batchID)
+}
+
+// GetBatchStats aggregates the results of a batch
+func (m *Manager) GetBatchStats(batchID string) (*BatchStats, error) {
+	conversions, err := m.repo.GetBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if len(conversions) == 0 {
+		return nil, fmt.Errorf("conversion batch not found: %s", batchID)
+	}
+	stats := NewBatchStats(batchID, conversions)
+	return &stats, nil
+}
+
+// ClearHistory removes all finished conversions
+func (m *Manager) ClearHistory() error {
+	return m.repo.ClearHistory()
+}
+
+// NewBatchStats aggregates the given conversions of a batch.
+func NewBatchStats(batchID string, conversions []*storage.Conversion) BatchStats {
+	stats := BatchStats{BatchID: batchID, Total: len(conversions)}
+	for _, c := range conversions {
+		switch c.Status {
+		case storage.ConversionPending:
+			stats.Pending++
+		case storage.ConversionProcessing:
+			stats.Processing++
+		case storage.ConversionCompleted:
+			stats.Completed++
+			stats.InputSize += c.InputSize
+			stats.OutputSize += c.OutputSize
+		case storage.ConversionFailed:
+			stats.Failed++
+		case storage.ConversionCancelled:
+			stats.Cancelled++
+		}
+	}
+	if stats.InputSize > 0 {
+		stats.Compression = (1.0 - float64(stats.OutputSize)/float64(stats.InputSize)) * 100
+	}
+	return stats
+}
+
+func (m *Manager) processJob(job *Job) {
+	record := job.Conversion
+	select {
+	case <-job.Ctx.Done():
+		m.finishJob(record, storage.ConversionCancelled, "")
+		return
+	default:
+	}
+
+	now := time.Now()
+	record.Status = storage.ConversionProcessing
+	record.StartedAt = &now
+	if err := m.repo.Update(record); err != nil {
+		logger.Log.Error().Err(err).Str("id", record.ID).Msg("failed to update conversion")
+	}
+	m.emitEvent(events.ConversionUpdated, record)
+	logger.Log.Info().Str("traceID", record.ID).Str("phase", "start").Str("input", record.InputPath).Msg("processing conversion")
+
+	var opts Options
+	if err := json.Unmarshal(record.Options, &opts); err != nil {
+		m.finishJob(record, storage.ConversionFailed, fmt.Sprintf("stored options are corrupt: %v", err))
+		return
+	}
+	output, err := m.converter.Convert(job.Ctx, record.Kind, record.InputPath, opts, func(progress converter.Progress) {
+		m.emitEvent(events.ConverterProgress, NewProgress(record.ID, progress))
+	})
+	if err != nil {
+		if job.Ctx.Err() != nil {
+			m.finishJob(record, storage.ConversionCancelled, "")
+			return
+		}
+		m.finishJob(record, storage.ConversionFailed, err.Error())
+		return
+	}
+	record.OutputPath = output.OutputPath
+	record.InputSize = output.InputSize
+	record.OutputSize = output.OutputSize
+	m.finishJob(record, storage.ConversionCompleted, "")
+}
+
+func (m *Manager) finishJob(record *storage.Conversion, status storage.ConversionStatus, errMsg string) {
+	select {
+	case <-m.quit:
+		if status == storage.ConversionCancelled {
+			// Interrupted by shutdown: leave it for the next session.
+			m.cleanupJob(record.ID)
+			return
+		}
+	default:
+	}
+
+	now := time.Now()
+	record.Status = status
+	record.ErrorMessage = errMsg
+	record.CompletedAt = &now
+	if err := m.repo.Update(record); err != nil {
+		logger.Log.Error().Err(err).Str("id", record.ID).Msg("failed to persist conversion")
+	}
+	m.cleanupJob(record.ID)
+	m.emitEvent(events.ConversionUpdated, record)
+
+	logger.Log.Info().
+		Str("traceID", record.ID).
+		Str("phase", string(status)).
+		Str("error", errMsg).
+		Msg("conversion finished")
+	m.checkBatchDone(record.BatchID)
+}
+
+// checkBatchDone emits the batch statistics once its last file finishes.
+func (m *Manager) checkBatchDone(batchID string) {
+	conversions, err := m.repo.GetBatch(batchID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("batchID", batchID).Msg("failed to read conversion batch")
+		return
+	}
+	stats := NewBatchStats(batchID, conversions)
+	if !stats.Done() {
+		return
+	}
+	m.emitEvent(events.ConversionBatchDone, stats)
+	logger.Log.Info().
+		Str("batchID", batchID).
+		Int("completed", stats.Completed).
+		Int("failed", stats.Failed).
+		Int("cancelled", stats.Cancelled).
+		Float64("compression", stats.Compression).
+		Msg("conversion batch finished")
+}
+
+func (m *Manager) cleanupJob(id string) {
+	m.mu.Lock()
+	job := m.jobs[id]
+	delete(m.jobs, id)
+	m.mu.Unlock()
+	if job != nil {
+		job.Cancel()
+	}
+}
+
+// restorePendingJobs requeues jobs that were pending or interrupted mid-run
+// when the app last closed.
+func (m *Manager) restorePendingJobs() {
+	if err := m.repo.RequeueInterrupted(); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to requeue interrupted conversions")
+	}
+	pending, err := m.repo.GetPending()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("failed to restore pending conversions")
+		return
+	}
+	for _, record := range pending {
+		m.enqueue(record)
+	}
+	if len(pending) > 0 {
+		logger.Log.Info().Int("count", len(pending)).Msg("restored pending conversions")
+	}
+}
+
+// emitEvent tolerates a missing Wails application so the manager works in tests.
+func (m *Manager) emitEvent(eventName string, data any) {
+	if app := application.Get(); app != nil {
+		app.Event.Emit(eventName, data)
+	}
+}
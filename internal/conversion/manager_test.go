@@ -0,0 +1,206 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"kingo/internal/converter"
+	"kingo/internal/storage"
+)
+
+// fakeConverter halves every file, fails inputs named "broken*" and blocks
+// until released when block is set.
+type fakeConverter struct {
+	block chan struct{}
+}
+
+func (f *fakeConverter) Convert(ctx context.Context, kind, inputPath string, opts Options, onProgress converter.ProgressFunc) (*Output, error) {
+	if f.block != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.block:
+		}
+	}
+	if strings.HasPrefix(filepath.Base(inputPath), "broken") {
+		return nil, errors.New("invalid data found when processing input")
+	}
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{OutputPath: inputPath + ".out", InputSize: info.Size(), OutputSize: info.Size() / 2}, nil
+}
+
+func testManager(t *testing.T, conv Converter) (*Manager, *storage.ConversionRepository) {
+	t.Helper()
+	db, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo := storage.NewConversionRepository(db)
+	m := NewManager(repo, conv, 2)
+	t.Cleanup(m.Stop)
+	return m, repo
+}
+
+func writeInputs(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, 1000), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func waitForBatch(t *testing.T, m *Manager, batchID string) *BatchStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		stats, err := m.GetBatchStats(batchID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Done() {
+			return stats
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("batch %s never finished", batchID)
+	return nil
+}
+
+func TestNewManagerBoundsWorkers(t *testing.T) {
+	if m := NewManager(nil, nil, 0); m.workers != 1 {
+		t.Fatalf("workers = %d, want 1", m.workers)
+	}
+	if m := NewManager(nil, nil, 10000); m.workers != runtime.NumCPU() {
+		t.Fatalf("workers = %d, want %d", m.workers, runtime.NumCPU())
+	}
+}
+
+func TestAddBatchContinuesOnError(t *testing.T) {
+	m, _ := testManager(t, &fakeConverter{})
+	m.Start()
+
+	inputs := writeInputs(t, "a.mp4", "broken.mp4", "b.mp4")
+	inputs = append(inputs, filepath.Join(t.TempDir(), "missing.mp4"))
+	batch, err := m.AddBatch(KindVideo, inputs, Options{Quality: "medium"})
+	if err != nil {
+		t.Fatalf("AddBatch() error: %v", err)
+	}
+	if len(batch.Conversions) != 4 || batch.Conversions[3].Status != storage.ConversionFailed {
+		t.Fatalf("missing input should be failed on arrival: %#v", batch.Conversions)
+	}
+
+	stats := waitForBatch(t, m, batch.ID)
+	if stats.Total != 4 || stats.Completed != 2 || stats.Failed != 2 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+	if stats.InputSize != 2000 || stats.OutputSize != 1000 || stats.Compression != 50 {
+		t.Fatalf("stats should only count completed files: %#v", stats)
+	}
+
+	conversions, err := m.GetBatch(batch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conversions[1].ErrorMessage == "" || conversions[0].OutputPath != inputs[0]+".out" {
+		t.Fatalf("per-file results not stored: %#v %#v", conversions[0], conversions[1])
+	}
+	var opts Options
+	if err := json.Unmarshal(conversions[0].Options, &opts); err != nil || opts.Quality != "medium" {
+		t.Fatalf("options not stored with the file: %s", conversions[0].Options)
+	}
+}
+
+func TestAddBatchReservesDistinctOutputNames(t *testing.T) {
+	m, _ := testManager(t, &fakeConverter{})
+	first := writeInputs(t, "a.mp4", "a.mkv")
+	second := writeInputs(t, "A.mov")
+	output := t.TempDir()
+	if err := os.WriteFile(filepath.Join(output, "a_converted.mp4"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := m.AddBatch(KindVideo, append(first, second...), Options{OutputDir: output, Format: "mp4"})
+	if err != nil {
+		t.Fatalf("AddBatch() error: %v", err)
+	}
+	var names []string
+	for _, record := range batch.Conversions {
+		var opts Options
+		if err := json.Unmarshal(record.Options, &opts); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, opts.OutputName)
+	}
+	if strings.Join(names, ",") != "a_converted_2,a_converted_3,A_converted_4" {
+		t.Fatalf("output names = %v", names)
+	}
+}
+
+func TestAddBatchValidatesOptions(t *testing.T) {
+	m, _ := testManager(t, &fakeConverter{})
+	inputs := writeInputs(t, "a.wav")
+
+	if _, err := m.AddBatch("gif", inputs, Options{}); err == nil {
+		t.Fatal("expected unknown kind to be rejected")
+	}
+	if _, err := m.AddBatch(KindAudio, inputs, Options{}); err == nil {
+		t.Fatal("expected audio batch without format to be rejected")
+	}
+	if _, err := m.AddBatch(KindAudio, nil, Options{Format: "mp3"}); err == nil {
+		t.Fatal("expected empty batch to be rejected")
+	}
+}
+
+func TestCancelBatch(t *testing.T) {
+	conv := &fakeConverter{block: make(chan struct{})}
+	m, _ := testManager(t, conv)
+	m.workers = 1
+	m.Start()
+
+	batch, err := m.AddBatch(KindImage, writeInputs(t, "a.png", "b.png", "c.png"), Options{Format: "webp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CancelBatch(batch.ID); err != nil {
+		t.Fatalf("CancelBatch() error: %v", err)
+	}
+	stats := waitForBatch(t, m, batch.ID)
+	if stats.Cancelled != 3 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+}
+
+func TestRestorePendingJobs(t *testing.T) {
+	m, repo := testManager(t, &fakeConverter{})
+	input := writeInputs(t, "song.flac")[0]
+	options, _ := json.Marshal(Options{Format: "mp3"})
+	for _, status := range []storage.ConversionStatus{storage.ConversionPending, storage.ConversionProcessing} {
+		record := &storage.Conversion{BatchID: "previous", Kind: KindAudio, InputPath: input, Options: options, Status: status}
+		if err := repo.Create(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.Start()
+	stats := waitForBatch(t, m, "previous")
+	if stats.Completed != 2 {
+		t.Fatalf("interrupted conversions were not resumed: %#v", stats)
+	}
+}
@@ -0,0 +1,328 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kingo/internal/converter"
+	"kingo/internal/storage"
+)
+
+// presetExportVersion is written into exported files so the format can evolve.
+const presetExportVersion = 1
+
+// Preset is a named kind plus options. Built-in presets cannot be edited or
+// deleted, and user presets cannot take their names.
+type Preset struct {
+	ID          string  `json:"id"` // Empty for built-ins
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Options     Options `json:"options"`
+	BuiltIn     bool    `json:"builtIn"`
+}
+
+// builtinPresets cover the common destinations. Their names are part of the
+// API: downloads and saved batches reference them.
+var builtinPresets = []Preset{
+	{
+		Name:        "WhatsApp 720p",
+		Kind:        KindVideo,
+		Description: "MP4 H.264 em 720p, leve para mensageiros",
+		Options:     Options{Format: "mp4", Quality: "low", Preset: "fast", Resolution: "1280x720", KeepAudio: true},
+	},
+	{
+		Name:        "E-mail 25 MB",
+		Kind:        KindVideo,
+		Description: "MP4 em até 25 MB, em duas passadas",
+		Options:     Options{Format: "mp4", Preset: "medium", Resolution: "1280x720", KeepAudio: true, TargetSizeMB: 24},
+	},
+	{
+		Name:        "Archive HEVC",
+		Kind:        KindVideo,
+		Description: "MKV HEVC 10-bit com áudio FLAC, para guardar com pouca perda",
+		Options: Options{Format: "mkv", Quality: "high", Preset: "slow", KeepAudio: true,
+			VideoCodec: "hevc", AudioCodec: "flac", TenBit: true, KeyframeInterval: 5},
+	},
+	{
+		Name:        "Podcast mono 64k",
+		Kind:        KindAudio,
+		Description: "MP3 mono a 64 kbps para voz",
+		Options:     Options{Format: "mp3", CustomBitrate: 64, Channels: 1},
+	},
+	{
+		Name:        "Música MP3 320k",
+		Kind:        KindAudio,
+		Description: "MP3 estéreo na maior taxa",
+		Options:     Options{Format: "mp3", CustomBitrate: 320},
+	},
+	{
+		Name:        "Web WebP",
+		Kind:        KindImage,
+		Description: "WebP até 1920 px de largura para sites",
+		Options:     Options{Format: "webp", ImageQuality: 80, Width: 1920},
+	},
+}
+
+// PresetStore combines the built-in presets with the ones saved by the user.
+type PresetStore struct {
+	repo *storage.ConversionPresetRepository
+}
+
+// NewPresetStore creates a preset store. A nil repository serves only the
+// built-in presets.
+func NewPresetStore(repo *storage.ConversionPresetRepository) *PresetStore {
+	return &PresetStore{repo: repo}
+}
+
+// List returns the built-in presets followed by the user's, by name.
+func (s *PresetStore) List() ([]Preset, error) {
+	presets := BuiltinPresets()
+	if s.repo == nil {
+		return presets, nil
+	}
+	records, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		preset, err := presetFromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		presets = append(presets, preset)
+	}
+	return presets, nil
+}
+
+// BuiltinPresets returns a copy of the presets shipped with the app.
+func BuiltinPresets() []Preset {
+	presets := make([]Preset, len(builtinPresets))
+	copy(presets, builtinPresets)
+	for i := range presets {
+		presets[i].BuiltIn = true
+	}
+	return presets
+}
+
+// Get finds a preset by name, ignoring case.
+func (s *PresetStore) Get(name string) (*Preset, error) {
+	name = strings.TrimSpace(name)
+	if builtin := builtinPreset(name); builtin != nil {
+		return builtin, nil
+	}
+	if s.repo != nil {
+		record, err := s.repo.GetByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			preset, err := presetFromRecord(record)
+			if err != nil {
+				return nil, err
+			}
+			return &preset, nil
+		}
+	}
+	return nil, fmt.Errorf("conversion preset not found: %s", name)
+}
+
+// Save creates the preset when it has no ID and updates it otherwise.
+func (s *PresetStore) Save(preset Preset) (*Preset, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("conversion presets are not available")
+	}
+	preset.Name = strings.TrimSpace(preset.Name)
+	if builtinPreset(preset.Name) != nil {
+		return nil, fmt.Errorf("%q is a built-in preset; choose another name", preset.Name)
+	}
+	if err := validatePreset(preset); err != nil {
+		return nil, err
+	}
+	record, err := presetRecord(preset)
+	if err != nil {
+		return nil, err
+	}
+	if record.ID == "" {
+		err = s.repo.Create(record)
+	} else {
+		err = s.repo.Update(record)
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved, err := presetFromRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// Delete removes a user preset.
+func (s *PresetStore) Delete(id string) error {
+	if s.repo == nil {
+		return fmt.Errorf("conversion presets are not available")
+	}
+	return s.repo.Delete(id)
+}
+
+// presetFile is the format of exported presets.
+type presetFile struct {
+	Version int      `json:"version"`
+	Presets []Preset `json:"presets"`
+}
+
+// Export writes the user presets with the given IDs, or all of them when ids
+// is empty, to a JSON file. Built-ins are never exported.
+func (s *PresetStore) Export(path string, ids []string) (int, error) {
+	presets, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	file := presetFile{Version: presetExportVersion, Presets: []Preset{}}
+	for _, preset := range presets {
+		if preset.BuiltIn || (len(ids) > 0 && !wanted[preset.ID]) {
+			continue
+		}
+		preset.ID = ""
+		preset.Options.OutputDir = "" // Folders are machine-specific
+		file.Presets = append(file.Presets, preset)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return 0, fmt.Errorf("write presets: %w", err)
+	}
+	return len(file.Presets), nil
+}
+
+// ImportResult lists what happened to each preset of an imported file.
+type ImportResult struct {
+	Created []string       `json:"created"`
+	Updated []string       `json:"updated"` // A user preset with the same name was replaced
+	Failed  []ImportFailed `json:"failed"`
+}
+
+// ImportFailed is a preset that could not be imported.
+type ImportFailed struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// Import reads presets exported by Export. Presets named like an existing
+// user preset replace it; invalid ones and built-in names are reported.
+func (s *PresetStore) Import(path string) (*ImportResult, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("conversion presets are not available")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read presets: %w", err)
+	}
+	var file presetFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s is not a preset file: %w", filepath.Base(path), err)
+	}
+	if file.Version > presetExportVersion {
+		return nil, fmt.Errorf("preset file version %d is newer than supported (%d)", file.Version, presetExportVersion)
+	}
+
+	result := &ImportResult{Created: []string{}, Updated: []string{}, Failed: []ImportFailed{}}
+	for _, preset := range file.Presets {
+		preset.ID = ""
+		preset.BuiltIn = false
+		existing, err := s.repo.GetByName(preset.Name)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			preset.ID = existing.ID
+		}
+		if _, err := s.Save(preset); err != nil {
+			result.Failed = append(result.Failed, ImportFailed{Name: preset.Name, Error: err.Error()})
+			continue
+		}
+		if existing != nil {
+			result.Updated = append(result.Updated, preset.Name)
+		} else {
+			result.Created = append(result.Created, preset.Name)
+		}
+	}
+	return result, nil
+}
+
+// PresetConverter converts single files with a named preset, outside the
+// queue. The post-download pipeline uses it.
+type PresetConverter struct {
+	presets   *PresetStore
+	converter Converter
+}
+
+// NewPresetConverter creates a converter for named presets.
+func NewPresetConverter(presets *PresetStore, conv Converter) *PresetConverter {
+	return &PresetConverter{presets: presets, converter: conv}
+}
+
+// ConvertWithPreset converts inputPath into outputDir with the named preset.
+// An empty outputName keeps the input's base name.
+func (p *PresetConverter) ConvertWithPreset(ctx context.Context, name, inputPath, outputDir, outputName string, onProgress converter.ProgressFunc) (*Output, error) {
+	preset, err := p.presets.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	opts := preset.Options
+	opts.OutputDir = outputDir
+	opts.OutputName = outputName
+	if opts.OutputName == "" {
+		opts.OutputName = strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	}
+	return p.converter.Convert(ctx, preset.Kind, inputPath, opts, onProgress)
+}
+
+func builtinPreset(name string) *Preset {
+	for _, preset := range BuiltinPresets() {
+		if strings.EqualFold(preset.Name, name) {
+			return &preset
+		}
+	}
+	return nil
+}
+
+func validatePreset(preset Preset) error {
+	if preset.Name == "" {
+		return fmt.Errorf("conversion preset name is required")
+	}
+	return validateOptions(preset.Kind, preset.Options)
+}
+
+func presetRecord(preset Preset) (*storage.ConversionPreset, error) {
+	options, err := json.Marshal(preset.Options)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.ConversionPreset{
+		ID:          preset.ID,
+		Name:        preset.Name,
+		Kind:        preset.Kind,
+		Description: strings.TrimSpace(preset.Description),
+		Options:     options,
+	}, nil
+}
+
+func presetFromRecord(record *storage.ConversionPreset) (Preset, error) {
+	preset := Preset{ID: record.ID, Name: record.Name, Kind: record.Kind, Description: record.Description}
+	if err := json.Unmarshal(record.Options, &preset.Options); err != nil {
+		return Preset{}, fmt.Errorf("stored preset %q is corrupt: %w", record.Name, err)
+	}
+	return preset, nil
+}
@@ -0,0 +1,135 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kingo/internal/storage"
+)
+
+func testPresetStore(t *testing.T) *PresetStore {
+	t.Helper()
+	db, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewPresetStore(storage.NewConversionPresetRepository(db))
+}
+
+func TestBuiltinPresetsAreValid(t *testing.T) {
+	for _, preset := range BuiltinPresets() {
+		if !preset.BuiltIn {
+			t.Fatalf("%s should be marked built-in", preset.Name)
+		}
+		if err := validatePreset(preset); err != nil {
+			t.Fatalf("built-in preset %q is invalid: %v", preset.Name, err)
+		}
+	}
+}
+
+func TestPresetStoreSaveAndGet(t *testing.T) {
+	store := testPresetStore(t)
+
+	if _, err := store.Save(Preset{Name: "whatsapp 720P", Kind: KindVideo, Options: Options{Format: "mp4"}}); err == nil {
+		t.Fatal("expected built-in name to be reserved")
+	}
+	if _, err := store.Save(Preset{Name: "Voice", Kind: KindAudio}); err == nil {
+		t.Fatal("expected audio preset without format to be rejected")
+	}
+
+	saved, err := store.Save(Preset{Name: " Voice ", Kind: KindAudio, Options: Options{Format: "opus", CustomBitrate: 48}})
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if saved.ID == "" || saved.Name != "Voice" || saved.BuiltIn {
+		t.Fatalf("unexpected saved preset: %#v", saved)
+	}
+
+	saved.Options.CustomBitrate = 32
+	if _, err := store.Save(*saved); err != nil {
+		t.Fatalf("Save() update error: %v", err)
+	}
+	got, err := store.Get("VOICE")
+	if err != nil || got.Options.CustomBitrate != 32 {
+		t.Fatalf("Get() = %#v, %v", got, err)
+	}
+	if got, err := store.Get("Podcast mono 64k"); err != nil || !got.BuiltIn || got.Options.Channels != 1 {
+		t.Fatalf("Get() built-in = %#v, %v", got, err)
+	}
+	if _, err := store.Get("missing"); err == nil {
+		t.Fatal("expected unknown preset to fail")
+	}
+
+	presets, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) != len(builtinPresets)+1 || presets[len(presets)-1].Name != "Voice" {
+		t.Fatalf("List() should end with the user preset: %#v", presets)
+	}
+}
+
+func TestPresetExportImport(t *testing.T) {
+	source := testPresetStore(t)
+	if _, err := source.Save(Preset{Name: "Thumb", Kind: KindImage, Options: Options{Format: "jpg", Width: 320, OutputDir: "/home/me"}}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "presets.json")
+	count, err := source.Export(path, nil)
+	if err != nil || count != 1 {
+		t.Fatalf("Export() = %d, %v", count, err)
+	}
+
+	// Hand-edited files may carry invalid entries and built-in names.
+	var file presetFile
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Presets[0].Options.OutputDir != "" {
+		t.Fatal("output folders should not be exported")
+	}
+	file.Presets = append(file.Presets,
+		Preset{Name: "Web WebP", Kind: KindImage, Options: Options{Format: "webp"}},
+		Preset{Name: "Bad", Kind: "gif"},
+	)
+	data, _ = json.Marshal(file)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	target := testPresetStore(t)
+	if _, err := target.Save(Preset{Name: "thumb", Kind: KindImage, Options: Options{Format: "png"}}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := target.Import(path)
+	if err != nil {
+		t.Fatalf("Import() error: %v", err)
+	}
+	if len(result.Updated) != 1 || len(result.Created) != 0 || len(result.Failed) != 2 {
+		t.Fatalf("unexpected import result: %#v", result)
+	}
+	got, err := target.Get("Thumb")
+	if err != nil || got.Options.Format != "jpg" || got.Options.Width != 320 {
+		t.Fatalf("imported preset not applied: %#v, %v", got, err)
+	}
+}
+
+func TestConvertWithPreset(t *testing.T) {
+	conv := NewPresetConverter(testPresetStore(t), &fakeConverter{})
+	input := writeInputs(t, "talk.wav")[0]
+	output, err := conv.ConvertWithPreset(context.Background(), "podcast mono 64k", input, "", "", nil)
+	if err != nil {
+		t.Fatalf("ConvertWithPreset() error: %v", err)
+	}
+	if output.OutputSize != 500 {
+		t.Fatalf("unexpected output: %#v", output)
+	}
+	if _, err := conv.ConvertWithPreset(context.Background(), "missing", input, "", "", nil); err == nil {
+		t.Fatal("expected unknown preset to fail")
+	}
+}
@@ -0,0 +1,103 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"kingo/internal/converter"
+	"kingo/internal/storage"
+)
+
+// WatermarkPreset is a named watermark the user reuses across conversions.
+type WatermarkPreset struct {
+	ID        string              `json:"id"`
+	Name      string              `json:"name"`
+	Watermark converter.Watermark `json:"watermark"`
+}
+
+// WatermarkStore keeps the user's watermark presets.
+type WatermarkStore struct {
+	repo *storage.WatermarkPresetRepository
+}
+
+// NewWatermarkStore creates a watermark preset store.
+func NewWatermarkStore(repo *storage.WatermarkPresetRepository) *WatermarkStore {
+	return &WatermarkStore{repo: repo}
+}
+
+// List returns the saved watermarks by name.
+func (s *WatermarkStore) List() ([]WatermarkPreset, error) {
+	records, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	presets := make([]WatermarkPreset, 0, len(records))
+	for _, record := range records {
+		preset, err := watermarkFromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		presets = append(presets, preset)
+	}
+	return presets, nil
+}
+
+// Get finds a watermark by name, ignoring case.
+func (s *WatermarkStore) Get(name string) (*converter.Watermark, error) {
+	record, err := s.repo.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("watermark preset not found: %s", strings.TrimSpace(name))
+	}
+	preset, err := watermarkFromRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	return &preset.Watermark, nil
+}
+
+// Save creates the watermark when it has no ID and updates it otherwise.
+func (s *WatermarkStore) Save(preset WatermarkPreset) (*WatermarkPreset, error) {
+	preset.Name = strings.TrimSpace(preset.Name)
+	if preset.Name == "" {
+		return nil, fmt.Errorf("watermark preset name is required")
+	}
+	if !preset.Watermark.Enabled() {
+		return nil, fmt.Errorf("watermark needs an image or a text")
+	}
+	if err := preset.Watermark.Validate(); err != nil {
+		return nil, err
+	}
+	settings, err := json.Marshal(preset.Watermark)
+	if err != nil {
+		return nil, err
+	}
+	record := &storage.WatermarkPreset{ID: preset.ID, Name: preset.Name, Settings: settings}
+	if record.ID == "" {
+		err = s.repo.Create(record)
+	} else {
+		err = s.repo.Update(record)
+	}
+	if err != nil {
+		return nil, err
+	}
+	preset.ID = record.ID
+	preset.Name = record.Name
+	return &preset, nil
+}
+
+// Delete removes a watermark preset.
+func (s *WatermarkStore) Delete(id string) error {
+	return s.repo.Delete(id)
+}
+
+func watermarkFromRecord(record *storage.WatermarkPreset) (WatermarkPreset, error) {
+	preset := WatermarkPreset{ID: record.ID, Name: record.Name}
+	if err := json.Unmarshal(record.Settings, &preset.Watermark); err != nil {
+		return WatermarkPreset{}, fmt.Errorf("stored watermark %q is corrupt: %w", record.Name, err)
+	}
+	return preset, nil
+}
@@ -0,0 +1,299 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// AnimationFormat represents supported animated output formats
+type AnimationFormat string
+
+const (
+	AnimationFormatGIF  AnimationFormat = "gif"
+	AnimationFormatWebP AnimationFormat = "webp"
+)
+
+const (
+	defaultAnimationFPS     = 12
+	defaultAnimationWidth   = 480
+	defaultAnimationQuality = 75
+	minAnimationQuality     = 10
+	minAnimationWidth       = 120
+
+	// maxAnimationAttempts bounds the target-size search so an impossible
+	// target cannot keep FFmpeg running indefinitely.
+	maxAnimationAttempts = 8
+)
+
+// AnimationExportOptions configures a GIF/animated WebP export
+type AnimationExportOptions struct {
+	InputPath       string
+	OutputDir       string          // If empty, uses same directory as input
+	OutputPath      string          // Explicit destination, overrides OutputDir/CustomName
+	Format          AnimationFormat // gif or webp
+	StartTime       float64         // Range start in seconds
+	EndTime         float64         // Range end in seconds (0 = until the end)
+	FPS             int             // Frames per second (default 12)
+	Width           int             // Output width, height keeps aspect ratio (default 480)
+	Loop            int             // 0 = loop forever, N = play N times
+	Quality         int             // 1-100, drives palette size (GIF) or encoder quality (WebP)
+	TargetSizeBytes int64           // If > 0, lowers quality until the file fits
+	SubtitlesPath   string          // Optional ASS/SRT file burned into the frames (source time)
+	FFmpegPath      string
+	CustomName      string       // Custom output filename (without extension)
+	OnProgress      ProgressFunc // Optional; reported per attempt
+}
+
+// AnimationExportResult contains the result of an animation export
+type AnimationExportResult struct {
+	OutputPath string
+	InputSize  int64
+	OutputSize int64
+	Quality    int  // Quality used by the final attempt
+	Width      int  // Width used by the final attempt
+	Attempts   int  // Number of encodes performed
+	TargetMet  bool // True when no target was requested or the output fits it
+}
+
+// ExportAnimation renders a range of a video as an optimized GIF (two-stage
+// palettegen/paletteuse) or an animated WebP. With TargetSizeBytes set it
+// re-encodes with progressively lower quality, then smaller width, until the
+// file fits or the attempt budget is exhausted.
+func ExportAnimation(ctx context.Context, opts AnimationExportOptions) (*AnimationExportResult, error) {
+	if opts.FFmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg path is required")
+	}
+
+	inputInfo, err := os.Stat(opts.InputPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file does not exist: %s", opts.InputPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat input file: %w", err)
+	}
+
+	switch opts.Format {
+	case AnimationFormatGIF, AnimationFormatWebP:
+	default:
+		return nil, fmt.Errorf("unsupported animation format: %s", opts.Format)
+	}
+	if opts.StartTime < 0 {
+		opts.StartTime = 0
+	}
+	if opts.EndTime > 0 && opts.EndTime <= opts.StartTime {
+		return nil, fmt.Errorf("invalid animation range: end (%.2fs) must be after start (%.2fs)", opts.EndTime, opts.StartTime)
+	}
+	opts = normalizeAnimationOptions(opts)
+
+	outputPath := opts.OutputPath
+	if outputPath == "" {
+		inputExt := filepath.Ext(opts.InputPath)
+		baseName := strings.TrimSuffix(filepath.Base(opts.InputPath), inputExt)
+		outputDir := opts.OutputDir
+		if outputDir == "" {
+			outputDir = filepath.Dir(opts.InputPath)
+		}
+		if opts.CustomName != "" {
+			outputPath = safeOutputPath(outputDir, opts.CustomName, "", string(opts.Format))
+		} else {
+			outputPath = safeOutputPath(outputDir, baseName, "_animated", string(opts.Format))
+		}
+	}
+
+	duration := opts.EndTime - opts.StartTime
+	if opts.EndTime <= 0 {
+		duration = probeDuration(ctx, opts.FFmpegPath, opts.InputPath) - opts.StartTime
+	}
+	quality := opts.Quality
+	width := opts.Width
+	result := &AnimationExportResult{OutputPath: outputPath, InputSize: inputInfo.Size()}
+	for attempt := 1; attempt <= maxAnimationAttempts; attempt++ {
+		args := buildAnimationArgs(opts, quality, width, outputPath)
+		stage := fmt.Sprintf("attempt %d", attempt)
+		if err := runFFmpeg(ctx, opts.FFmpegPath, args, stage, duration, opts.OnProgress); err != nil {
+			os.Remove(outputPath)
+			return nil, err
+		}
+
+		outputInfo, err := os.Stat(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat output file: %w", err)
+		}
+		result.OutputSize = outputInfo.Size()
+		result.Quality = quality
+		result.Width = width
+		result.Attempts = attempt
+
+		if opts.TargetSizeBytes <= 0 || result.OutputSize <= opts.TargetSizeBytes {
+			result.TargetMet = true
+			break
+		}
+		var ok bool
+		quality, width, ok = nextAnimationStep(quality, width, result.OutputSize, opts.TargetSizeBytes)
+		if !ok {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// normalizeAnimationOptions applies defaults and clamps user-supplied values.
+func normalizeAnimationOptions(opts AnimationExportOptions) AnimationExportOptions {
+	if opts.FPS <= 0 {
+		opts.FPS = defaultAnimationFPS
+	}
+	if opts.FPS > 50 {
+		opts.FPS = 50
+	}
+	if opts.Width <= 0 {
+		opts.Width = defaultAnimationWidth
+	}
+	if opts.Width < minAnimationWidth {
+		opts.Width = minAnimationWidth
+	}
+	if opts.Width > 1920 {
+		opts.Width = 1920
+	}
+	if opts.Quality <= 0 {
+		opts.Quality = defaultAnimationQuality
+	}
+	if opts.Quality > 100 {
+		opts.Quality = 100
+	}
+	if opts.Loop < 0 {
+		opts.Loop = 0
+	}
+	return opts
+}
+
+// nextAnimationStep lowers quality first and only shrinks the frame once the
+// quality floor is reached. Large overshoots take bigger steps so typical
+// targets are reached within a few encodes.
+func nextAnimationStep(quality, width int, size, target int64) (int, int, bool) {
+	step := 10
+	if size > target*2 {
+		step = 25
+	}
+	if quality > minAnimationQuality {
+		return max(minAnimationQuality, quality-step), width, true
+	}
+	if width > minAnimationWidth {
+		// Keep even widths; both encoders and chroma subsampling prefer them.
+		next := max(minAnimationWidth, width*4/5) &^ 1
+		return quality, next, true
+	}
+	return quality, width, false
+}
+
+// animationPaletteColors maps quality (1-100) to the GIF palette size.
+func animationPaletteColors(quality int) int {
+	colors := 16 + (quality*240)/100
+	return min(256, max(16, colors))
+}
+
+// buildAnimationArgs builds the single-pass FFmpeg command. GIF uses a split
+// graph so the palette is generated and applied from the same decoded frames.
+func buildAnimationArgs(opts AnimationExportOptions, quality, width int, outputPath string) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-y"}
+	if opts.StartTime > 0 {
+		args = append(args, "-ss", formatSeconds(opts.StartTime))
+	}
+	if opts.EndTime > 0 {
+		args = append(args, "-t", formatSeconds(opts.EndTime-opts.StartTime))
+	}
+	args = append(args, "-i", opts.InputPath)
+
+	filters := make([]string, 0, 5)
+	if opts.SubtitlesPath != "" {
+		// Input seeking resets timestamps to zero; shift them back to source time
+		// so the subtitle file lines up, then restart the clock for the output.
+		if opts.StartTime > 0 {
+			filters = append(filters, fmt.Sprintf("setpts=PTS+%s/TB", formatSeconds(opts.StartTime)))
+		}
+		filters = append(filters, subtitleFilter(opts.SubtitlesPath))
+		if opts.StartTime > 0 {
+			filters = append(filters, "setpts=PTS-STARTPTS")
+		}
+	}
+	filters = append(filters,
+		fmt.Sprintf("fps=%d", opts.FPS),
+		fmt.Sprintf("scale=%d:-2:flags=lanczos", width),
+	)
+	chain := strings.Join(filters, ",")
+
+	switch opts.Format {
+	case AnimationFormatGIF:
+		graph := fmt.Sprintf("[0:v]%s,split[a][b];[a]palettegen=max_colors=%d:stats_mode=diff[p];[b][p]paletteuse=dither=bayer:bayer_scale=%d:diff_mode=rectangle",
+			chain, animationPaletteColors(quality), gifBayerScale(quality))
+		args = append(args, "-filter_complex", graph, "-an", "-loop", strconv.Itoa(gifLoopValue(opts.Loop)))
+	case AnimationFormatWebP:
+		args = append(args,
+			"-vf", chain, "-an",
+			"-c:v", "libwebp", "-lossless", "0",
+			"-quality", strconv.Itoa(quality),
+			"-compression_level", "6",
+			"-loop", strconv.Itoa(opts.Loop),
+		)
+	}
+	return append(args, outputPath)
+}
+
+// gifLoopValue converts "play N times" into the GIF muxer convention where
+// -1 disables looping and N repeats the animation N extra times.
+func gifLoopValue(plays int) int {
+	switch {
+	case plays <= 0:
+		return 0
+	case plays == 1:
+		return -1
+	default:
+		return plays - 1
+	}
+}
+
+// gifBayerScale trades dithering detail for smaller files at low quality.
+func gifBayerScale(quality int) int {
+	switch {
+	case quality >= 80:
+		return 2
+	case quality >= 50:
+		return 3
+	case quality >= 30:
+		return 4
+	default:
+		return 5
+	}
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+// subtitleFilter selects the ASS renderer for styled tracks and the generic
+// subtitles filter for SRT/VTT sidecars.
+func subtitleFilter(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".ass") {
+		return "ass=filename=" + escapeFilterPath(path)
+	}
+	return "subtitles=filename=" + escapeFilterPath(path)
+}
+
+// escapeFilterPath quotes a path for use inside an FFmpeg filtergraph.
+func escapeFilterPath(path string) string {
+	path = filepath.ToSlash(path)
+	path = strings.NewReplacer(
+		"\\", "\\\\",
+		":", "\\:",
+		"'", "\\'",
+		"[", "\\[",
+		"]", "\\]",
+		",", "\\,",
+		";", "\\;",
+	).Replace(path)
+	return "'" + path + "'"
+}
@@ -0,0 +1,67 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildAnimationArgsGIFUsesPaletteGraph(t *testing.T) {
+	opts := normalizeAnimationOptions(AnimationExportOptions{
+		InputPath: "in.mp4",
+		Format:    AnimationFormatGIF,
+		StartTime: 2,
+		EndTime:   5.5,
+		Loop:      1,
+	})
+	args := strings.Join(buildAnimationArgs(opts, 75, 480, "out.gif"), " ")
+	for _, want := range []string{
+		"-ss 2.000 -t 3.500 -i in.mp4",
+		"fps=12,scale=480:-2:flags=lanczos,split[a][b]",
+		"palettegen=max_colors=196",
+		"paletteuse=dither=bayer:bayer_scale=3",
+		"-loop -1 out.gif",
+	} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("args missing %q:\n%s", want, args)
+		}
+	}
+}
+
+func TestBuildAnimationArgsShiftsSubtitlesToSourceTime(t *testing.T) {
+	opts := normalizeAnimationOptions(AnimationExportOptions{
+		InputPath:     "in.mp4",
+		Format:        AnimationFormatWebP,
+		StartTime:     10,
+		SubtitlesPath: "captions.ass",
+	})
+	args := buildAnimationArgs(opts, 60, 320, "out.webp")
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "setpts=PTS+10.000/TB,ass=filename='captions.ass',setpts=PTS-STARTPTS,fps=12") {
+		t.Fatalf("subtitle chain not aligned to source time:\n%s", joined)
+	}
+	if !strings.Contains(joined, "-c:v libwebp -lossless 0 -quality 60") || !strings.Contains(joined, "-loop 0 out.webp") {
+		t.Fatalf("webp encoder args missing:\n%s", joined)
+	}
+}
+
+func TestNextAnimationStepLowersQualityBeforeWidth(t *testing.T) {
+	quality, width, ok := nextAnimationStep(75, 480, 3_000_000, 1_000_000)
+	if !ok || quality != 50 || width != 480 {
+		t.Fatalf("large overshoot step = %d/%d/%v", quality, width, ok)
+	}
+	quality, width, ok = nextAnimationStep(minAnimationQuality, 480, 1_100_000, 1_000_000)
+	if !ok || quality != minAnimationQuality || width != 384 {
+		t.Fatalf("width step = %d/%d/%v", quality, width, ok)
+	}
+	if _, _, ok = nextAnimationStep(minAnimationQuality, minAnimationWidth, 1_100_000, 1_000_000); ok {
+		t.Fatal("expected search to stop at the quality and width floors")
+	}
+}
+
+func TestGIFLoopValue(t *testing.T) {
+	for plays, want := range map[int]int{0: 0, 1: -1, 3: 2} {
+		if got := gifLoopValue(plays); got != want {
+			t.Fatalf("gifLoopValue(%d) = %d, want %d", plays, got, want)
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -38,8 +38,11 @@ type AudioExtractOptions struct {
 	Format        AudioFormat  // Target audio format
 	Quality       AudioQuality // Bitrate quality
 	CustomBitrate int          // Custom bitrate in kbps, overrides Quality if > 0
+	Channels      int          // 1 = mono, 2 = stereo, 0 keeps the source layout
 	FFmpegPath    string
-	CustomName    string // Custom output filename (without extension)
+	CustomName    string       // Custom output filename (without extension)
+	OnProgress    ProgressFunc // Optional progress callback
+	Speed         *Speed       // Optional speed change or reverse; Interpolate is ignored
 }
 
 // AudioExtractResult contains the result of audio extraction
@@ -51,7 +54,8 @@ type AudioExtractResult struct {
 }
 
 // ExtractAudio extracts audio from a video file and converts to specified format.
-func ExtractAudio(opts AudioExtractOptions) (*AudioExtractResult, error) {
+// Cancelling ctx stops FFmpeg and removes the partial output.
+func ExtractAudio(ctx context.Context, opts AudioExtractOptions) (*AudioExtractResult, error) {
 	if opts.FFmpegPath == "" {
 		return nil, fmt.Errorf("ffmpeg path is required")
 	}
@@ -65,6 +69,9 @@ func ExtractAudio(opts AudioExtractOptions) (*AudioExtractResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat input file: %w", err)
 	}
+	if err := opts.Speed.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Build output path
 	inputExt := filepath.Ext(opts.InputPath)
@@ -81,46 +88,48 @@ func ExtractAudio(opts AudioExtractOptions) (*AudioExtractResult, error) {
 		outputPath = filepath.Join(outputDir, baseName+"."+string(opts.Format))
 	}
 
+	media := probeMedia(ctx, opts.FFmpegPath, opts.InputPath)
+	if media != nil && !media.HasAudio() {
+		return nil, fmt.Errorf("input has no audio stream: %s", filepath.Base(opts.InputPath))
+	}
+
+	inputPath := opts.InputPath
+	if opts.Speed.reverses() {
+		reversed, cleanup, err := reversedInput(ctx, opts.FFmpegPath, opts.InputPath, outputDir, ReverseAudio, opts.OnProgress)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		inputPath = reversed
+	}
+
 	// Build FFmpeg arguments
-	args := []string{"-i", opts.InputPath, "-y", "-vn"} // -vn = no video
+	args := []string{"-i", inputPath, "-y", "-vn"} // -vn = no video
 
-	// Get bitrate
-	bitrate := getBitrateValue(opts.Quality)
+	// Get bitrate; presets never exceed the source bitrate
+	bitrate := sourceAudioBitrate(media, getBitrateValue(opts.Quality))
 	if opts.CustomBitrate > 0 {
 		bitrate = opts.CustomBitrate
 	}
 
-	// Audio codec based on format
-	switch opts.Format {
-	case AudioFormatMP3:
-		args = append(args, "-c:a", "libmp3lame", "-b:a", fmt.Sprintf("%dk", bitrate))
-	case AudioFormatAAC, AudioFormatM4A:
-		args = append(args, "-c:a", "aac", "-b:a", fmt.Sprintf("%dk", bitrate))
-	case AudioFormatFLAC:
-		// FLAC is lossless, no bitrate needed
-		args = append(args, "-c:a", "flac")
-	case AudioFormatWAV:
-		// WAV is uncompressed PCM
-		args = append(args, "-c:a", "pcm_s16le")
-	case AudioFormatOGG:
-		// Vorbis uses quality scale instead of bitrate
-		quality := getVorbisQuality(bitrate)
-		args = append(args, "-c:a", "libvorbis", "-q:a", fmt.Sprintf("%d", quality))
-	case AudioFormatOPUS:
-		args = append(args, "-c:a", "libopus", "-b:a", fmt.Sprintf("%dk", bitrate))
-	default:
-		return nil, fmt.Errorf("unsupported audio format: %s", opts.Format)
+	codecArgs, err := audioFormatArgs(opts.Format, bitrate)
+	if err != nil {
+		return nil, err
 	}
+	args = append(args, codecArgs...)
+
+	if opts.Channels > 0 {
+		args = append(args, "-ac", fmt.Sprintf("%d", opts.Channels))
+	}
+	args = append(args, opts.Speed.outputArgs()...)
 
 	args = append(args, outputPath)
 
 	// Execute FFmpeg
-	cmd := exec.Command(opts.FFmpegPath, args...)
-	setSysProcAttr(cmd)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("ffmpeg error: %v | output: %s", err, string(output))
+	duration := opts.Speed.OutputDuration(mediaDuration(media))
+	if err := runFFmpeg(ctx, opts.FFmpegPath, args, "extracting", duration, opts.OnProgress); err != nil {
+		os.Remove(outputPath)
+		return nil, err
 	}
 
 	// Get output file size
@@ -133,9 +142,33 @@ func ExtractAudio(opts AudioExtractOptions) (*AudioExtractResult, error) {
 		OutputPath: outputPath,
 		InputSize:  inputInfo.Size(),
 		OutputSize: outputInfo.Size(),
+		Duration:   duration,
 	}, nil
 }
 
+// audioFormatArgs returns the encoder arguments of an audio file format.
+func audioFormatArgs(format AudioFormat, bitrate int) ([]string, error) {
+	switch format {
+	case AudioFormatMP3:
+		return []string{"-c:a", "libmp3lame", "-b:a", fmt.Sprintf("%dk", bitrate)}, nil
+	case AudioFormatAAC, AudioFormatM4A:
+		return []string{"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", bitrate)}, nil
+	case AudioFormatFLAC:
+		// FLAC is lossless, no bitrate needed
+		return []string{"-c:a", "flac"}, nil
+	case AudioFormatWAV:
+		// WAV is uncompressed PCM
+		return []string{"-c:a", "pcm_s16le"}, nil
+	case AudioFormatOGG:
+		// Vorbis uses quality scale instead of bitrate
+		return []string{"-c:a", "libvorbis", "-q:a", fmt.Sprintf("%d", getVorbisQuality(bitrate))}, nil
+	case AudioFormatOPUS:
+		return []string{"-c:a", "libopus", "-b:a", fmt.Sprintf("%dk", bitrate)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported audio format: %s", format)
+	}
+}
+
 // getBitrateValue converts quality preset to bitrate in kbps
 func getBitrateValue(quality AudioQuality) int {
 	switch quality {
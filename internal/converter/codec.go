@@ -0,0 +1,437 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// VideoCodec is the video encoder family of a conversion.
+type VideoCodec string
+
+const (
+	VideoCodecH264  VideoCodec = "h264"  // libx264
+	VideoCodecHEVC  VideoCodec = "hevc"  // libx265
+	VideoCodecAV1   VideoCodec = "av1"   // libsvtav1
+	VideoCodecVP9   VideoCodec = "vp9"   // libvpx-vp9
+	VideoCodecMPEG4 VideoCodec = "mpeg4" // MPEG-4 Part 2, the AVI default
+)
+
+// AudioCodec is the audio encoder of a video conversion.
+type AudioCodec string
+
+const (
+	AudioCodecAAC    AudioCodec = "aac"
+	AudioCodecMP3    AudioCodec = "mp3"
+	AudioCodecOpus   AudioCodec = "opus"
+	AudioCodecVorbis AudioCodec = "vorbis"
+	AudioCodecFLAC   AudioCodec = "flac"
+)
+
+// Pixel formats accepted by VideoConvertOptions.PixelFormat
+var pixelFormats = []string{"yuv420p", "yuv422p", "yuv444p", "yuv420p10le", "yuv422p10le", "yuv444p10le"}
+
+// videoCodecSpec describes how a codec is driven.
+type videoCodecSpec struct {
+	label        string
+	encoder      string
+	maxCRF       int
+	crf          [4]int   // high, medium, low, tiny
+	pixelFormats []string // Supported subset of pixelFormats
+}
+
+var videoCodecSpecs = map[VideoCodec]videoCodecSpec{
+	VideoCodecH264: {label: "H.264", encoder: "libx264", maxCRF: 51, crf: [4]int{18, 23, 28, 35}, pixelFormats: pixelFormats},
+	// x265 looks about as good as x264 at a CRF a few points higher.
+	VideoCodecHEVC:  {label: "HEVC (H.265)", encoder: "libx265", maxCRF: 51, crf: [4]int{20, 26, 30, 36}, pixelFormats: pixelFormats},
+	VideoCodecAV1:   {label: "AV1 (SVT-AV1)", encoder: "libsvtav1", maxCRF: 63, crf: [4]int{24, 32, 38, 46}, pixelFormats: []string{"yuv420p", "yuv420p10le"}},
+	VideoCodecVP9:   {label: "VP9", encoder: "libvpx-vp9", maxCRF: 63, crf: [4]int{24, 31, 36, 45}, pixelFormats: pixelFormats},
+	VideoCodecMPEG4: {label: "MPEG-4 Part 2", encoder: "mpeg4", maxCRF: 51, crf: [4]int{18, 23, 28, 35}, pixelFormats: []string{"yuv420p"}},
+}
+
+var audioCodecEncoders = map[AudioCodec]string{
+	AudioCodecAAC:    "aac",
+	AudioCodecMP3:    "libmp3lame",
+	AudioCodecOpus:   "libopus",
+	AudioCodecVorbis: "libvorbis",
+	AudioCodecFLAC:   "flac",
+}
+
+// containerCodecs lists what each container can hold. The first entries are
+// the defaults used when no codec is chosen.
+var containerCodecs = map[VideoFormat]struct {
+	video []VideoCodec
+	audio []AudioCodec
+}{
+	VideoFormatMP4:  {video: []VideoCodec{VideoCodecH264, VideoCodecHEVC, VideoCodecAV1, VideoCodecVP9}, audio: []AudioCodec{AudioCodecAAC, AudioCodecMP3, AudioCodecOpus, AudioCodecFLAC}},
+	VideoFormatMOV:  {video: []VideoCodec{VideoCodecH264, VideoCodecHEVC}, audio: []AudioCodec{AudioCodecAAC, AudioCodecMP3}},
+	VideoFormatMKV:  {video: []VideoCodec{VideoCodecH264, VideoCodecHEVC, VideoCodecAV1, VideoCodecVP9, VideoCodecMPEG4}, audio: []AudioCodec{AudioCodecAAC, AudioCodecMP3, AudioCodecOpus, AudioCodecVorbis, AudioCodecFLAC}},
+	VideoFormatWebM: {video: []VideoCodec{VideoCodecVP9, VideoCodecAV1}, audio: []AudioCodec{AudioCodecOpus, AudioCodecVorbis}},
+	VideoFormatAVI:  {video: []VideoCodec{VideoCodecMPEG4, VideoCodecH264}, audio: []AudioCodec{AudioCodecMP3}},
+}
+
+// videoEncoding is the resolved codec setup of a video conversion.
+type videoEncoding struct {
+	codec          VideoCodec
+	spec           videoCodecSpec
+	audioCodec     AudioCodec
+	pixelFormat    string // Empty keeps the encoder's choice
+	keyframeGap    int    // Frames between keyframes; 0 keeps the encoder default
+	explicitCodecs bool   // A codec was chosen, so the FFmpeg build must be checked
+}
+
+// ValidateVideoOptions checks codec, container and encoder settings without
+// running FFmpeg. An empty Format skips the container checks.
+func ValidateVideoOptions(opts VideoConvertOptions) error {
+	_, err := resolveVideoEncoding(opts)
+	return err
+}
+
+func resolveVideoEncoding(opts VideoConvertOptions) (*videoEncoding, error) {
+	enc := &videoEncoding{codec: opts.VideoCodec, audioCodec: opts.AudioCodec}
+	enc.explicitCodecs = opts.VideoCodec != "" || opts.AudioCodec != ""
+	container, known := containerCodecs[opts.Format]
+	if opts.Format != "" && !known {
+		return nil, fmt.Errorf("unsupported output format: %s", opts.Format)
+	}
+
+	if enc.codec == "" {
+		enc.codec = VideoCodecH264
+		if known {
+			enc.codec = container.video[0]
+		}
+	}
+	spec, ok := videoCodecSpecs[enc.codec]
+	if !ok {
+		return nil, fmt.Errorf("unsupported video codec: %s", enc.codec)
+	}
+	enc.spec = spec
+	if known && !slices.Contains(container.video, enc.codec) {
+		return nil, fmt.Errorf("%s video cannot be stored in %s; use %s", spec.label, strings.ToUpper(string(opts.Format)), formatsFor(enc.codec))
+	}
+
+	if enc.audioCodec == "" && known {
+		enc.audioCodec = container.audio[0]
+	}
+	if enc.audioCodec != "" {
+		if _, ok := audioCodecEncoders[enc.audioCodec]; !ok {
+			return nil, fmt.Errorf("unsupported audio codec: %s", enc.audioCodec)
+		}
+		if known && !slices.Contains(container.audio, enc.audioCodec) {
+			return nil, fmt.Errorf("%s audio cannot be stored in %s", enc.audioCodec, strings.ToUpper(string(opts.Format)))
+		}
+	}
+	if opts.AudioBitrate < 0 || opts.AudioBitrate > 640 {
+		return nil, fmt.Errorf("audio bitrate must be between 0 and 640 kbps")
+	}
+
+	if opts.CustomCRF < 0 || opts.CustomCRF > spec.maxCRF {
+		return nil, fmt.Errorf("CRF for %s must be between 0 and %d", spec.label, spec.maxCRF)
+	}
+	if enc.codec == VideoCodecAV1 && opts.Quality == VideoQualityLossless && opts.CustomCRF == 0 && opts.TargetSizeBytes == 0 {
+		return nil, fmt.Errorf("%s has no lossless mode; use high quality instead", spec.label)
+	}
+
+	enc.pixelFormat = opts.PixelFormat
+	if enc.pixelFormat != "" && !slices.Contains(pixelFormats, enc.pixelFormat) {
+		return nil, fmt.Errorf("unsupported pixel format: %s", enc.pixelFormat)
+	}
+	if opts.TenBit {
+		if enc.pixelFormat == "" {
+			enc.pixelFormat = "yuv420p10le"
+		} else if !strings.HasSuffix(enc.pixelFormat, "10le") {
+			return nil, fmt.Errorf("pixel format %s is not 10-bit", enc.pixelFormat)
+		}
+	}
+	if enc.pixelFormat != "" && !slices.Contains(spec.pixelFormats, enc.pixelFormat) {
+		return nil, fmt.Errorf("%s does not support pixel format %s", spec.label, enc.pixelFormat)
+	}
+
+	if err := opts.Watermark.Validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.Speed.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.FPS < 0 || opts.FPS > 240 {
+		return nil, fmt.Errorf("frame rate must be between 0 and 240")
+	}
+	if opts.KeyframeInterval < 0 || opts.KeyframeInterval > 60 {
+		return nil, fmt.Errorf("keyframe interval must be between 0 and 60 seconds")
+	}
+	return enc, nil
+}
+
+// formatsFor lists the containers that can hold a codec, for error messages.
+func formatsFor(codec VideoCodec) string {
+	var formats []string
+	for _, format := range []VideoFormat{VideoFormatMP4, VideoFormatMKV, VideoFormatWebM, VideoFormatMOV, VideoFormatAVI} {
+		if slices.Contains(containerCodecs[format].video, codec) {
+			formats = append(formats, strings.ToUpper(string(format)))
+		}
+	}
+	return strings.Join(formats, " or ")
+}
+
+// setKeyframeGap converts the keyframe interval into frames using the output
+// frame rate, or the source's, or 30 fps when neither is known.
+func (e *videoEncoding) setKeyframeGap(opts VideoConvertOptions, sourceFPS float64) {
+	if opts.KeyframeInterval <= 0 {
+		return
+	}
+	fps := opts.FPS
+	if fps <= 0 {
+		fps = sourceFPS
+	}
+	if fps <= 0 {
+		fps = 30
+	}
+	e.keyframeGap = max(1, int(math.Round(opts.KeyframeInterval*fps)))
+}
+
+// crf returns the CRF of a quality level on the codec's own scale.
+func (e *videoEncoding) crf(quality VideoQuality, custom int) int {
+	if custom > 0 {
+		return custom
+	}
+	switch quality {
+	case VideoQualityLossless:
+		return 0
+	case VideoQualityHigh:
+		return e.spec.crf[0]
+	case VideoQualityLow:
+		return e.spec.crf[2]
+	case VideoQualityTiny:
+		return e.spec.crf[3]
+	default:
+		return e.spec.crf[1]
+	}
+}
+
+// encoderArgs selects the video encoder.
+func (e *videoEncoding) encoderArgs() []string {
+	args := []string{"-c:v", e.spec.encoder}
+	if e.codec == VideoCodecVP9 {
+		args = append(args, "-row-mt", "1")
+	}
+	return args
+}
+
+// qualityArgs sets constant-quality rate control. Lossless switches each
+// encoder to its own lossless mode.
+func (e *videoEncoding) qualityArgs(quality VideoQuality, custom int) []string {
+	crf := e.crf(quality, custom)
+	lossless := crf == 0 && custom == 0
+	switch e.codec {
+	case VideoCodecMPEG4:
+		// -q:v runs 1-31 with lower being better
+		return []string{"-q:v", fmt.Sprintf("%d", max(1, 31-(crf*31/51)))}
+	case VideoCodecHEVC:
+		if lossless {
+			return []string{"-x265-params", "lossless=1"}
+		}
+	case VideoCodecVP9:
+		if lossless {
+			return []string{"-lossless", "1", "-b:v", "0"}
+		}
+		return []string{"-crf", fmt.Sprintf("%d", crf), "-b:v", "0"} // -b:v 0 selects constant quality
+	}
+	return []string{"-crf", fmt.Sprintf("%d", crf)}
+}
+
+// speedArgs maps the x264 preset names onto each encoder's speed control.
+func (e *videoEncoding) speedArgs(preset string) []string {
+	if preset == "" {
+		preset = "medium"
+	}
+	switch e.codec {
+	case VideoCodecH264, VideoCodecHEVC:
+		return []string{"-preset", preset}
+	case VideoCodecAV1:
+		return []string{"-preset", fmt.Sprintf("%d", presetLevel(preset, [9]int{12, 11, 10, 9, 8, 7, 5, 4, 2}))}
+	case VideoCodecVP9:
+		return []string{"-deadline", "good", "-cpu-used", fmt.Sprintf("%d", presetLevel(preset, [9]int{5, 5, 5, 4, 3, 2, 1, 0, 0}))}
+	default:
+		return nil
+	}
+}
+
+// presetLevel picks the value for an x264 preset name from levels ordered
+// ultrafast to veryslow.
+func presetLevel(preset string, levels [9]int) int {
+	names := []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"}
+	if index := slices.Index(names, preset); index >= 0 {
+		return levels[index]
+	}
+	return levels[5]
+}
+
+// formatArgs sets pixel format, profile, keyframes and container tags.
+func (e *videoEncoding) formatArgs(format VideoFormat) []string {
+	var args []string
+	if e.pixelFormat != "" {
+		args = append(args, "-pix_fmt", e.pixelFormat)
+		if e.codec == VideoCodecVP9 {
+			// VP9 profiles: 0 = 8-bit 4:2:0, 1 = 8-bit 4:2:2/4:4:4, 2 and 3 the 10-bit ones
+			profile := 0
+			if strings.HasSuffix(e.pixelFormat, "10le") {
+				profile = 2
+			}
+			if !strings.HasPrefix(e.pixelFormat, "yuv420") {
+				profile++
+			}
+			args = append(args, "-profile:v", fmt.Sprintf("%d", profile))
+		}
+	}
+	if e.keyframeGap > 0 {
+		args = append(args, "-g", fmt.Sprintf("%d", e.keyframeGap))
+	}
+	if e.codec == VideoCodecHEVC && (format == VideoFormatMP4 || format == VideoFormatMOV) {
+		args = append(args, "-tag:v", "hvc1") // Apple players require the hvc1 tag
+	}
+	return args
+}
+
+// audioEncoder returns the FFmpeg encoder of the resolved audio codec.
+func (e *videoEncoding) audioEncoder() string {
+	return audioCodecEncoders[e.audioCodec]
+}
+
+// videoFilters chains the speed change, frame-rate change, scaling and
+// watermark. Interpolated slow motion already outputs opts.FPS. A logo
+// watermark turns the chain into a filter graph, which needs explicit maps;
+// its input must follow the main one (see Watermark.InputArgs).
+func videoFilters(opts VideoConvertOptions) []string {
+	filters := opts.Speed.VideoFilters(opts.FPS)
+	if opts.FPS > 0 && !opts.Speed.interpolates() {
+		filters = append(filters, fmt.Sprintf("fps=%g", opts.FPS))
+	}
+	if opts.Resolution != "" {
+		filters = append(filters, fmt.Sprintf("scale=%s", opts.Resolution))
+	}
+	filter := watermarkFilters(filters, opts.Watermark, 1, "vout")
+	switch {
+	case filter == "":
+		return nil
+	case opts.Watermark.HasImage():
+		return []string{"-filter_complex", filter, "-map", "[vout]", "-map", "0:a:0?"}
+	default:
+		return []string{"-vf", filter}
+	}
+}
+
+// =============================================================================
+// ENCODER DETECTION
+// =============================================================================
+
+var (
+	encodersMu    sync.Mutex
+	encodersCache = map[string]map[string]bool{} // By FFmpeg path
+)
+
+// Encoders returns the encoders compiled into an FFmpeg binary. Results are
+// cached per binary.
+func Encoders(ctx context.Context, ffmpegPath string) (map[string]bool, error) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if encoders, ok := encodersCache[ffmpegPath]; ok {
+		return encoders, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-hide_banner", "-encoders")
+	setSysProcAttr(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("list ffmpeg encoders: %w", err)
+	}
+	encoders := parseEncoders(output)
+	encodersCache[ffmpegPath] = encoders
+	return encoders, nil
+}
+
+// parseEncoders reads `ffmpeg -encoders`: a legend, a dashed line, then one
+// " V....D libx264   description" line per encoder.
+func parseEncoders(output []byte) map[string]bool {
+	encoders := map[string]bool{}
+	listing := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if !listing {
+			listing = len(fields) == 1 && strings.HasPrefix(fields[0], "---")
+			continue
+		}
+		if len(fields) >= 2 {
+			encoders[fields[1]] = true
+		}
+	}
+	return encoders
+}
+
+// checkEncoders fails when the FFmpeg build lacks the chosen encoders.
+func (e *videoEncoding) checkEncoders(ctx context.Context, ffmpegPath string, keepAudio bool) error {
+	if !e.explicitCodecs {
+		return nil
+	}
+	encoders, err := Encoders(ctx, ffmpegPath)
+	if err != nil {
+		return err
+	}
+	if !encoders[e.spec.encoder] {
+		return fmt.Errorf("this FFmpeg build has no %s encoder (%s); choose another video codec", e.spec.label, e.spec.encoder)
+	}
+	if keepAudio && e.audioCodec != "" && !encoders[e.audioEncoder()] {
+		return fmt.Errorf("this FFmpeg build has no %s audio encoder (%s); choose another audio codec", e.audioCodec, e.audioEncoder())
+	}
+	return nil
+}
+
+// CodecSupport tells the UI whether a codec can be used and where.
+type CodecSupport struct {
+	Codec      string        `json:"codec"`
+	Label      string        `json:"label"`
+	Type       string        `json:"type"` // video, audio
+	Encoder    string        `json:"encoder"`
+	Available  bool          `json:"available"` // The FFmpeg build has the encoder
+	Containers []VideoFormat `json:"containers"`
+}
+
+// SupportedCodecs lists the selectable video and audio codecs with their
+// availability in the given FFmpeg build.
+func SupportedCodecs(ctx context.Context, ffmpegPath string) ([]CodecSupport, error) {
+	encoders, err := Encoders(ctx, ffmpegPath)
+	if err != nil {
+		return nil, err
+	}
+	formats := []VideoFormat{VideoFormatMP4, VideoFormatMKV, VideoFormatWebM, VideoFormatMOV, VideoFormatAVI}
+	var codecs []CodecSupport
+	for _, codec := range []VideoCodec{VideoCodecH264, VideoCodecHEVC, VideoCodecAV1, VideoCodecVP9} {
+		spec := videoCodecSpecs[codec]
+		support := CodecSupport{Codec: string(codec), Label: spec.label, Type: "video", Encoder: spec.encoder, Available: encoders[spec.encoder]}
+		for _, format := range formats {
+			if slices.Contains(containerCodecs[format].video, codec) {
+				support.Containers = append(support.Containers, format)
+			}
+		}
+		codecs = append(codecs, support)
+	}
+	for _, codec := range []AudioCodec{AudioCodecAAC, AudioCodecMP3, AudioCodecOpus, AudioCodecVorbis, AudioCodecFLAC} {
+		encoder := audioCodecEncoders[codec]
+		support := CodecSupport{Codec: string(codec), Label: strings.ToUpper(string(codec)), Type: "audio", Encoder: encoder, Available: encoders[encoder]}
+		for _, format := range formats {
+			if slices.Contains(containerCodecs[format].audio, codec) {
+				support.Containers = append(support.Containers, format)
+			}
+		}
+		codecs = append(codecs, support)
+	}
+	return codecs, nil
+}
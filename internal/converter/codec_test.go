@@ -0,0 +1,97 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveVideoEncodingDefaults(t *testing.T) {
+	cases := map[VideoFormat]struct {
+		video VideoCodec
+		audio string
+	}{
+		VideoFormatMP4:  {VideoCodecH264, "aac"},
+		VideoFormatWebM: {VideoCodecVP9, "libopus"},
+		VideoFormatAVI:  {VideoCodecMPEG4, "libmp3lame"},
+	}
+	for format, want := range cases {
+		enc, err := resolveVideoEncoding(VideoConvertOptions{Format: format})
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if enc.codec != want.video || enc.audioEncoder() != want.audio || enc.explicitCodecs {
+			t.Fatalf("%s resolved to %s/%s", format, enc.codec, enc.audioEncoder())
+		}
+	}
+}
+
+func TestResolveVideoEncodingRejectsInvalidCombinations(t *testing.T) {
+	invalid := []VideoConvertOptions{
+		{Format: VideoFormatWebM, VideoCodec: VideoCodecHEVC},
+		{Format: VideoFormatMOV, VideoCodec: VideoCodecAV1},
+		{Format: VideoFormatWebM, AudioCodec: AudioCodecAAC},
+		{Format: VideoFormatMP4, VideoCodec: "theora"},
+		{Format: VideoFormatMP4, CustomCRF: 60},
+		{Format: VideoFormatMKV, VideoCodec: VideoCodecAV1, PixelFormat: "yuv444p"},
+		{Format: VideoFormatMKV, TenBit: true, PixelFormat: "yuv420p"},
+		{Format: VideoFormatMKV, VideoCodec: VideoCodecAV1, Quality: VideoQualityLossless},
+		{Format: VideoFormatMP4, FPS: 500},
+		{Format: VideoFormatMP4, KeyframeInterval: -1},
+	}
+	for _, opts := range invalid {
+		if _, err := resolveVideoEncoding(opts); err == nil {
+			t.Fatalf("expected %+v to be rejected", opts)
+		}
+	}
+	if err := ValidateVideoOptions(VideoConvertOptions{VideoCodec: VideoCodecAV1, CustomCRF: 60}); err != nil {
+		t.Fatalf("AV1 CRF 60 without a container should be valid: %v", err)
+	}
+}
+
+func TestVideoEncodingArgs(t *testing.T) {
+	opts := VideoConvertOptions{Format: VideoFormatMP4, VideoCodec: VideoCodecHEVC, Quality: VideoQualityHigh, TenBit: true, KeyframeInterval: 2}
+	enc, err := resolveVideoEncoding(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.setKeyframeGap(opts, 29.97)
+	args := strings.Join(append(append(enc.encoderArgs(), enc.qualityArgs(opts.Quality, 0)...), enc.formatArgs(opts.Format)...), " ")
+	if args != "-c:v libx265 -crf 20 -pix_fmt yuv420p10le -g 60 -tag:v hvc1" {
+		t.Fatalf("HEVC args = %q", args)
+	}
+
+	vp9 := &videoEncoding{codec: VideoCodecVP9, spec: videoCodecSpecs[VideoCodecVP9], pixelFormat: "yuv444p10le"}
+	if got := strings.Join(vp9.formatArgs(VideoFormatWebM), " "); got != "-pix_fmt yuv444p10le -profile:v 3" {
+		t.Fatalf("VP9 format args = %q", got)
+	}
+	if got := strings.Join(vp9.qualityArgs(VideoQualityLossless, 0), " "); got != "-lossless 1 -b:v 0" {
+		t.Fatalf("VP9 lossless args = %q", got)
+	}
+	if got := strings.Join(vp9.speedArgs("slow"), " "); got != "-deadline good -cpu-used 1" {
+		t.Fatalf("VP9 speed args = %q", got)
+	}
+
+	av1 := &videoEncoding{codec: VideoCodecAV1, spec: videoCodecSpecs[VideoCodecAV1]}
+	if got := strings.Join(append(av1.qualityArgs(VideoQualityMedium, 0), av1.speedArgs("")...), " "); got != "-crf 32 -preset 7" {
+		t.Fatalf("AV1 args = %q", got)
+	}
+
+	if got := strings.Join(videoFilters(VideoConvertOptions{FPS: 23.976, Resolution: "1280:-2"}), " "); got != "-vf fps=23.976,scale=1280:-2" {
+		t.Fatalf("filters = %q", got)
+	}
+}
+
+func TestParseEncoders(t *testing.T) {
+	output := []byte(`Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10 (codec h264)
+ V....D libsvtav1            SVT-AV1(Scalable Video Technology for AV1) encoder (codec av1)
+ A....D aac                  AAC (Advanced Audio Coding)
+`)
+	encoders := parseEncoders(output)
+	if !encoders["libx264"] || !encoders["libsvtav1"] || !encoders["aac"] || encoders["V....."] || encoders["libx265"] {
+		t.Fatalf("unexpected encoders: %v", encoders)
+	}
+}
@@ -0,0 +1,407 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kingo/internal/mediainfo"
+)
+
+const (
+	maxCrossfade      = 10.0  // Seconds
+	concatSampleRate  = 48000 // Re-encoded audio sample rate
+	concatAudioKbps   = 192
+	fpsMatchTolerance = 0.01
+)
+
+// ConcatOptions configures joining several files into one.
+type ConcatOptions struct {
+	InputPaths    []string     // In playback order; at least two
+	OutputDir     string       // If empty, uses the directory of the first input
+	Format        string       // Output extension, e.g. "mp4" or "mp3"; empty uses the first input's
+	CustomName    string       // Custom output filename (without extension); default "<first>_merged"
+	Crossfade     float64      // Seconds of crossfade between files; forces a re-encode
+	Chapters      bool         // Add a chapter per input, titled after its file name
+	ForceReencode bool         // Re-encode even when the streams match
+	Quality       VideoQuality // Re-encode quality
+	Preset        string       // Re-encode speed preset
+	FFmpegPath    string
+	OnProgress    ProgressFunc // Optional progress callback
+}
+
+// ConcatResult contains the result of a concat.
+type ConcatResult struct {
+	OutputPath string
+	InputSize  int64 // All inputs together
+	OutputSize int64
+	Duration   float64
+	Lossless   bool // Streams were copied with the concat demuxer
+	Chapters   []mediainfo.Chapter
+}
+
+// concatInput is one probed input.
+type concatInput struct {
+	path  string
+	media *mediainfo.Info
+}
+
+// Concat joins files end to end. Files whose streams match are copied
+// losslessly with the concat demuxer; otherwise every file is normalized to
+// the first one's resolution, frame rate and sample rate and re-encoded.
+// Cancelling ctx stops FFmpeg and removes the partial output.
+func Concat(ctx context.Context, opts ConcatOptions) (*ConcatResult, error) {
+	if opts.FFmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg path is required")
+	}
+	if len(opts.InputPaths) < 2 {
+		return nil, fmt.Errorf("at least two files are needed to join")
+	}
+	if opts.Crossfade < 0 || opts.Crossfade > maxCrossfade {
+		return nil, fmt.Errorf("crossfade must be between 0 and %.0f seconds", maxCrossfade)
+	}
+
+	inputs := make([]concatInput, 0, len(opts.InputPaths))
+	var inputSize int64
+	for _, path := range opts.InputPaths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("input file does not exist: %s", path)
+		}
+		inputSize += info.Size()
+		media := probeMedia(ctx, opts.FFmpegPath, path)
+		if media == nil || media.Duration <= 0 {
+			return nil, fmt.Errorf("cannot read the duration of %s", filepath.Base(path))
+		}
+		inputs = append(inputs, concatInput{path: path, media: media})
+	}
+
+	audioOnly, err := concatMode(inputs)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Crossfade > 0 {
+		for _, input := range inputs {
+			if input.media.Duration <= 2*opts.Crossfade {
+				return nil, fmt.Errorf("crossfade must be shorter than half of %s", filepath.Base(input.path))
+			}
+		}
+	}
+
+	format := strings.ToLower(strings.TrimPrefix(opts.Format, "."))
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(inputs[0].path), "."))
+	}
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(inputs[0].path)
+	}
+	name := opts.CustomName
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(inputs[0].path), filepath.Ext(inputs[0].path)) + "_merged"
+	}
+	outputPath := safeOutputPath(outputDir, name, "", format)
+
+	workDir, err := os.MkdirTemp("", "kingo-concat-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create concat directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	chapters := concatChapters(inputs, opts.Crossfade)
+	total := chapters[len(chapters)-1].End
+
+	lossless := !opts.ForceReencode && opts.Crossfade == 0 &&
+		strings.EqualFold(filepath.Ext(inputs[0].path), "."+format) && streamsMatch(inputs)
+
+	var args []string
+	stage := "joining"
+	if lossless {
+		stage = "joining (lossless)"
+		args, err = losslessConcatArgs(inputs, workDir)
+	} else {
+		args, err = reencodeConcatArgs(inputs, opts, format, audioOnly)
+	}
+	if err != nil {
+		return nil, err
+	}
+	chapterInput := len(inputs)
+	if lossless {
+		chapterInput = 1
+	}
+	if opts.Chapters {
+		metaPath := filepath.Join(workDir, "chapters.txt")
+		if err := os.WriteFile(metaPath, []byte(chapterMetadata(chapters)), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write chapters: %w", err)
+		}
+		args = append(args, "-f", "ffmetadata", "-i", metaPath)
+	}
+	args = append(args, concatOutputArgs(inputs, lossless, audioOnly)...)
+	if opts.Chapters {
+		args = append(args, "-map_chapters", fmt.Sprintf("%d", chapterInput))
+	}
+	if format == "mp4" || format == "mov" || format == "m4a" {
+		args = append(args, "-movflags", "+faststart")
+	}
+	args = append(args, "-y", outputPath)
+
+	if err := runFFmpeg(ctx, opts.FFmpegPath, args, stage, total, opts.OnProgress); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+	outputInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+	result := &ConcatResult{
+		OutputPath: outputPath,
+		InputSize:  inputSize,
+		OutputSize: outputInfo.Size(),
+		Duration:   total,
+		Lossless:   lossless,
+	}
+	if opts.Chapters {
+		result.Chapters = chapters
+	}
+	return result, nil
+}
+
+// concatMode reports whether the join is audio-only. Videos and audio-only
+// files cannot be mixed.
+func concatMode(inputs []concatInput) (bool, error) {
+	videos := 0
+	for _, input := range inputs {
+		if input.media.HasVideo() {
+			videos++
+		} else if !input.media.HasAudio() {
+			return false, fmt.Errorf("%s has no audio or video", filepath.Base(input.path))
+		}
+	}
+	if videos > 0 && videos < len(inputs) {
+		return false, fmt.Errorf("cannot join audio-only files with videos")
+	}
+	return videos == 0, nil
+}
+
+// streamsMatch reports whether all inputs share codecs and parameters, so
+// their packets can be copied one after the other.
+func streamsMatch(inputs []concatInput) bool {
+	first := inputs[0].media
+	for _, input := range inputs[1:] {
+		media := input.media
+		if media.HasVideo() != first.HasVideo() || media.HasAudio() != first.HasAudio() {
+			return false
+		}
+		if a, b := first.Video(), media.Video(); a != nil {
+			if a.Codec != b.Codec || a.Width != b.Width || a.Height != b.Height ||
+				a.PixelFormat != b.PixelFormat || math.Abs(a.FPS-b.FPS) > fpsMatchTolerance {
+				return false
+			}
+		}
+		if a, b := first.Audio(), media.Audio(); a != nil {
+			if a.Codec != b.Codec || a.SampleRate != b.SampleRate || a.Channels != b.Channels {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// losslessConcatArgs feeds the inputs through the concat demuxer.
+func losslessConcatArgs(inputs []concatInput, workDir string) ([]string, error) {
+	var list strings.Builder
+	for _, input := range inputs {
+		path, err := filepath.Abs(input.path)
+		if err != nil {
+			return nil, err
+		}
+		list.WriteString(concatListEntry(path))
+	}
+	listPath := filepath.Join(workDir, "inputs.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write concat list: %w", err)
+	}
+	return []string{"-f", "concat", "-safe", "0", "-i", listPath}, nil
+}
+
+// concatListEntry quotes a path for the concat demuxer, where a single quote
+// is written as '\”.
+func concatListEntry(path string) string {
+	return "file '" + strings.ReplaceAll(path, "'", `'\''`) + "'\n"
+}
+
+// reencodeConcatArgs normalizes every input and joins them in one filter graph.
+func reencodeConcatArgs(inputs []concatInput, opts ConcatOptions, format string, audioOnly bool) ([]string, error) {
+	var codecArgs []string
+	if audioOnly {
+		encoder, err := audioFormatArgs(AudioFormat(format), concatAudioKbps)
+		if err != nil {
+			return nil, err
+		}
+		codecArgs = encoder
+	} else {
+		enc, err := resolveVideoEncoding(VideoConvertOptions{Format: VideoFormat(format), Quality: opts.Quality})
+		if err != nil {
+			return nil, err
+		}
+		codecArgs = append(codecArgs, enc.encoderArgs()...)
+		codecArgs = append(codecArgs, enc.qualityArgs(opts.Quality, 0)...)
+		codecArgs = append(codecArgs, enc.speedArgs(opts.Preset)...)
+		codecArgs = append(codecArgs, enc.formatArgs(VideoFormat(format))...)
+		if concatHasAudio(inputs) {
+			codecArgs = append(codecArgs, "-c:a", enc.audioEncoder())
+			if enc.audioCodec != AudioCodecFLAC {
+				codecArgs = append(codecArgs, "-b:a", fmt.Sprintf("%dk", concatAudioKbps))
+			}
+		}
+	}
+
+	var args []string
+	for _, input := range inputs {
+		args = append(args, "-i", input.path)
+	}
+	args = append(args, "-filter_complex", buildConcatFilter(inputs, opts.Crossfade, audioOnly))
+	return append(args, codecArgs...), nil
+}
+
+// concatOutputArgs maps the joined streams.
+func concatOutputArgs(inputs []concatInput, lossless, audioOnly bool) []string {
+	if lossless && audioOnly {
+		return []string{"-map", "0:a:0", "-c", "copy"} // Cover art does not survive the demuxer
+	}
+	if lossless {
+		return []string{"-map", "0:v:0", "-map", "0:a:0?", "-c", "copy"}
+	}
+	if audioOnly {
+		return []string{"-map", "[aout]", "-vn"}
+	}
+	args := []string{"-map", "[vout]"}
+	if concatHasAudio(inputs) {
+		args = append(args, "-map", "[aout]")
+	}
+	return args
+}
+
+func concatHasAudio(inputs []concatInput) bool {
+	for _, input := range inputs {
+		if input.media.HasAudio() {
+			return true
+		}
+	}
+	return false
+}
+
+// buildConcatFilter scales, pads and resamples each input to the first video's
+// frame and the shared sample rate, fills silent videos with silence, then
+// joins them with concat or a chain of crossfades.
+func buildConcatFilter(inputs []concatInput, crossfade float64, audioOnly bool) string {
+	var parts []string
+	width, height, fps := 0, 0, 30.0
+	if !audioOnly {
+		video := inputs[0].media.Video()
+		width, height = video.Width&^1, video.Height&^1
+		if video.FPS > 0 {
+			fps = video.FPS
+		}
+	}
+	withAudio := audioOnly || concatHasAudio(inputs)
+
+	for i, input := range inputs {
+		if !audioOnly {
+			parts = append(parts, fmt.Sprintf(
+				"[%d:v:0]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%g,format=yuv420p,settb=AVTB,setpts=PTS-STARTPTS[v%d]",
+				i, width, height, width, height, fps, i))
+		}
+		if !withAudio {
+			continue
+		}
+		if input.media.HasAudio() {
+			parts = append(parts, fmt.Sprintf(
+				"[%d:a:0]aresample=%d,aformat=sample_fmts=fltp:channel_layouts=stereo,asetpts=PTS-STARTPTS[a%d]",
+				i, concatSampleRate, i))
+		} else {
+			parts = append(parts, fmt.Sprintf(
+				"anullsrc=r=%d:cl=stereo,atrim=duration=%.3f,aformat=sample_fmts=fltp[a%d]",
+				concatSampleRate, input.media.Duration, i))
+		}
+	}
+
+	if crossfade <= 0 {
+		var labels strings.Builder
+		for i := range inputs {
+			if !audioOnly {
+				fmt.Fprintf(&labels, "[v%d]", i)
+			}
+			if withAudio {
+				fmt.Fprintf(&labels, "[a%d]", i)
+			}
+		}
+		outputs, videoCount, audioCount := "", 1, 0
+		if audioOnly {
+			videoCount = 0
+		} else {
+			outputs = "[vout]"
+		}
+		if withAudio {
+			audioCount = 1
+			outputs += "[aout]"
+		}
+		parts = append(parts, fmt.Sprintf("%sconcat=n=%d:v=%d:a=%d%s", labels.String(), len(inputs), videoCount, audioCount, outputs))
+		return strings.Join(parts, ";")
+	}
+
+	// Each crossfade overlaps the next file with the end of the joined result.
+	videoLabel, audioLabel := "v0", "a0"
+	elapsed := 0.0
+	for i := 1; i < len(inputs); i++ {
+		elapsed += inputs[i-1].media.Duration
+		last := i == len(inputs)-1
+		if !audioOnly {
+			next := fmt.Sprintf("vx%d", i)
+			if last {
+				next = "vout"
+			}
+			offset := elapsed - float64(i)*crossfade
+			parts = append(parts, fmt.Sprintf("[%s][v%d]xfade=transition=fade:duration=%g:offset=%.3f[%s]", videoLabel, i, crossfade, offset, next))
+			videoLabel = next
+		}
+		if withAudio {
+			next := fmt.Sprintf("ax%d", i)
+			if last {
+				next = "aout"
+			}
+			parts = append(parts, fmt.Sprintf("[%s][a%d]acrossfade=d=%g[%s]", audioLabel, i, crossfade, next))
+			audioLabel = next
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// concatChapters places one chapter per input on the joined timeline. With
+// crossfades each file starts where its fade-in begins.
+func concatChapters(inputs []concatInput, crossfade float64) []mediainfo.Chapter {
+	chapters := make([]mediainfo.Chapter, len(inputs))
+	start := 0.0
+	for i, input := range inputs {
+		title := strings.TrimSuffix(filepath.Base(input.path), filepath.Ext(input.path))
+		end := start + input.media.Duration
+		chapters[i] = mediainfo.Chapter{Start: start, End: end, Title: title}
+		start = end - crossfade
+	}
+	return chapters
+}
+
+// chapterMetadata writes chapters in FFmpeg's ffmetadata format.
+func chapterMetadata(chapters []mediainfo.Chapter) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&b, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(math.Round(chapter.Start*1000)), int64(math.Round(chapter.End*1000)), escaper.Replace(chapter.Title))
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"kingo/internal/mediainfo"
+)
+
+func videoInput(path string, duration float64, codec string, width int, audio bool) concatInput {
+	media := &mediainfo.Info{Duration: duration, Streams: []mediainfo.Stream{
+		{Type: mediainfo.StreamVideo, Codec: codec, Width: width, Height: width * 9 / 16, FPS: 30, PixelFormat: "yuv420p"},
+	}}
+	if audio {
+		media.Streams = append(media.Streams, mediainfo.Stream{Type: mediainfo.StreamAudio, Codec: "aac", SampleRate: 48000, Channels: 2})
+	}
+	return concatInput{path: path, media: media}
+}
+
+func TestStreamsMatch(t *testing.T) {
+	a := videoInput("a.mp4", 10, "h264", 1920, true)
+	if !streamsMatch([]concatInput{a, videoInput("b.mp4", 20, "h264", 1920, true)}) {
+		t.Fatal("identical streams should match")
+	}
+	if streamsMatch([]concatInput{a, videoInput("b.mp4", 20, "h264", 1280, true)}) {
+		t.Fatal("different resolutions should not match")
+	}
+	if streamsMatch([]concatInput{a, videoInput("b.mp4", 20, "h264", 1920, false)}) {
+		t.Fatal("a silent file should not match one with audio")
+	}
+}
+
+func TestConcatMode(t *testing.T) {
+	song := concatInput{path: "a.mp3", media: &mediainfo.Info{Duration: 5, Streams: []mediainfo.Stream{
+		{Type: mediainfo.StreamAudio, Codec: "mp3"},
+		{Type: mediainfo.StreamVideo, AttachedPic: true},
+	}}}
+	if audioOnly, err := concatMode([]concatInput{song, song}); err != nil || !audioOnly {
+		t.Fatalf("songs with cover art should join as audio: %v %v", audioOnly, err)
+	}
+	if _, err := concatMode([]concatInput{song, videoInput("b.mp4", 5, "h264", 1280, true)}); err == nil {
+		t.Fatal("expected mixing audio-only files and videos to fail")
+	}
+}
+
+func TestBuildConcatFilter(t *testing.T) {
+	inputs := []concatInput{videoInput("a.mp4", 10, "h264", 1920, true), videoInput("b.mp4", 8, "hevc", 1280, false)}
+
+	filter := buildConcatFilter(inputs, 0, false)
+	for _, want := range []string{
+		"[0:v:0]scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080",
+		"anullsrc=r=48000:cl=stereo,atrim=duration=8.000",
+		"[v0][a0][v1][a1]concat=n=2:v=1:a=1[vout][aout]",
+	} {
+		if !strings.Contains(filter, want) {
+			t.Fatalf("filter missing %q:\n%s", want, filter)
+		}
+	}
+
+	filter = buildConcatFilter(append(inputs, videoInput("c.mp4", 6, "h264", 1920, true)), 1, false)
+	for _, want := range []string{
+		"[v0][v1]xfade=transition=fade:duration=1:offset=9.000[vx1]",
+		"[vx1][v2]xfade=transition=fade:duration=1:offset=16.000[vout]",
+		"[ax1][a2]acrossfade=d=1[aout]",
+	} {
+		if !strings.Contains(filter, want) {
+			t.Fatalf("crossfade filter missing %q:\n%s", want, filter)
+		}
+	}
+}
+
+func TestConcatChapters(t *testing.T) {
+	inputs := []concatInput{videoInput("/rec/part 1.mp4", 10, "h264", 1920, true), videoInput("/rec/part=2.mp4", 5, "h264", 1920, true)}
+	chapters := concatChapters(inputs, 1)
+	if chapters[1].Start != 9 || chapters[1].End != 14 || chapters[0].Title != "part 1" {
+		t.Fatalf("unexpected chapters: %#v", chapters)
+	}
+	metadata := chapterMetadata(chapters)
+	if !strings.HasPrefix(metadata, ";FFMETADATA1\n") || !strings.Contains(metadata, "START=9000\nEND=14000\ntitle=part\\=2\n") {
+		t.Fatalf("unexpected metadata:\n%s", metadata)
+	}
+}
+
+func TestConcatListEntry(t *testing.T) {
+	if got := concatListEntry("/music/Don't Stop.mp3"); got != "file '/music/Don'\\''t Stop.mp3'\n" {
+		t.Fatalf("entry = %q", got)
+	}
+}
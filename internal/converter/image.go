@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -64,8 +65,10 @@ type ImageConvertOptions struct {
 	Width       int         // Target width (0 = keep original)
 	Height      int         // Target height (0 = keep original)
 	FFmpegPath  string
-	AvifencPath string // Path to avifenc binary (used for AVIF output)
-	CustomName  string // Custom output filename (without extension)
+	AvifencPath string       // Path to avifenc binary (used for AVIF output)
+	CustomName  string       // Custom output filename (without extension)
+	OnProgress  ProgressFunc // Optional; images only report completion
+	Watermark   *Watermark   // Optional logo or text overlay; time range is ignored
 }
 
 // ImageConvertResult contains the result of image conversion
@@ -76,7 +79,7 @@ type ImageConvertResult struct {
 }
 
 // ConvertImage converts an image to another format using FFmpeg.
-func ConvertImage(opts ImageConvertOptions) (*ImageConvertResult, error) {
+func ConvertImage(ctx context.Context, opts ImageConvertOptions) (*ImageConvertResult, error) {
 	if opts.FFmpegPath == "" {
 		return nil, fmt.Errorf("ffmpeg path is required")
 	}
@@ -89,6 +92,9 @@ func ConvertImage(opts ImageConvertOptions) (*ImageConvertResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat input file: %w", err)
 	}
+	if err := opts.Watermark.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Normalize quality (default to 85 if not specified)
 	quality := opts.Quality
@@ -117,11 +123,14 @@ func ConvertImage(opts ImageConvertOptions) (*ImageConvertResult, error) {
 
 	// Build FFmpeg arguments
 	args := []string{"-i", opts.InputPath, "-y"}
+	args = append(args, opts.Watermark.InputArgs()...)
 
 	// Determine if we need to preserve alpha channel
 	preserveAlpha := inputHasAlpha(opts.InputPath) && hasAlphaChannel(opts.Format)
 
 	needsResize := opts.Width > 0 || opts.Height > 0
+	var filters []string
+	flatten := false
 
 	// Resize if specified — keep alpha-compatible scaling filter
 	if needsResize {
@@ -133,7 +142,7 @@ func ConvertImage(opts ImageConvertOptions) (*ImageConvertResult, error) {
 		if h == 0 {
 			h = -1
 		}
-		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d", w, h))
+		filters = append(filters, fmt.Sprintf("scale=%d:%d", w, h))
 	}
 
 	// useAvifenc is set to true when the target is AVIF — encoding is delegated to avifenc
@@ -143,9 +152,7 @@ func ConvertImage(opts ImageConvertOptions) (*ImageConvertResult, error) {
 	switch opts.Format {
 	case ImageFormatJPEG:
 		// JPEG has no alpha. Composite over white background to avoid green cast.
-		if inputHasAlpha(opts.InputPath) {
-			args = append(args, "-vf", "color=white,format=rgb24[bg];[bg][0:v]scale2ref[bg2][fg];[bg2][fg]overlay,format=rgb24")
-		}
+		flatten = inputHasAlpha(opts.InputPath)
 		// JPEG: -q:v range is 2-31 (lower is better quality)
 		qv := 31 - (quality * 29 / 100)
 		if qv < 2 {
@@ -180,39 +187,38 @@ func ConvertImage(opts ImageConvertOptions) (*ImageConvertResult, error) {
 	default:
 		return nil, fmt.Errorf("unsupported image format: %s", opts.Format)
 	}
+	args = append(args, imageFilters(filters, opts.Watermark, flatten)...)
 
 	if useAvifenc {
 		// AVIF: delegate encoding to avifenc
 		avifencInput := opts.InputPath
 		var tempPNG string
 
-		if needsResize {
-			// FFmpeg resizes to a temporary PNG, then avifenc encodes
+		if needsResize || opts.Watermark.Enabled() {
+			// FFmpeg resizes and watermarks to a temporary PNG, then avifenc encodes
 			tempPNG = outputPath + ".tmp.png"
 			resizeArgs := append(args, "-pix_fmt", "rgba", tempPNG)
-			cmd := exec.Command(opts.FFmpegPath, resizeArgs...)
-			setSysProcAttr(cmd)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				return nil, fmt.Errorf("ffmpeg resize error: %v | output: %s", err, string(out))
+			defer os.Remove(tempPNG)
+			if err := runFFmpeg(ctx, opts.FFmpegPath, resizeArgs, "resizing", 0, nil); err != nil {
+				return nil, err
 			}
 			avifencInput = tempPNG
-			defer os.Remove(tempPNG)
 		}
 
-		if err := runAvifenc(opts.AvifencPath, avifencInput, outputPath, quality); err != nil {
+		if err := runAvifenc(ctx, opts.AvifencPath, avifencInput, outputPath, quality); err != nil {
+			os.Remove(outputPath)
 			return nil, err
 		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(Progress{Percent: 100, Stage: "encoding"})
+		}
 	} else {
 		args = append(args, outputPath)
 
 		// Execute FFmpeg
-		cmd := exec.Command(opts.FFmpegPath, args...)
-		setSysProcAttr(cmd)
-
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return nil, fmt.Errorf("ffmpeg error: %v | output: %s", err, string(output))
+		if err := runFFmpeg(ctx, opts.FFmpegPath, args, "encoding", 0, opts.OnProgress); err != nil {
+			os.Remove(outputPath)
+			return nil, err
 		}
 	}
 
@@ -228,9 +234,40 @@ func ConvertImage(opts ImageConvertOptions) (*ImageConvertResult, error) {
 	}, nil
 }
 
+// imageFilters builds the filters of one image conversion: resizing, the
+// watermark, and for JPEG a white background under transparent pixels.
+func imageFilters(chain []string, w *Watermark, flatten bool) []string {
+	if w != nil {
+		still := *w
+		still.Start, still.End = 0, 0
+		w = &still
+	}
+	filter := watermarkFilters(chain, w, 1, "wmout")
+	if !flatten {
+		switch {
+		case filter == "":
+			return nil
+		case w.HasImage():
+			return []string{"-filter_complex", filter, "-map", "[wmout]", "-frames:v", "1"}
+		default:
+			return []string{"-vf", filter}
+		}
+	}
+
+	graph, source := "", "[0:v]"
+	switch {
+	case w.HasImage():
+		graph, source = filter+";", "[wmout]"
+	case filter != "":
+		graph, source = "[0:v]"+filter+"[wmout];", "[wmout]"
+	}
+	graph += "color=white,format=rgb24[bg];[bg]" + source + "scale2ref[bg2][fg];[bg2][fg]overlay=shortest=1,format=rgb24[out]"
+	return []string{"-filter_complex", graph, "-map", "[out]", "-frames:v", "1"}
+}
+
 // CompressImage reduces image file size while keeping the same format.
 // Uses "_compressed" suffix (not "_converted") and anti-collision timestamp.
-func CompressImage(inputPath string, quality int, ffmpegPath string, avifencPath string) (*ImageConvertResult, error) {
+func CompressImage(ctx context.Context, inputPath string, quality int, ffmpegPath string, avifencPath string) (*ImageConvertResult, error) {
 	if ffmpegPath == "" {
 		return nil, fmt.Errorf("ffmpeg path is required")
 	}
@@ -319,18 +356,16 @@ func CompressImage(inputPath string, quality int, ffmpegPath string, avifencPath
 	}
 
 	if useAvifenc {
-		if err := runAvifenc(avifencPath, inputPath, outputPath, quality); err != nil {
+		if err := runAvifenc(ctx, avifencPath, inputPath, outputPath, quality); err != nil {
+			os.Remove(outputPath)
 			return nil, err
 		}
 	} else {
 		args = append(args, outputPath)
 
-		cmd := exec.Command(ffmpegPath, args...)
-		setSysProcAttr(cmd)
-
-		out, err := cmd.CombinedOutput()
-		if err != nil {
-			return nil, fmt.Errorf("ffmpeg error: %v | output: %s", err, string(out))
+		if err := runFFmpeg(ctx, ffmpegPath, args, "encoding", 0, nil); err != nil {
+			os.Remove(outputPath)
+			return nil, err
 		}
 	}
 
@@ -349,7 +384,7 @@ func CompressImage(inputPath string, quality int, ffmpegPath string, avifencPath
 // runAvifenc encodes an image to AVIF using the avifenc CLI.
 // Uses -q (color quality) and --qalpha (alpha quality) flags.
 // quality is 0-100 where 100 is lossless (maps directly to avifenc's scale).
-func runAvifenc(avifencPath, inputPath, outputPath string, quality int) error {
+func runAvifenc(ctx context.Context, avifencPath, inputPath, outputPath string, quality int) error {
 	if avifencPath == "" {
 		return fmt.Errorf("avifenc path is required for AVIF encoding")
 	}
@@ -362,10 +397,13 @@ func runAvifenc(avifencPath, inputPath, outputPath string, quality int) error {
 		outputPath,
 	}
 
-	cmd := exec.Command(avifencPath, args...)
+	cmd := exec.CommandContext(ctx, avifencPath, args...)
 	setSysProcAttr(cmd)
 
 	out, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil {
 		return fmt.Errorf("avifenc error: %v | output: %s", err, string(out))
 	}
@@ -0,0 +1,119 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"kingo/internal/mediainfo"
+)
+
+// Progress reports how far an FFmpeg operation is.
+type Progress struct {
+	Percent float64 `json:"percent"` // 0-100; stays at 0 until the end when the duration is unknown
+	Stage   string  `json:"stage"`   // What is running, e.g. "encoding" or "pass 1/2"
+	Seconds float64 `json:"seconds"` // Media time processed so far
+	Speed   string  `json:"speed"`   // Encoding speed reported by FFmpeg, e.g. "2.5x"
+}
+
+// ProgressFunc receives progress updates. It runs on the goroutine reading
+// FFmpeg output and must not block.
+type ProgressFunc func(Progress)
+
+// runFFmpeg runs FFmpeg with machine-readable progress on stdout and reports
+// it against duration. Cancelling ctx kills FFmpeg and returns ctx.Err().
+func runFFmpeg(ctx context.Context, ffmpegPath string, args []string, stage string, duration float64, onProgress ProgressFunc) error {
+	_, err := runFFmpegLog(ctx, ffmpegPath, args, stage, duration, onProgress)
+	return err
+}
+
+// runFFmpegLog is runFFmpeg for filters that report through the log, such as
+// silencedetect; it returns what FFmpeg wrote to stderr.
+func runFFmpegLog(ctx context.Context, ffmpegPath string, args []string, stage string, duration float64, onProgress ProgressFunc) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	full := append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+	cmd := exec.CommandContext(ctx, ffmpegPath, full...)
+	setSysProcAttr(cmd)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("ffmpeg error: %w", err)
+	}
+	parseProgress(stdout, stage, duration, onProgress)
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg error: %v | output: %s", err, stderr.String())
+	}
+	return stderr.String(), nil
+}
+
+// parseProgress reads the key=value blocks written by `-progress`. Every
+// block ends with a progress=continue or progress=end line.
+func parseProgress(r io.Reader, stage string, duration float64, onProgress ProgressFunc) {
+	scanner := bufio.NewScanner(r)
+	current := Progress{Stage: stage}
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "out_time_us", "out_time_ms":
+			// Both keys are in microseconds; out_time_ms is misnamed upstream.
+			if micro, err := strconv.ParseInt(value, 10, 64); err == nil && micro >= 0 {
+				current.Seconds = float64(micro) / 1e6
+			}
+		case "speed":
+			current.Speed = strings.TrimSpace(value)
+		case "progress":
+			if duration > 0 {
+				current.Percent = min(100, current.Seconds/duration*100)
+			}
+			if value == "end" {
+				current.Percent = 100
+			}
+			if onProgress != nil {
+				onProgress(current)
+			}
+		}
+	}
+	// Drain the rest so FFmpeg never blocks on a full pipe.
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// probeMedia inspects the input, or returns nil when ffprobe is missing or
+// cannot read it; callers then fall back to fixed defaults and progress only
+// reports the end.
+func probeMedia(ctx context.Context, ffmpegPath, inputPath string) *mediainfo.Info {
+	info, err := mediainfo.Inspect(ctx, mediainfo.FFprobePath(ffmpegPath), inputPath)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// probeDuration returns the media duration in seconds, or 0 when unknown.
+func probeDuration(ctx context.Context, ffmpegPath, inputPath string) float64 {
+	return mediaDuration(probeMedia(ctx, ffmpegPath, inputPath))
+}
+
+func mediaDuration(info *mediainfo.Info) float64 {
+	if info == nil {
+		return 0
+	}
+	return info.Duration
+}
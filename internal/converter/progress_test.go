@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProgressReportsAgainstDuration(t *testing.T) {
+	output := `frame=10
+out_time_us=5000000
+out_time=00:00:05.000000
+speed=2.5x
+progress=continue
+out_time_us=N/A
+progress=continue
+out_time_ms=9000000
+speed=3x
+progress=end
+`
+	var updates []Progress
+	parseProgress(strings.NewReader(output), "encoding", 10, func(progress Progress) {
+		updates = append(updates, progress)
+	})
+	if len(updates) != 3 {
+		t.Fatalf("updates = %#v", updates)
+	}
+	if got := updates[0]; got.Percent != 50 || got.Seconds != 5 || got.Speed != "2.5x" || got.Stage != "encoding" {
+		t.Fatalf("first update = %#v", got)
+	}
+	if updates[1].Seconds != 5 {
+		t.Fatalf("N/A time must keep the last position: %#v", updates[1])
+	}
+	if got := updates[2]; got.Percent != 100 || got.Seconds != 9 || got.Speed != "3x" {
+		t.Fatalf("final update = %#v", got)
+	}
+}
+
+func TestParseProgressWithoutDurationOnlyReportsEnd(t *testing.T) {
+	var percents []float64
+	parseProgress(strings.NewReader("out_time_us=1000000\nprogress=continue\nprogress=end\n"), "", 0, func(progress Progress) {
+		percents = append(percents, progress.Percent)
+	})
+	if len(percents) != 2 || percents[0] != 0 || percents[1] != 100 {
+		t.Fatalf("percents = %v", percents)
+	}
+}
@@ -0,0 +1,230 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kingo/internal/mediainfo"
+)
+
+// Playback speed limits
+const (
+	MinSpeed = 0.25
+	MaxSpeed = 4.0
+)
+
+// Reverse and areverse buffer every decoded frame of their input before
+// writing the first one, so the input is reversed in chunks sized to keep
+// the decoded video of each chunk under reverseFrameBudget bytes.
+const (
+	reverseFrameBudget     = 1 << 30
+	reverseBytesPerPixel   = 3 // 4:4:4 at 8 bits, or 4:2:0 at 10 bits
+	reverseDefaultFPS      = 60
+	reverseMinChunkSeconds = 0.5
+	reverseMaxChunkSeconds = 30.0 // Also bounds areverse, which holds little per second
+)
+
+// ReverseStreams selects the streams ReverseMedia writes; the others are
+// dropped instead of being decoded for nothing.
+type ReverseStreams string
+
+const (
+	ReverseAll   ReverseStreams = "all"
+	ReverseVideo ReverseStreams = "video"
+	ReverseAudio ReverseStreams = "audio"
+)
+
+// Speed changes the playback speed and direction. Audio keeps its pitch.
+type Speed struct {
+	Factor      float64 `json:"factor"`      // 0.25-4; 0 or 1 keeps the speed
+	Interpolate bool    `json:"interpolate"` // Below 1x, synthesize in-between frames instead of repeating them
+	Reverse     bool    `json:"reverse"`     // Play backwards
+}
+
+// Enabled reports whether the speed or direction changes.
+func (s *Speed) Enabled() bool {
+	return s.changesRate() || s.reverses()
+}
+
+func (s *Speed) reverses() bool {
+	return s != nil && s.Reverse
+}
+
+func (s *Speed) changesRate() bool {
+	return s != nil && s.Factor > 0 && s.Factor != 1
+}
+
+func (s *Speed) interpolates() bool {
+	return s.changesRate() && s.Interpolate && s.Factor < 1
+}
+
+// Validate checks the speed before FFmpeg runs.
+func (s *Speed) Validate() error {
+	if s == nil || s.Factor == 0 {
+		return nil
+	}
+	if s.Factor < MinSpeed || s.Factor > MaxSpeed {
+		return fmt.Errorf("speed must be between %gx and %gx", MinSpeed, MaxSpeed)
+	}
+	return nil
+}
+
+// OutputDuration converts a duration on the source timeline to the output's.
+func (s *Speed) OutputDuration(duration float64) float64 {
+	if !s.changesRate() {
+		return duration
+	}
+	return duration / s.Factor
+}
+
+// VideoFilters retimes video. Interpolation targets fps, or minterpolate's
+// default of 60 when fps is 0.
+func (s *Speed) VideoFilters(fps float64) []string {
+	if !s.changesRate() {
+		return nil
+	}
+	filters := []string{fmt.Sprintf("setpts=PTS/%g", s.Factor)}
+	if s.interpolates() {
+		rate := ""
+		if fps > 0 {
+			rate = fmt.Sprintf("fps=%g:", fps)
+		}
+		filters = append(filters, "minterpolate="+rate+"mi_mode=mci:mc_mode=aobmc:vsbmc=1")
+	}
+	return filters
+}
+
+// AudioFilters retimes audio with atempo, chained so every step stays within
+// the 0.5-2 range where atempo does not drop or repeat samples.
+func (s *Speed) AudioFilters() []string {
+	if !s.changesRate() {
+		return nil
+	}
+	var filters []string
+	factor := s.Factor
+	for factor > 2 {
+		filters = append(filters, "atempo=2")
+		factor /= 2
+	}
+	for factor < 0.5 {
+		filters = append(filters, "atempo=0.5")
+		factor /= 0.5
+	}
+	return append(filters, fmt.Sprintf("atempo=%g", factor))
+}
+
+// outputArgs returns the audio filters of a speed change. Chapters are
+// dropped because they would point at the wrong times.
+func (s *Speed) outputArgs() []string {
+	if !s.Enabled() {
+		return nil
+	}
+	args := []string{"-map_chapters", "-1"}
+	if filters := s.AudioFilters(); len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	return args
+}
+
+// ReverseMedia writes the selected streams of inputPath backwards to
+// outputPath, a Matroska file with lossless video and PCM audio meant to be
+// encoded again. The input is reversed in chunks, last first, so memory use
+// does not grow with its length.
+func ReverseMedia(ctx context.Context, ffmpegPath, inputPath, outputPath string, streams ReverseStreams, onProgress ProgressFunc) error {
+	media := probeMedia(ctx, ffmpegPath, inputPath)
+	if media == nil || media.Duration <= 0 {
+		return fmt.Errorf("cannot read the duration of %s", filepath.Base(inputPath))
+	}
+	video, audio := media.Video(), media.Audio()
+	switch streams {
+	case ReverseVideo:
+		audio = nil
+	case ReverseAudio:
+		video = nil
+	}
+	if video == nil && audio == nil {
+		return fmt.Errorf("input has no %s to reverse: %s", reverseStreamLabel(streams), filepath.Base(inputPath))
+	}
+
+	tempDir, err := os.MkdirTemp(filepath.Dir(outputPath), ".kingo-reverse-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	chunks := lengthParts(media.Duration, reverseChunkSeconds(video))
+	var list strings.Builder
+	done := 0.0
+	for i := len(chunks) - 1; i >= 0; i-- {
+		length := chunks[i].End - chunks[i].Start
+		chunkPath := filepath.Join(tempDir, fmt.Sprintf("chunk%04d.mkv", i))
+		args := []string{"-ss", formatSeconds(chunks[i].Start), "-i", inputPath, "-t", formatSeconds(length), "-y", "-map_metadata", "-1"}
+		if video != nil {
+			args = append(args, "-map", fmt.Sprintf("0:%d", video.Index), "-vf", "reverse",
+				"-c:v", "libx264", "-preset", "ultrafast", "-qp", "0")
+		}
+		if audio != nil {
+			args = append(args, "-map", fmt.Sprintf("0:%d", audio.Index), "-af", "areverse", "-c:a", "pcm_s16le")
+		}
+		args = append(args, chunkPath)
+		if err := runFFmpeg(ctx, ffmpegPath, args, "reversing", length, splitProgress(onProgress, done, length, media.Duration)); err != nil {
+			return err
+		}
+		done += length
+		list.WriteString(concatListEntry(chunkPath))
+	}
+
+	listPath := filepath.Join(tempDir, "chunks.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write chunk list: %w", err)
+	}
+	args := []string{"-f", "concat", "-safe", "0", "-i", listPath, "-c", "copy", "-y", outputPath}
+	if err := runFFmpeg(ctx, ffmpegPath, args, "reversing", 0, nil); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	return nil
+}
+
+func reverseStreamLabel(streams ReverseStreams) string {
+	switch streams {
+	case ReverseVideo:
+		return "video"
+	case ReverseAudio:
+		return "audio"
+	}
+	return "audio or video"
+}
+
+// reverseChunkSeconds returns the chunk length whose decoded frames fit in
+// reverseFrameBudget. Audio-only inputs use the longest chunk.
+func reverseChunkSeconds(video *mediainfo.Stream) float64 {
+	if video == nil || video.Width <= 0 || video.Height <= 0 {
+		return reverseMaxChunkSeconds
+	}
+	fps := video.FPS
+	if fps <= 0 {
+		fps = reverseDefaultFPS
+	}
+	frameBytes := float64(video.Width) * float64(video.Height) * reverseBytesPerPixel
+	seconds := reverseFrameBudget / (frameBytes * fps)
+	return max(reverseMinChunkSeconds, min(reverseMaxChunkSeconds, seconds))
+}
+
+// reversedInput reverses inputPath into a temporary folder in dir. The
+// returned cleanup removes it.
+func reversedInput(ctx context.Context, ffmpegPath, inputPath, dir string, streams ReverseStreams, onProgress ProgressFunc) (string, func(), error) {
+	tempDir, err := os.MkdirTemp(dir, ".kingo-reversed-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	path := filepath.Join(tempDir, "reversed.mkv")
+	if err := ReverseMedia(ctx, ffmpegPath, inputPath, path, streams, onProgress); err != nil {
+		os.RemoveAll(tempDir)
+		return "", nil, err
+	}
+	return path, func() { os.RemoveAll(tempDir) }, nil
+}
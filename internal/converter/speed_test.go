@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"kingo/internal/mediainfo"
+)
+
+func TestSpeedAudioFiltersChainAtempo(t *testing.T) {
+	cases := map[float64][]string{
+		1.5:  {"atempo=1.5"},
+		4:    {"atempo=2", "atempo=2"},
+		3:    {"atempo=2", "atempo=1.5"},
+		0.25: {"atempo=0.5", "atempo=0.5"},
+		0.3:  {"atempo=0.5", "atempo=0.6"},
+		1:    nil,
+	}
+	for factor, want := range cases {
+		if got := (&Speed{Factor: factor}).AudioFilters(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%gx: AudioFilters() = %v, want %v", factor, got, want)
+		}
+	}
+}
+
+func TestSpeedVideoFilters(t *testing.T) {
+	slow := &Speed{Factor: 0.5, Interpolate: true}
+	if got := strings.Join(slow.VideoFilters(30), ","); got != "setpts=PTS/0.5,minterpolate=fps=30:mi_mode=mci:mc_mode=aobmc:vsbmc=1" {
+		t.Fatalf("VideoFilters() = %s", got)
+	}
+	fast := &Speed{Factor: 2, Interpolate: true}
+	if got := strings.Join(fast.VideoFilters(30), ","); got != "setpts=PTS/2" {
+		t.Fatalf("interpolation only applies to slow motion: %s", got)
+	}
+	if got := strings.Join(videoFilters(VideoConvertOptions{Speed: slow, FPS: 25, Resolution: "1280:-2"}), " "); got != "-vf setpts=PTS/0.5,minterpolate=fps=25:mi_mode=mci:mc_mode=aobmc:vsbmc=1,scale=1280:-2" {
+		t.Fatalf("videoFilters() = %s", got)
+	}
+	if got := (&Speed{Factor: 0.5}).OutputDuration(60); got != 120 {
+		t.Fatalf("OutputDuration() = %g", got)
+	}
+}
+
+func TestSpeedOutputArgs(t *testing.T) {
+	var none *Speed
+	if args := none.outputArgs(); args != nil {
+		t.Fatalf("nil speed should add nothing: %v", args)
+	}
+	if args := strings.Join((&Speed{Reverse: true}).outputArgs(), " "); args != "-map_chapters -1" {
+		t.Fatalf("reverse outputArgs() = %s", args)
+	}
+	if args := strings.Join((&Speed{Factor: 1.25}).outputArgs(), " "); args != "-map_chapters -1 -af atempo=1.25" {
+		t.Fatalf("outputArgs() = %s", args)
+	}
+}
+
+func TestSpeedValidate(t *testing.T) {
+	for _, speed := range []*Speed{nil, {}, {Factor: 0.25}, {Factor: 4}, {Reverse: true}} {
+		if err := speed.Validate(); err != nil {
+			t.Fatalf("%+v: %v", speed, err)
+		}
+	}
+	for _, speed := range []*Speed{{Factor: 0.2}, {Factor: 5}, {Factor: -1}} {
+		if err := speed.Validate(); err == nil {
+			t.Fatalf("%+v should be rejected", speed)
+		}
+	}
+	if _, err := resolveVideoEncoding(VideoConvertOptions{Format: VideoFormatMP4, Speed: &Speed{Factor: 8}}); err == nil {
+		t.Fatal("video options should reject an out-of-range speed")
+	}
+}
+
+func TestReverseChunkSecondsFollowsFrameSize(t *testing.T) {
+	hd := reverseChunkSeconds(&mediainfo.Stream{Width: 1920, Height: 1080, FPS: 30})
+	uhd := reverseChunkSeconds(&mediainfo.Stream{Width: 3840, Height: 2160, FPS: 60})
+	if hd < 5 || hd > 6 || uhd < 0.5 || uhd > 1 {
+		t.Fatalf("chunk seconds = %v (1080p30), %v (2160p60)", hd, uhd)
+	}
+	if got := reverseChunkSeconds(nil); got != reverseMaxChunkSeconds {
+		t.Fatalf("audio-only chunk = %v", got)
+	}
+	if got := reverseChunkSeconds(&mediainfo.Stream{Width: 320, Height: 240, FPS: 25}); got != reverseMaxChunkSeconds {
+		t.Fatalf("small video chunk = %v", got)
+	}
+}
@@ -0,0 +1,421 @@
+package converter
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kingo/internal/mediainfo"
+)
+
+// Audio split modes
+const (
+	SplitByChapters = "chapters" // One part per embedded chapter
+	SplitBySilence  = "silence"  // Cut in the middle of detected pauses
+	SplitByLength   = "length"   // Parts of SegmentSeconds each
+)
+
+const (
+	defaultSilenceThreshold = -35.0 // dB
+	defaultMinSilence       = 2.0   // Seconds
+	defaultMinSplitPart     = 5.0   // Seconds
+	minSplitRemainder       = 1.0   // A shorter last part joins the previous one
+	maxSplitParts           = 999
+)
+
+// AudioSplitOptions configures splitting audio into numbered parts
+type AudioSplitOptions struct {
+	InputPath     string
+	OutputDir     string       // If empty, uses same directory as input
+	Format        AudioFormat  // Target audio format
+	Quality       AudioQuality // Bitrate quality
+	CustomBitrate int          // Custom bitrate in kbps, overrides Quality if > 0
+	Channels      int          // 1 = mono, 2 = stereo, 0 keeps the source layout
+	FFmpegPath    string
+	CustomName    string       // Base name of the parts, their folder and the zip
+	OnProgress    ProgressFunc // Optional progress callback
+
+	Mode             string  // chapters, silence or length
+	SegmentSeconds   float64 // Length mode: duration of each part
+	SilenceThreshold float64 // Silence mode: level in dB below which audio is silence; 0 uses -35
+	MinSilence       float64 // Silence mode: shortest pause that splits, in seconds; 0 uses 2
+	MinPartSeconds   float64 // Silence mode: shorter parts are joined with the next; 0 uses 5
+	Zip              bool    // Pack the parts into one zip instead of a folder
+}
+
+// SplitPart is one part of a split, on the source timeline.
+type SplitPart struct {
+	Track int     `json:"track"` // 1-based
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`
+	Title string  `json:"title"` // Chapter title; empty for the other modes
+}
+
+// SplitFile is a written part. Path is the file, or its entry name when the
+// parts were zipped.
+type SplitFile struct {
+	SplitPart
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// AudioSplitResult contains the result of an audio split
+type AudioSplitResult struct {
+	OutputDir  string // Folder holding the parts; empty when zipped
+	ZipPath    string // Zip holding the parts, when requested
+	Parts      []SplitFile
+	InputSize  int64
+	OutputSize int64 // All parts, or the zip
+}
+
+// silenceRange is a pause reported by silencedetect.
+type silenceRange struct {
+	Start float64
+	End   float64
+}
+
+// PreviewAudioSplit returns the parts SplitAudio would write, without
+// encoding anything. Silence mode still has to scan the whole input.
+func PreviewAudioSplit(ctx context.Context, opts AudioSplitOptions) ([]SplitPart, error) {
+	if opts.FFmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg path is required")
+	}
+	if _, err := os.Stat(opts.InputPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file does not exist: %s", opts.InputPath)
+	}
+	media, err := splitSource(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return planSplit(ctx, opts, media)
+}
+
+// SplitAudio extracts the audio of the input as numbered parts tagged track
+// N/M, cut by chapters, silences or a fixed length. Cancelling ctx stops
+// FFmpeg and removes the parts written so far.
+func SplitAudio(ctx context.Context, opts AudioSplitOptions) (*AudioSplitResult, error) {
+	if opts.FFmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg path is required")
+	}
+	inputInfo, err := os.Stat(opts.InputPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file does not exist: %s", opts.InputPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat input file: %w", err)
+	}
+
+	media, err := splitSource(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	bitrate := sourceAudioBitrate(media, getBitrateValue(opts.Quality))
+	if opts.CustomBitrate > 0 {
+		bitrate = opts.CustomBitrate
+	}
+	codecArgs, err := audioFormatArgs(opts.Format, bitrate)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := planSplit(ctx, opts, media)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := opts.CustomName
+	if baseName == "" {
+		baseName = strings.TrimSuffix(filepath.Base(opts.InputPath), filepath.Ext(opts.InputPath))
+	}
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(opts.InputPath)
+	}
+
+	// Parts go to their own folder; when zipped, to a temporary one.
+	var partsDir string
+	if opts.Zip {
+		partsDir, err = os.MkdirTemp(outputDir, ".kingo-split-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(partsDir)
+	} else {
+		partsDir = filepath.Join(outputDir, baseName)
+		if _, err := os.Stat(partsDir); err == nil {
+			partsDir += "_" + time.Now().Format("20060102_150405")
+		}
+		if err := os.MkdirAll(partsDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	result := &AudioSplitResult{InputSize: inputInfo.Size()}
+	width := max(2, len(strconv.Itoa(len(parts))))
+	done := 0.0
+	for _, part := range parts {
+		name := fmt.Sprintf("%s - %0*d", baseName, width, part.Track)
+		path := filepath.Join(partsDir, name+"."+string(opts.Format))
+		args := splitPartArgs(opts, part, len(parts), name, codecArgs)
+		args = append(args, path)
+
+		length := part.End - part.Start
+		stage := fmt.Sprintf("part %d/%d", part.Track, len(parts))
+		if err := runFFmpeg(ctx, opts.FFmpegPath, args, stage, length, splitProgress(opts.OnProgress, done, length, media.Duration)); err != nil {
+			if !opts.Zip {
+				os.RemoveAll(partsDir)
+			}
+			return nil, err
+		}
+		done += length
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat output file: %w", err)
+		}
+		result.Parts = append(result.Parts, SplitFile{SplitPart: part, Path: path, Size: info.Size()})
+		result.OutputSize += info.Size()
+	}
+
+	if !opts.Zip {
+		result.OutputDir = partsDir
+		return result, nil
+	}
+	zipPath := safeOutputPath(outputDir, baseName, "", "zip")
+	files := make([]string, len(result.Parts))
+	for i, part := range result.Parts {
+		files[i] = part.Path
+		result.Parts[i].Path = filepath.Base(part.Path)
+	}
+	if err := zipFiles(zipPath, files); err != nil {
+		return nil, err
+	}
+	zipInfo, err := os.Stat(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+	result.ZipPath = zipPath
+	result.OutputSize = zipInfo.Size()
+	return result, nil
+}
+
+// splitSource probes the input; splitting needs its duration and chapters.
+func splitSource(ctx context.Context, opts AudioSplitOptions) (*mediainfo.Info, error) {
+	media := probeMedia(ctx, opts.FFmpegPath, opts.InputPath)
+	if media == nil || media.Duration <= 0 {
+		return nil, fmt.Errorf("cannot read the duration of %s", filepath.Base(opts.InputPath))
+	}
+	if !media.HasAudio() {
+		return nil, fmt.Errorf("input has no audio stream: %s", filepath.Base(opts.InputPath))
+	}
+	return media, nil
+}
+
+// planSplit returns the numbered parts of the chosen mode.
+func planSplit(ctx context.Context, opts AudioSplitOptions, media *mediainfo.Info) ([]SplitPart, error) {
+	var parts []SplitPart
+	switch opts.Mode {
+	case SplitByChapters:
+		parts = chapterParts(media.Chapters, media.Duration)
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("input has no chapters: %s", filepath.Base(opts.InputPath))
+		}
+	case SplitByLength:
+		if opts.SegmentSeconds < minSplitRemainder {
+			return nil, fmt.Errorf("part length must be at least %g second", minSplitRemainder)
+		}
+		parts = lengthParts(media.Duration, opts.SegmentSeconds)
+	case SplitBySilence:
+		silences, err := detectSilences(ctx, opts, media.Duration)
+		if err != nil {
+			return nil, err
+		}
+		minPart := opts.MinPartSeconds
+		if minPart <= 0 {
+			minPart = defaultMinSplitPart
+		}
+		parts = silenceParts(silences, media.Duration, minPart)
+	default:
+		return nil, fmt.Errorf("unsupported split mode: %s", opts.Mode)
+	}
+	if len(parts) > maxSplitParts {
+		return nil, fmt.Errorf("split would create %d parts; the limit is %d", len(parts), maxSplitParts)
+	}
+	for i := range parts {
+		parts[i].Track = i + 1
+	}
+	return parts, nil
+}
+
+// chapterParts turns chapters into parts, skipping empty ones.
+func chapterParts(chapters []mediainfo.Chapter, duration float64) []SplitPart {
+	var parts []SplitPart
+	for _, chapter := range chapters {
+		end := min(chapter.End, duration)
+		if end-chapter.Start <= 0 {
+			continue
+		}
+		parts = append(parts, SplitPart{Start: chapter.Start, End: end, Title: strings.TrimSpace(chapter.Title)})
+	}
+	return parts
+}
+
+// lengthParts cuts every segment seconds. A last part shorter than a second
+// joins the previous one.
+func lengthParts(duration, segment float64) []SplitPart {
+	var parts []SplitPart
+	for start := 0.0; start < duration; start += segment {
+		end := min(start+segment, duration)
+		if end-start < minSplitRemainder && len(parts) > 0 {
+			parts[len(parts)-1].End = end
+			break
+		}
+		parts = append(parts, SplitPart{Start: start, End: end})
+	}
+	return parts
+}
+
+// silenceParts cuts in the middle of every pause, except at the edges, and
+// skips cuts that would leave a part shorter than minPart.
+func silenceParts(silences []silenceRange, duration, minPart float64) []SplitPart {
+	var parts []SplitPart
+	start := 0.0
+	for _, silence := range silences {
+		if silence.Start <= 0 || silence.End >= duration {
+			continue
+		}
+		cut := (silence.Start + silence.End) / 2
+		if cut-start < minPart || duration-cut < minPart {
+			continue
+		}
+		parts = append(parts, SplitPart{Start: start, End: cut})
+		start = cut
+	}
+	return append(parts, SplitPart{Start: start, End: duration})
+}
+
+// detectSilences runs silencedetect over the whole input.
+func detectSilences(ctx context.Context, opts AudioSplitOptions, duration float64) ([]silenceRange, error) {
+	threshold := opts.SilenceThreshold
+	if threshold == 0 {
+		threshold = defaultSilenceThreshold
+	}
+	minSilence := opts.MinSilence
+	if minSilence <= 0 {
+		minSilence = defaultMinSilence
+	}
+	args := []string{"-i", opts.InputPath, "-vn", "-map", "0:a:0",
+		"-af", fmt.Sprintf("silencedetect=noise=%gdB:d=%g", threshold, minSilence), "-f", "null", "-"}
+	log, err := runFFmpegLog(ctx, opts.FFmpegPath, args, "detecting silence", duration, opts.OnProgress)
+	if err != nil {
+		return nil, err
+	}
+	return parseSilences(strings.NewReader(log), duration), nil
+}
+
+// parseSilences reads the silence_start and silence_end lines of
+// silencedetect. A pause still open at the end runs to duration.
+func parseSilences(r io.Reader, duration float64) []silenceRange {
+	var silences []silenceRange
+	open := -1.0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := logValue(line, "silence_start:"); ok {
+			open = max(0, value)
+		} else if value, ok := logValue(line, "silence_end:"); ok && open >= 0 {
+			silences = append(silences, silenceRange{Start: open, End: value})
+			open = -1
+		}
+	}
+	if open >= 0 {
+		silences = append(silences, silenceRange{Start: open, End: duration})
+	}
+	return silences
+}
+
+// logValue returns the number following key on a log line.
+func logValue(line, key string) (float64, bool) {
+	_, rest, ok := strings.Cut(line, key)
+	if !ok {
+		return 0, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	return value, err == nil
+}
+
+// splitPartArgs encodes one part, keeping the source tags but numbering the
+// track and naming it after its chapter.
+func splitPartArgs(opts AudioSplitOptions, part SplitPart, total int, name string, codecArgs []string) []string {
+	args := []string{"-ss", formatSeconds(part.Start), "-i", opts.InputPath, "-t", formatSeconds(part.End - part.Start),
+		"-y", "-vn", "-map", "0:a:0", "-map_metadata", "0", "-map_chapters", "-1"}
+	args = append(args, codecArgs...)
+	if opts.Channels > 0 {
+		args = append(args, "-ac", fmt.Sprintf("%d", opts.Channels))
+	}
+	title := part.Title
+	if title == "" {
+		title = name
+	}
+	return append(args, "-metadata", fmt.Sprintf("track=%d/%d", part.Track, total), "-metadata", "title="+title)
+}
+
+// splitProgress reports a part's progress against the whole input.
+func splitProgress(onProgress ProgressFunc, done, length, total float64) ProgressFunc {
+	if onProgress == nil {
+		return nil
+	}
+	return func(progress Progress) {
+		progress.Percent = min(100, (done+min(progress.Seconds, length))/total*100)
+		onProgress(progress)
+	}
+}
+
+// zipFiles stores files in a new zip. Encoded audio does not compress
+// further, so entries are stored as is.
+func zipFiles(zipPath string, files []string) (err error) {
+	out, err := os.Create(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to create zip: %w", err)
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(zipPath)
+		}
+	}()
+
+	archive := zip.NewWriter(out)
+	for _, path := range files {
+		if err := addZipFile(archive, path); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func addZipFile(archive *zip.Writer, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: filepath.Base(path), Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, in)
+	return err
+}
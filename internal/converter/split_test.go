@@ -0,0 +1,103 @@
+package converter
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"kingo/internal/mediainfo"
+)
+
+func TestLengthPartsJoinsShortTail(t *testing.T) {
+	got := lengthParts(600.5, 300)
+	want := []SplitPart{{Start: 0, End: 300}, {Start: 300, End: 600.5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("lengthParts() = %+v, want %+v", got, want)
+	}
+	if got := lengthParts(250, 100); len(got) != 3 || got[2].End != 250 {
+		t.Fatalf("lengthParts() = %+v", got)
+	}
+}
+
+func TestParseSilences(t *testing.T) {
+	log := `[silencedetect @ 0x1] silence_start: -0.01
+[silencedetect @ 0x1] silence_end: 1.5 | silence_duration: 1.51
+size=N/A time=00:01:00.00 bitrate=N/A speed= 900x
+[silencedetect @ 0x1] silence_start: 40.2
+[silencedetect @ 0x1] silence_end: 43.8 | silence_duration: 3.6
+[silencedetect @ 0x1] silence_start: 118`
+	got := parseSilences(strings.NewReader(log), 120)
+	want := []silenceRange{{0, 1.5}, {40.2, 43.8}, {118, 120}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseSilences() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSilencePartsSkipsEdgesAndShortParts(t *testing.T) {
+	silences := []silenceRange{{0, 1.5}, {40, 44}, {45, 47}, {100, 102}, {118, 120}}
+	got := silenceParts(silences, 120, 5)
+	want := []SplitPart{{Start: 0, End: 42}, {Start: 42, End: 101}, {Start: 101, End: 120}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("silenceParts() = %+v, want %+v", got, want)
+	}
+	if got := silenceParts(nil, 60, 5); len(got) != 1 || got[0].End != 60 {
+		t.Fatalf("no silences should give one part: %+v", got)
+	}
+}
+
+func TestPlanSplitByChapters(t *testing.T) {
+	media := &mediainfo.Info{Duration: 90, Chapters: []mediainfo.Chapter{
+		{Start: 0, End: 30, Title: " Intro "}, {Start: 30, End: 30}, {Start: 30, End: 95, Title: "Chapter 1"},
+	}}
+	got, err := planSplit(context.Background(), AudioSplitOptions{Mode: SplitByChapters}, media)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SplitPart{{Track: 1, Start: 0, End: 30, Title: "Intro"}, {Track: 2, Start: 30, End: 90, Title: "Chapter 1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("planSplit() = %+v, want %+v", got, want)
+	}
+	if _, err := planSplit(context.Background(), AudioSplitOptions{Mode: SplitByChapters}, &mediainfo.Info{Duration: 90}); err == nil {
+		t.Fatal("expected an error without chapters")
+	}
+	if _, err := planSplit(context.Background(), AudioSplitOptions{Mode: SplitByLength}, media); err == nil {
+		t.Fatal("expected an error without a part length")
+	}
+}
+
+func TestSplitPartArgsTagsTrack(t *testing.T) {
+	opts := AudioSplitOptions{InputPath: "book.m4b", Channels: 1}
+	args := strings.Join(splitPartArgs(opts, SplitPart{Track: 2, Start: 30, End: 90.5}, 12, "book - 02", []string{"-c:a", "libmp3lame"}), " ")
+	want := "-ss 30.000 -i book.m4b -t 60.500 -y -vn -map 0:a:0 -map_metadata 0 -map_chapters -1 -c:a libmp3lame -ac 1 -metadata track=2/12 -metadata title=book - 02"
+	if args != want {
+		t.Fatalf("splitPartArgs() =\n%s\nwant\n%s", args, want)
+	}
+}
+
+func TestZipFilesStoresParts(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a - 01.mp3", "a - 02.mp3"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	zipPath := filepath.Join(dir, "a.zip")
+	if err := zipFiles(zipPath, files); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if len(archive.File) != 2 || archive.File[1].Name != "a - 02.mp3" || archive.File[1].Method != zip.Store {
+		t.Fatalf("unexpected zip entries: %+v", archive.File)
+	}
+}
@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kingo/internal/mediainfo"
+)
+
+const (
+	maxTargetAttempts   = 2    // The first encode plus one corrected retry
+	targetOverhead      = 0.97 // Share of the target left after container overhead
+	minTargetVideoKbps  = 64   // Below this the video is unwatchable
+	maxTargetAudioKbps  = 128
+	minTargetAudioKbps  = 32
+	targetRetryHeadroom = 0.95 // Extra margin applied to the retry bitrate
+)
+
+// targetBitrates splits a size budget into video and audio bitrates in kbps.
+// Audio gets an eighth of the budget within sane bounds unless audioKbps is
+// fixed; it gets nothing when the input has no audio.
+func targetBitrates(targetBytes int64, duration float64, hasAudio bool, audioKbps int) (int, int, error) {
+	if duration <= 0 {
+		return 0, 0, fmt.Errorf("cannot determine the video duration needed for a target size")
+	}
+	totalKbps := int(float64(targetBytes) * 8 / 1000 / duration * targetOverhead)
+	if !hasAudio {
+		audioKbps = 0
+	} else if audioKbps <= 0 {
+		audioKbps = max(minTargetAudioKbps, min(maxTargetAudioKbps, totalKbps/8))
+	}
+	videoKbps := totalKbps - audioKbps
+	if videoKbps < minTargetVideoKbps {
+		return 0, 0, fmt.Errorf("target size of %.1f MB is too small for %.0f seconds of video", float64(targetBytes)/1e6, duration)
+	}
+	return videoKbps, audioKbps, nil
+}
+
+// retryVideoBitrate scales the video bitrate down by the measured overshoot.
+// Audio is close to constant bitrate, so all of the excess comes off video.
+func retryVideoBitrate(videoKbps int, outputSize, targetBytes int64, duration float64) int {
+	actualKbps := float64(outputSize) * 8 / 1000 / duration
+	targetKbps := float64(targetBytes) * 8 / 1000 / duration
+	excess := actualKbps - targetKbps
+	next := int((float64(videoKbps) - excess) * targetRetryHeadroom)
+	return max(minTargetVideoKbps, min(next, videoKbps-1))
+}
+
+// buildTwoPassArgs returns the FFmpeg arguments of one pass. The first pass
+// only writes the rate-control log, so it drops audio and discards output.
+func buildTwoPassArgs(opts VideoConvertOptions, enc *videoEncoding, pass, videoKbps, audioKbps int, passLog, outputPath string) ([]string, error) {
+	args := []string{"-i", opts.InputPath, "-y"}
+	args = append(args, opts.Watermark.InputArgs()...)
+	args = append(args, enc.encoderArgs()...)
+	args = append(args, "-b:v", fmt.Sprintf("%dk", videoKbps))
+	args = append(args, enc.speedArgs(opts.Preset)...)
+	args = append(args, videoFilters(opts)...)
+	args = append(args, opts.Speed.outputArgs()...)
+	args = append(args, enc.formatArgs(opts.Format)...)
+	switch enc.codec {
+	case VideoCodecHEVC:
+		// libx265 ignores -pass and takes its passes through x265-params,
+		// where ':' separates options, so the drive colon of a Windows path
+		// has to be escaped.
+		args = append(args, "-x265-params", fmt.Sprintf("pass=%d:stats=%s", pass, escapeChars(passLog, `\:'`)))
+	case VideoCodecAV1:
+		return nil, fmt.Errorf("target size is not supported with %s; use H.264, HEVC or VP9", enc.spec.label)
+	default:
+		args = append(args, "-pass", fmt.Sprintf("%d", pass), "-passlogfile", passLog)
+	}
+
+	if pass == 1 {
+		return append(args, "-an", "-f", "null", os.DevNull), nil
+	}
+	if audioKbps > 0 {
+		args = append(args, "-c:a", enc.audioEncoder())
+		if enc.audioCodec != AudioCodecFLAC {
+			args = append(args, "-b:a", fmt.Sprintf("%dk", audioKbps))
+		}
+	} else {
+		args = append(args, "-an")
+	}
+	if opts.Format == VideoFormatMP4 || opts.Format == VideoFormatMOV {
+		args = append(args, "-movflags", "+faststart")
+	}
+	return append(args, outputPath), nil
+}
+
+// convertToSize encodes with two-pass average bitrate so the output lands
+// near TargetSizeBytes. A result that still overshoots is encoded once more
+// with the bitrate corrected by the measured excess.
+func convertToSize(ctx context.Context, opts VideoConvertOptions, enc *videoEncoding, info *mediainfo.Info, inputSize int64, outputPath string) (*VideoConvertResult, error) {
+	duration := opts.Speed.OutputDuration(mediaDuration(info))
+	hasAudio := opts.KeepAudio // Already cleared for inputs known to be silent
+	videoKbps, audioKbps, err := targetBitrates(opts.TargetSizeBytes, duration, hasAudio, opts.AudioBitrate)
+	if err != nil {
+		return nil, err
+	}
+
+	logDir, err := os.MkdirTemp("", "kingo-2pass-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pass log directory: %w", err)
+	}
+	defer os.RemoveAll(logDir)
+	passLog := filepath.Join(logDir, "ffmpeg2pass")
+
+	result := &VideoConvertResult{OutputPath: outputPath, InputSize: inputSize}
+	for attempt := 1; attempt <= maxTargetAttempts; attempt++ {
+		for pass := 1; pass <= 2; pass++ {
+			args, err := buildTwoPassArgs(opts, enc, pass, videoKbps, audioKbps, passLog, outputPath)
+			if err != nil {
+				return nil, err
+			}
+			stage := fmt.Sprintf("pass %d/2", pass)
+			if attempt > 1 {
+				stage = fmt.Sprintf("attempt %d, %s", attempt, stage)
+			}
+			if err := runFFmpeg(ctx, opts.FFmpegPath, args, stage, duration, opts.OnProgress); err != nil {
+				os.Remove(outputPath)
+				return nil, err
+			}
+		}
+
+		outputInfo, err := os.Stat(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat output file: %w", err)
+		}
+		result.OutputSize = outputInfo.Size()
+		result.Attempts = attempt
+		result.VideoBitrate = videoKbps
+		if result.OutputSize <= opts.TargetSizeBytes {
+			result.TargetMet = true
+			break
+		}
+		next := retryVideoBitrate(videoKbps, result.OutputSize, opts.TargetSizeBytes, duration)
+		if next >= videoKbps {
+			break // Already at the floor
+		}
+		videoKbps = next
+	}
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package converter
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"kingo/internal/mediainfo"
+)
+
+func TestTargetBitratesSplitsBudget(t *testing.T) {
+	// 25 MB over 200 s leaves ~970 kbps after overhead.
+	video, audio, err := targetBitrates(25_000_000, 200, true, 0)
+	if err != nil {
+		t.Fatalf("targetBitrates() error: %v", err)
+	}
+	if audio != 121 || video != 849 {
+		t.Fatalf("bitrates = %d/%d kbps, want 849/121", video, audio)
+	}
+
+	video, audio, err = targetBitrates(25_000_000, 200, false, 0)
+	if err != nil || audio != 0 || video != 970 {
+		t.Fatalf("silent input = %d/%d kbps, %v", video, audio, err)
+	}
+
+	if _, audio, _ = targetBitrates(100_000_000, 60, true, 0); audio != maxTargetAudioKbps {
+		t.Fatalf("audio should be capped at %d kbps, got %d", maxTargetAudioKbps, audio)
+	}
+	if _, audio, _ = targetBitrates(100_000_000, 60, true, 192); audio != 192 {
+		t.Fatalf("fixed audio bitrate ignored, got %d", audio)
+	}
+}
+
+func TestTargetBitratesRejectsImpossibleTargets(t *testing.T) {
+	if _, _, err := targetBitrates(25_000_000, 0, true, 0); err == nil {
+		t.Fatal("expected unknown duration to be rejected")
+	}
+	if _, _, err := targetBitrates(1_000_000, 3600, true, 0); err == nil {
+		t.Fatal("expected a 1 MB hour-long video to be rejected")
+	}
+}
+
+func TestRetryVideoBitrateRemovesOvershoot(t *testing.T) {
+	// 10% over a 25 MB target for 200 s is ~100 kbps too much.
+	next := retryVideoBitrate(849, 27_500_000, 25_000_000, 200)
+	if next >= 749 || next < 700 {
+		t.Fatalf("retry bitrate = %d, want a little below 749", next)
+	}
+	if next := retryVideoBitrate(minTargetVideoKbps, 27_500_000, 25_000_000, 200); next != minTargetVideoKbps {
+		t.Fatalf("retry bitrate should stop at the floor, got %d", next)
+	}
+}
+
+func TestBuildTwoPassArgs(t *testing.T) {
+	opts := VideoConvertOptions{InputPath: "in.mp4", Format: VideoFormatMP4, Resolution: "1280:-2"}
+	enc, err := resolveVideoEncoding(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := buildTwoPassArgs(opts, enc, 1, 800, 96, "log", "out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(first, " ")
+	for _, want := range []string{"-c:v libx264 -b:v 800k -preset medium", "-vf scale=1280:-2", "-pass 1 -passlogfile log", "-an -f null " + os.DevNull} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("pass 1 missing %q:\n%s", want, joined)
+		}
+	}
+
+	second, err := buildTwoPassArgs(opts, enc, 2, 800, 96, "log", "out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined = strings.Join(second, " ")
+	if !strings.Contains(joined, "-pass 2 -passlogfile log -c:a aac -b:a 96k -movflags +faststart out.mp4") {
+		t.Fatalf("pass 2 args unexpected:\n%s", joined)
+	}
+
+	webmOpts := VideoConvertOptions{InputPath: "in.webm", Format: VideoFormatWebM}
+	webmEnc, _ := resolveVideoEncoding(webmOpts)
+	webm, _ := buildTwoPassArgs(webmOpts, webmEnc, 2, 500, 0, "log", "out.webm")
+	joined = strings.Join(webm, " ")
+	if !strings.Contains(joined, "-c:v libvpx-vp9") || strings.Contains(joined, "-preset") || !strings.Contains(joined, "-an out.webm") {
+		t.Fatalf("webm args unexpected:\n%s", joined)
+	}
+
+	hevcOpts := VideoConvertOptions{InputPath: "in.mp4", Format: VideoFormatMP4, VideoCodec: VideoCodecHEVC}
+	hevcEnc, err := resolveVideoEncoding(hevcOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hevc, _ := buildTwoPassArgs(hevcOpts, hevcEnc, 1, 800, 0, `C:\Users\Ana\Temp\kingo-2pass\pass`, "out.mp4")
+	joined = strings.Join(hevc, " ")
+	if !strings.Contains(joined, `-x265-params pass=1:stats=C\:\\Users\\Ana\\Temp\\kingo-2pass\\pass`) {
+		t.Fatalf("hevc stats path is not escaped:\n%s", joined)
+	}
+}
+
+func TestApplySourceDefaults(t *testing.T) {
+	media := &mediainfo.Info{Streams: []mediainfo.Stream{{Type: mediainfo.StreamVideo, Width: 1280, Height: 720}}}
+	opts := applySourceDefaults(VideoConvertOptions{KeepAudio: true, Resolution: "1920x1080"}, media)
+	if opts.KeepAudio || opts.Resolution != "" {
+		t.Fatalf("silent 720p input should drop audio and upscaling: %#v", opts)
+	}
+	opts = applySourceDefaults(VideoConvertOptions{Resolution: "854x480"}, media)
+	if opts.Resolution != "854x480" {
+		t.Fatalf("downscale dropped: %#v", opts)
+	}
+	opts = applySourceDefaults(VideoConvertOptions{KeepAudio: true, Resolution: "1920x1080"}, nil)
+	if !opts.KeepAudio || opts.Resolution != "1920x1080" {
+		t.Fatalf("unknown input should keep the options: %#v", opts)
+	}
+}
+
+func TestSourceAudioBitrate(t *testing.T) {
+	media := &mediainfo.Info{Streams: []mediainfo.Stream{{Type: mediainfo.StreamAudio, Bitrate: 96000}}}
+	if got := sourceAudioBitrate(media, 192); got != 96 {
+		t.Fatalf("bitrate = %d, want 96", got)
+	}
+	if got := sourceAudioBitrate(media, 64); got != 64 {
+		t.Fatalf("bitrate = %d, want 64", got)
+	}
+	if got := sourceAudioBitrate(nil, 192); got != 192 {
+		t.Fatalf("bitrate = %d, want 192", got)
+	}
+}
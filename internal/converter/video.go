@@ -2,11 +2,14 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"kingo/internal/mediainfo"
 )
 
 // VideoFormat represents supported output video formats
@@ -37,24 +40,41 @@ type VideoConvertOptions struct {
 	OutputDir  string       // If empty, uses same directory as input
 	Format     VideoFormat  // Target format
 	Quality    VideoQuality // Compression quality
-	CustomCRF  int          // Custom CRF value (0-51), overrides Quality if > 0
+	CustomCRF  int          // Custom CRF on the codec's scale (0-51, AV1/VP9 0-63), overrides Quality if > 0
 	Preset     string       // FFmpeg preset: ultrafast, fast, medium, slow, veryslow
 	Resolution string       // Target resolution e.g. "1920x1080", empty = keep original
 	KeepAudio  bool         // Whether to copy audio stream
 	FFmpegPath string       // Path to FFmpeg binary
 	CustomName string       // Custom output filename (without extension)
+	OnProgress ProgressFunc // Optional progress callback
+
+	TargetSizeBytes int64 // If > 0, two-pass encodes to fit this size; Quality and CustomCRF are ignored
+	AudioBitrate    int   // Audio kbps; 0 picks it from the source, or from the budget in target-size mode
+
+	VideoCodec       VideoCodec // Empty uses the container default: H.264, VP9 for WebM, MPEG-4 for AVI
+	AudioCodec       AudioCodec // Empty uses the container default
+	PixelFormat      string     // e.g. "yuv420p10le"; empty keeps the encoder's choice
+	TenBit           bool       // Encode 10-bit; picks yuv420p10le when PixelFormat is empty
+	FPS              float64    // Output frame rate; 0 keeps the source's
+	KeyframeInterval float64    // Seconds between keyframes; 0 keeps the encoder default
+	Watermark        *Watermark // Optional logo or text overlay
+	Speed            *Speed     // Optional speed change or reverse
 }
 
 // VideoConvertResult contains the result of a video conversion
 type VideoConvertResult struct {
-	OutputPath string
-	InputSize  int64
-	OutputSize int64
+	OutputPath   string
+	InputSize    int64
+	OutputSize   int64
+	TargetMet    bool // True when no target was requested or the output fits it
+	Attempts     int  // Two-pass encodes run in target-size mode
+	VideoBitrate int  // Video kbps of the final target-size encode
 }
 
 // ConvertVideo converts a video file to another format using FFmpeg.
-// Returns the path to the converted file.
-func ConvertVideo(opts VideoConvertOptions) (*VideoConvertResult, error) {
+// Returns the path to the converted file. Cancelling ctx stops FFmpeg and
+// removes the partial output.
+func ConvertVideo(ctx context.Context, opts VideoConvertOptions) (*VideoConvertResult, error) {
 	if opts.FFmpegPath == "" {
 		return nil, fmt.Errorf("ffmpeg path is required")
 	}
@@ -69,6 +89,14 @@ func ConvertVideo(opts VideoConvertOptions) (*VideoConvertResult, error) {
 		return nil, fmt.Errorf("failed to stat input file: %w", err)
 	}
 
+	enc, err := resolveVideoEncoding(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := enc.checkEncoders(ctx, opts.FFmpegPath, opts.KeepAudio); err != nil {
+		return nil, err
+	}
+
 	// Build output path
 	inputExt := filepath.Ext(opts.InputPath)
 	baseName := strings.TrimSuffix(filepath.Base(opts.InputPath), inputExt)
@@ -84,65 +112,48 @@ func ConvertVideo(opts VideoConvertOptions) (*VideoConvertResult, error) {
 		outputPath = filepath.Join(outputDir, baseName+"_converted."+string(opts.Format))
 	}
 
-	// Build FFmpeg arguments
-	args := []string{"-i", opts.InputPath, "-y"} // -y to overwrite
-
-	// Video codec based on format
-	switch opts.Format {
-	case VideoFormatMP4, VideoFormatMOV:
-		args = append(args, "-c:v", "libx264")
-		args = append(args, "-movflags", "+faststart") // Web optimization
-	case VideoFormatMKV:
-		args = append(args, "-c:v", "libx264")
-	case VideoFormatWebM:
-		args = append(args, "-c:v", "libvpx-vp9")
-		args = append(args, "-b:v", "0") // Required for CRF mode in VP9
-	case VideoFormatAVI:
-		args = append(args, "-c:v", "mpeg4")
-	default:
-		return nil, fmt.Errorf("unsupported output format: %s", opts.Format)
-	}
-
-	// Quality/CRF
-	crf := getCRFValue(opts.Quality)
-	if opts.CustomCRF > 0 {
-		crf = opts.CustomCRF
-	}
-
-	if opts.Format == VideoFormatAVI {
-		// AVI uses -q:v instead of -crf (1-31 scale, lower is better)
-		qv := 31 - (crf * 31 / 51)
-		if qv < 1 {
-			qv = 1
+	media := probeMedia(ctx, opts.FFmpegPath, opts.InputPath)
+	opts = applySourceDefaults(opts, media)
+	enc.setKeyframeGap(opts, sourceFPS(media))
+	if opts.Speed.reverses() {
+		streams := ReverseAll
+		if !opts.KeepAudio {
+			streams = ReverseVideo
 		}
-		args = append(args, "-q:v", fmt.Sprintf("%d", qv))
-	} else {
-		args = append(args, "-crf", fmt.Sprintf("%d", crf))
-	}
-
-	// Preset (encoding speed)
-	preset := opts.Preset
-	if preset == "" {
-		preset = "medium"
+		reversed, cleanup, err := reversedInput(ctx, opts.FFmpegPath, opts.InputPath, outputDir, streams, opts.OnProgress)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		opts.InputPath = reversed
 	}
-	if opts.Format != VideoFormatAVI { // AVI doesn't support presets
-		args = append(args, "-preset", preset)
+	if opts.TargetSizeBytes > 0 {
+		return convertToSize(ctx, opts, enc, media, inputInfo.Size(), outputPath)
 	}
 
-	// Resolution
-	if opts.Resolution != "" {
-		args = append(args, "-vf", fmt.Sprintf("scale=%s", opts.Resolution))
+	// Build FFmpeg arguments
+	args := []string{"-i", opts.InputPath, "-y"} // -y to overwrite
+	args = append(args, opts.Watermark.InputArgs()...)
+	args = append(args, enc.encoderArgs()...)
+	args = append(args, enc.qualityArgs(opts.Quality, opts.CustomCRF)...)
+	args = append(args, enc.speedArgs(opts.Preset)...)
+	args = append(args, videoFilters(opts)...)
+	args = append(args, opts.Speed.outputArgs()...)
+	args = append(args, enc.formatArgs(opts.Format)...)
+	if opts.Format == VideoFormatMP4 || opts.Format == VideoFormatMOV {
+		args = append(args, "-movflags", "+faststart") // Web optimization
 	}
 
 	// Audio handling
 	if opts.KeepAudio {
-		switch opts.Format {
-		case VideoFormatWebM:
-			args = append(args, "-c:a", "libopus")
-		case VideoFormatAVI:
-			args = append(args, "-c:a", "libmp3lame", "-b:a", "192k")
-		default:
-			args = append(args, "-c:a", "aac", "-b:a", "192k")
+		args = append(args, "-c:a", enc.audioEncoder())
+		switch {
+		case enc.audioCodec == AudioCodecFLAC:
+			// Lossless, no bitrate
+		case opts.AudioBitrate > 0:
+			args = append(args, "-b:a", fmt.Sprintf("%dk", opts.AudioBitrate))
+		case enc.audioCodec != AudioCodecOpus && enc.audioCodec != AudioCodecVorbis:
+			args = append(args, "-b:a", fmt.Sprintf("%dk", sourceAudioBitrate(media, 192)))
 		}
 	} else {
 		args = append(args, "-an") // No audio
@@ -151,12 +162,9 @@ func ConvertVideo(opts VideoConvertOptions) (*VideoConvertResult, error) {
 	args = append(args, outputPath)
 
 	// Execute FFmpeg
-	cmd := exec.Command(opts.FFmpegPath, args...)
-	setSysProcAttr(cmd)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("ffmpeg error: %v | output: %s", err, string(output))
+	if err := runFFmpeg(ctx, opts.FFmpegPath, args, "encoding", opts.Speed.OutputDuration(mediaDuration(media)), opts.OnProgress); err != nil {
+		os.Remove(outputPath)
+		return nil, err
 	}
 
 	// Get output file size
@@ -169,50 +177,109 @@ func ConvertVideo(opts VideoConvertOptions) (*VideoConvertResult, error) {
 		OutputPath: outputPath,
 		InputSize:  inputInfo.Size(),
 		OutputSize: outputInfo.Size(),
+		TargetMet:  true,
 	}, nil
 }
 
 // CompressVideo compresses a video file using CRF encoding.
 // This is essentially ConvertVideo but keeps the same format.
-func CompressVideo(inputPath string, quality VideoQuality, preset string, ffmpegPath string) (*VideoConvertResult, error) {
-	// Detect input format
-	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(inputPath), "."))
-	format := VideoFormatMP4 // Default
-	switch ext {
-	case "mkv":
-		format = VideoFormatMKV
-	case "webm":
-		format = VideoFormatWebM
-	case "avi":
-		format = VideoFormatAVI
-	case "mov":
-		format = VideoFormatMOV
-	}
-
-	return ConvertVideo(VideoConvertOptions{
+func CompressVideo(ctx context.Context, inputPath string, quality VideoQuality, preset string, ffmpegPath string, onProgress ProgressFunc) (*VideoConvertResult, error) {
+	return ConvertVideo(ctx, VideoConvertOptions{
 		InputPath:  inputPath,
-		Format:     format,
+		Format:     VideoFormatOf(inputPath),
 		Quality:    quality,
 		Preset:     preset,
 		KeepAudio:  true,
 		FFmpegPath: ffmpegPath,
+		OnProgress: onProgress,
 	})
 }
 
-// getCRFValue converts quality preset to CRF value
-func getCRFValue(quality VideoQuality) int {
-	switch quality {
-	case VideoQualityLossless:
-		return 0
-	case VideoQualityHigh:
-		return 18
-	case VideoQualityMedium:
-		return 23
-	case VideoQualityLow:
-		return 28
-	case VideoQualityTiny:
-		return 35
+// CompressVideoToSize compresses a video in its own format so it fits
+// targetBytes, using two-pass encoding with one corrected retry.
+func CompressVideoToSize(ctx context.Context, inputPath string, targetBytes int64, preset string, ffmpegPath string, onProgress ProgressFunc) (*VideoConvertResult, error) {
+	return ConvertVideo(ctx, VideoConvertOptions{
+		InputPath:       inputPath,
+		Format:          VideoFormatOf(inputPath),
+		Preset:          preset,
+		KeepAudio:       true,
+		FFmpegPath:      ffmpegPath,
+		OnProgress:      onProgress,
+		TargetSizeBytes: targetBytes,
+	})
+}
+
+// VideoFormatOf returns the output format matching a file's extension,
+// MP4 for anything else.
+func VideoFormatOf(path string) VideoFormat {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")) {
+	case "mkv":
+		return VideoFormatMKV
+	case "webm":
+		return VideoFormatWebM
+	case "avi":
+		return VideoFormatAVI
+	case "mov":
+		return VideoFormatMOV
 	default:
-		return 23 // Medium as default
+		return VideoFormatMP4
+	}
+}
+
+// applySourceDefaults adapts the options to what the input holds: silent
+// inputs get no audio encoder, the frame is never upscaled and interpolated
+// slow motion keeps the source frame rate.
+func applySourceDefaults(opts VideoConvertOptions, media *mediainfo.Info) VideoConvertOptions {
+	if media == nil {
+		return opts
+	}
+	if !media.HasAudio() {
+		opts.KeepAudio = false
+	}
+	if opts.Speed.interpolates() && opts.FPS == 0 {
+		opts.FPS = sourceFPS(media)
+	}
+	if video := media.Video(); video != nil && opts.Resolution != "" {
+		width, height, ok := parseResolution(opts.Resolution)
+		if ok && width >= video.Width && height >= video.Height {
+			opts.Resolution = ""
+		}
+	}
+	return opts
+}
+
+// parseResolution reads "1920x1080" or "1920:1080". Sizes with automatic
+// sides such as "1280:-2" are not comparable and report false.
+func parseResolution(resolution string) (int, int, bool) {
+	w, h, ok := strings.Cut(strings.ReplaceAll(resolution, ":", "x"), "x")
+	if !ok {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(strings.TrimSpace(w))
+	height, errH := strconv.Atoi(strings.TrimSpace(h))
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// sourceFPS returns the frame rate of the input's video, or 0.
+func sourceFPS(media *mediainfo.Info) float64 {
+	if media == nil || media.Video() == nil {
+		return 0
+	}
+	return media.Video().FPS
+}
+
+// sourceAudioBitrate caps a lossy audio bitrate at the source's, since
+// re-encoding a 96 kbps track at 192 kbps only wastes space.
+func sourceAudioBitrate(media *mediainfo.Info, kbps int) int {
+	if media == nil {
+		return kbps
+	}
+	audio := media.Audio()
+	if audio == nil || audio.Bitrate <= 0 {
+		return kbps
 	}
+	return max(64, min(kbps, int((audio.Bitrate+999)/1000)))
 }
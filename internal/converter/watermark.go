@@ -0,0 +1,251 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Watermark positions
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+const (
+	defaultImageWatermarkScale = 0.15 // Share of the frame width
+	defaultTextWatermarkScale  = 0.05 // Share of the frame height
+	defaultWatermarkMargin     = 24   // Pixels
+)
+
+// Watermark overlays a logo image or a line of text on video and image
+// outputs. It is applied in the same FFmpeg pass as the other filters.
+type Watermark struct {
+	ImagePath string  `json:"imagePath"` // Logo; takes precedence over Text
+	Text      string  `json:"text"`
+	FontFile  string  `json:"fontFile"`  // Text font; empty uses a system font
+	FontColor string  `json:"fontColor"` // Text colour, e.g. "white" or "#FFCC00"; default white
+	Position  string  `json:"position"`  // top-left, top-right, bottom-left, bottom-right, center; default bottom-right
+	Margin    int     `json:"margin"`    // Pixels from the edges; 0 uses 24, negative sticks to the edge
+	Opacity   float64 `json:"opacity"`   // 0-1; 0 means fully opaque
+	Scale     float64 `json:"scale"`     // Logo width as a share of the frame width, text height of the frame height
+	Start     float64 `json:"start"`     // Seconds; video only
+	End       float64 `json:"end"`       // Seconds; 0 shows it until the end
+}
+
+// Enabled reports whether the watermark draws anything.
+func (w *Watermark) Enabled() bool {
+	return w != nil && (w.ImagePath != "" || strings.TrimSpace(w.Text) != "")
+}
+
+// HasImage reports whether the watermark is a logo, which needs its own FFmpeg input.
+func (w *Watermark) HasImage() bool {
+	return w != nil && w.ImagePath != ""
+}
+
+// Validate checks the watermark before FFmpeg runs.
+func (w *Watermark) Validate() error {
+	if !w.Enabled() {
+		return nil
+	}
+	if w.ImagePath != "" {
+		if _, err := os.Stat(w.ImagePath); err != nil {
+			return fmt.Errorf("watermark image not found: %s", w.ImagePath)
+		}
+	}
+	switch w.Position {
+	case "", WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+	default:
+		return fmt.Errorf("unsupported watermark position: %s", w.Position)
+	}
+	if w.Opacity < 0 || w.Opacity > 1 {
+		return fmt.Errorf("watermark opacity must be between 0 and 1")
+	}
+	if w.Scale < 0 || w.Scale > 1 {
+		return fmt.Errorf("watermark scale must be between 0 and 1")
+	}
+	if w.Start < 0 || w.End < 0 || (w.End > 0 && w.End <= w.Start) {
+		return fmt.Errorf("watermark time range is invalid")
+	}
+	if w.FontFile != "" {
+		if _, err := os.Stat(w.FontFile); err != nil {
+			return fmt.Errorf("watermark font not found: %s", w.FontFile)
+		}
+	}
+	return nil
+}
+
+// watermarkFilters appends the watermark to a filter chain. The chain runs on
+// input 0's video and ends on outLabel; a logo is read from imageInput.
+// Without a logo the result is a plain chain that also works with -vf.
+func watermarkFilters(chain []string, w *Watermark, imageInput int, outLabel string) string {
+	if !w.Enabled() {
+		return strings.Join(chain, ",")
+	}
+	if !w.HasImage() {
+		return strings.Join(append(chain, w.drawText()), ",")
+	}
+
+	if len(chain) == 0 {
+		return w.overlay("0:v", imageInput, outLabel)
+	}
+	return "[0:v]" + strings.Join(chain, ",") + "[wmbase];" + w.overlay("wmbase", imageInput, outLabel)
+}
+
+// AppendFilter adds the watermark to a filter graph whose video ends on
+// inLabel, so it ends on outLabel instead. A logo is read from imageInput,
+// which the caller adds with InputArgs.
+func (w *Watermark) AppendFilter(graph, inLabel, outLabel string, imageInput int) string {
+	if !w.Enabled() {
+		return graph
+	}
+	filter := ""
+	if w.HasImage() {
+		filter = w.overlay(inLabel, imageInput, outLabel)
+	} else {
+		filter = "[" + inLabel + "]" + w.drawText() + "[" + outLabel + "]"
+	}
+	if strings.TrimSpace(graph) == "" {
+		return filter
+	}
+	return graph + ";" + filter
+}
+
+// overlay scales the logo against the frame on inLabel and places it.
+func (w *Watermark) overlay(inLabel string, imageInput int, outLabel string) string {
+	opacity := ""
+	if w.Opacity > 0 && w.Opacity < 1 {
+		opacity = fmt.Sprintf(",colorchannelmixer=aa=%g", w.Opacity)
+	}
+	scale := w.Scale
+	if scale <= 0 {
+		scale = defaultImageWatermarkScale
+	}
+	x, y := w.placement("main_w", "main_h", "overlay_w", "overlay_h")
+	return fmt.Sprintf("[%d:v]format=rgba%s[wmlogo];[wmlogo][%s]scale2ref=w=main_w*%g:h=ow/a[wmscaled][wmframe];[wmframe][wmscaled]overlay=x=%s:y=%s:shortest=1%s[%s]",
+		imageInput, opacity, inLabel, scale, x, y, w.enable(), outLabel)
+}
+
+// InputArgs returns the FFmpeg input of a logo, looped so it lasts as long as
+// the video it covers.
+func (w *Watermark) InputArgs() []string {
+	if !w.HasImage() {
+		return nil
+	}
+	return []string{"-loop", "1", "-i", w.ImagePath}
+}
+
+// drawText renders a text watermark with drawtext.
+func (w *Watermark) drawText() string {
+	scale := w.Scale
+	if scale <= 0 {
+		scale = defaultTextWatermarkScale
+	}
+	color := w.FontColor
+	if color == "" {
+		color = "white"
+	}
+	alpha := ""
+	if w.Opacity > 0 && w.Opacity < 1 {
+		alpha = fmt.Sprintf("@%g", w.Opacity)
+	}
+	x, y := w.placement("w", "h", "tw", "th")
+	parts := []string{
+		"drawtext=text=" + escapeDrawText(w.Text),
+		"expansion=none",
+		fmt.Sprintf("fontsize=h*%g", scale),
+		"fontcolor=" + color + alpha,
+		"shadowcolor=black" + alpha,
+		"shadowx=2",
+		"shadowy=2",
+		"x=" + x,
+		"y=" + y,
+	}
+	if font := w.fontFile(); font != "" {
+		parts = append(parts, "fontfile="+escapeFilterPath(font))
+	}
+	return strings.Join(parts, ":") + w.enable()
+}
+
+// placement returns the x and y expressions of the watermark. frameW/H and
+// markW/H are the variable names the filter uses for the two sizes.
+func (w *Watermark) placement(frameW, frameH, markW, markH string) (string, string) {
+	margin := w.Margin
+	if margin == 0 {
+		margin = defaultWatermarkMargin
+	}
+	margin = max(0, margin)
+	left := fmt.Sprintf("%d", margin)
+	right := fmt.Sprintf("%s-%s-%d", frameW, markW, margin)
+	top := fmt.Sprintf("%d", margin)
+	bottom := fmt.Sprintf("%s-%s-%d", frameH, markH, margin)
+	switch w.Position {
+	case WatermarkTopLeft:
+		return left, top
+	case WatermarkTopRight:
+		return right, top
+	case WatermarkBottomLeft:
+		return left, bottom
+	case WatermarkCenter:
+		return fmt.Sprintf("(%s-%s)/2", frameW, markW), fmt.Sprintf("(%s-%s)/2", frameH, markH)
+	default:
+		return right, bottom
+	}
+}
+
+// enable limits the watermark to its time range.
+func (w *Watermark) enable() string {
+	switch {
+	case w.End > 0:
+		return fmt.Sprintf(":enable='between(t,%g,%g)'", w.Start, w.End)
+	case w.Start > 0:
+		return fmt.Sprintf(":enable='gte(t,%g)'", w.Start)
+	default:
+		return ""
+	}
+}
+
+// fontFile returns the chosen font, or a common system font on platforms
+// where FFmpeg builds usually lack fontconfig.
+func (w *Watermark) fontFile() string {
+	if w.FontFile != "" {
+		return w.FontFile
+	}
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = []string{filepath.Join(os.Getenv("WINDIR"), "Fonts", "arial.ttf")}
+	case "darwin":
+		candidates = []string{"/System/Library/Fonts/Supplemental/Arial.ttf", "/Library/Fonts/Arial.ttf"}
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return "" // fontconfig default
+}
+
+// escapeDrawText escapes watermark text twice: once for the drawtext option
+// value and once for the filtergraph around it.
+func escapeDrawText(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\n", " ")
+	return escapeChars(escapeChars(text, `\':`), `\'[],;`)
+}
+
+// escapeChars prefixes every special character with a backslash.
+func escapeChars(text, specials string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(specials, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWatermarkFiltersLogo(t *testing.T) {
+	w := &Watermark{ImagePath: "logo.png", Position: WatermarkTopLeft, Margin: 10, Opacity: 0.5, Scale: 0.2, Start: 2, End: 8}
+	got := watermarkFilters([]string{"scale=1280:-2"}, w, 1, "vout")
+	want := "[0:v]scale=1280:-2[wmbase];[1:v]format=rgba,colorchannelmixer=aa=0.5[wmlogo];" +
+		"[wmlogo][wmbase]scale2ref=w=main_w*0.2:h=ow/a[wmscaled][wmframe];" +
+		"[wmframe][wmscaled]overlay=x=10:y=10:shortest=1:enable='between(t,2,8)'[vout]"
+	if got != want {
+		t.Fatalf("watermarkFilters() =\n%s\nwant\n%s", got, want)
+	}
+	if args := strings.Join(videoFilters(VideoConvertOptions{Watermark: w}), " "); !strings.HasPrefix(args, "-filter_complex [1:v]") || !strings.HasSuffix(args, "-map [vout] -map 0:a:0?") {
+		t.Fatalf("videoFilters() = %s", args)
+	}
+	if args := strings.Join(w.InputArgs(), " "); args != "-loop 1 -i logo.png" {
+		t.Fatalf("InputArgs() = %s", args)
+	}
+}
+
+func TestWatermarkFiltersText(t *testing.T) {
+	w := &Watermark{Text: "Kingo: 100% [live]", FontFile: "font.ttf", Opacity: 0.8, Start: 5}
+	got := watermarkFilters([]string{"fps=30"}, w, 1, "vout")
+	if !strings.HasPrefix(got, `fps=30,drawtext=text=Kingo\\: 100% \[live\]:expansion=none:fontsize=h*0.05:fontcolor=white@0.8`) {
+		t.Fatalf("unexpected drawtext: %s", got)
+	}
+	if !strings.Contains(got, ":x=w-tw-24:y=h-th-24:fontfile='font.ttf':enable='gte(t,5)'") {
+		t.Fatalf("unexpected placement: %s", got)
+	}
+	if args := videoFilters(VideoConvertOptions{Watermark: w}); args[0] != "-vf" {
+		t.Fatalf("text watermark should stay a simple chain: %v", args)
+	}
+}
+
+func TestWatermarkAppendFilter(t *testing.T) {
+	w := &Watermark{Text: "x", FontFile: "f.ttf", Position: WatermarkCenter}
+	got := w.AppendFilter("[0:v]null[vout]", "vout", "vmarked", 3)
+	if !strings.HasPrefix(got, "[0:v]null[vout];[vout]drawtext=") || !strings.HasSuffix(got, ":x=(w-tw)/2:y=(h-th)/2:fontfile='f.ttf'[vmarked]") {
+		t.Fatalf("AppendFilter() = %s", got)
+	}
+	logo := &Watermark{ImagePath: "logo.png"}
+	if got := logo.AppendFilter("", "vout", "vmarked", 3); !strings.HasPrefix(got, "[3:v]format=rgba[wmlogo];[wmlogo][vout]scale2ref") {
+		t.Fatalf("AppendFilter() = %s", got)
+	}
+	var none *Watermark
+	if got := none.AppendFilter("graph", "vout", "vmarked", 1); got != "graph" {
+		t.Fatalf("disabled watermark changed the graph: %s", got)
+	}
+}
+
+func TestImageFiltersFlattenJPEG(t *testing.T) {
+	got := strings.Join(imageFilters([]string{"scale=800:-1"}, &Watermark{Text: "x", FontFile: "f.ttf", End: 3}, true), " ")
+	if !strings.HasPrefix(got, "-filter_complex [0:v]scale=800:-1,drawtext=") || strings.Contains(got, "enable=") {
+		t.Fatalf("imageFilters() = %s", got)
+	}
+	if !strings.Contains(got, "[wmout];color=white,format=rgb24[bg];[bg][wmout]scale2ref") || !strings.HasSuffix(got, "-map [out] -frames:v 1") {
+		t.Fatalf("imageFilters() = %s", got)
+	}
+	if got := imageFilters(nil, nil, false); got != nil {
+		t.Fatalf("no filters expected, got %v", got)
+	}
+}
+
+func TestWatermarkValidate(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(logo, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	valid := []*Watermark{nil, {}, {ImagePath: logo, Opacity: 1, Scale: 0.3}, {Text: "x", Start: 1, End: 2}}
+	for _, w := range valid {
+		if err := w.Validate(); err != nil {
+			t.Fatalf("%+v: %v", w, err)
+		}
+	}
+	invalid := []*Watermark{
+		{ImagePath: logo + ".missing"},
+		{Text: "x", Position: "middle"},
+		{Text: "x", Opacity: 1.5},
+		{Text: "x", Scale: 2},
+		{Text: "x", Start: 5, End: 5},
+		{Text: "x", FontFile: logo + ".ttf"},
+	}
+	for _, w := range invalid {
+		if err := w.Validate(); err == nil {
+			t.Fatalf("%+v should be rejected", w)
+		}
+	}
+}
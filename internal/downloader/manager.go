@@ -3,6 +3,7 @@ package downloader
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,6 +29,7 @@ type Job struct {
 type Manager struct {
 	ctx           context.Context
 	repo          *storage.DownloadRepository
+	glossaries    *storage.GlossaryRepository
 	client        *youtube.Client
 	maxConcurrent int
 
@@ -138,6 +140,31 @@ func (m *Manager) workerLoop() {
 	}
 }
 
+// SetGlossaryRepository lets Whisper captions use the glossaries attached to
+// each download.
+func (m *Manager) SetGlossaryRepository(repo *storage.GlossaryRepository) {
+	m.glossaries = repo
+}
+
+// attachedGlossaries adds the glossaries attached to a download to ids.
+func (m *Manager) attachedGlossaries(downloadID string, ids []string) []string {
+	if m.glossaries == nil {
+		return ids
+	}
+	attached, err := m.glossaries.ListFor(storage.GlossaryTargetDownload, downloadID)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("id", downloadID).Msg("failed to load download glossaries")
+		return ids
+	}
+	result := append([]string(nil), ids...)
+	for _, glossary := range attached {
+		if !slices.Contains(result, glossary.ID) {
+			result = append(result, glossary.ID)
+		}
+	}
+	return result
+}
+
 // AddJob creates a new download job and adds it to the queue
 func (m *Manager) AddJob(opts youtube.DownloadOptions) (*storage.Download, error) {
 	// Check if there's already an active download for this URL
@@ -293,6 +320,7 @@ func (m *Manager) processJob(job *Job) {
 	download.Thumbnail = info.Thumbnail
 	download.Duration = int(info.Duration)
 	download.Uploader = info.Uploader
+	download.Description = info.Description
 	download.Status = storage.StatusDownloading
 	now := time.Now()
 	download.StartedAt = &now
@@ -307,6 +335,9 @@ func (m *Manager) processJob(job *Job) {
 	// Use the full options stored in the job
 	opts := job.Options
 	opts.URL = download.URL // Ensure URL is set
+	if opts.Captions.Enabled {
+		opts.Captions.GlossaryIDs = m.attachedGlossaries(download.ID, opts.Captions.GlossaryIDs)
+	}
 
 	// Check for cancellation
 	select {
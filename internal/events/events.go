@@ -33,11 +33,25 @@ const (
 // Eventos do Whisper (Transcrição)
 const (
 	WhisperModelProgress      = "whisper:model-progress"
-	WhisperTranscribeProgress = "whisper:transcribe-progress"
+	WhisperTranscribeProgress = "whisper:transcribe-progress" // payload: whisper.TranscribeProgress
+	WhisperTranscribeLanguage = "whisper:transcribe-language"
+)
+
+// Eventos da Fila de Transcrição
+const (
+	TranscriptionAdded   = "transcription:added"   // payload: storage.Transcription
+	TranscriptionUpdated = "transcription:updated" // payload: storage.Transcription (sem texto/segmentos)
 )
 
 // Eventos do Conversor (FFmpeg)
 const (
-	ConverterProgress = "converter:progress" // payload: { percent float64, stage string }
-	ConverterDone     = "converter:done"     // payload: ConversionResult
+	ConverterProgress = "converter:progress" // payload: conversion.Progress
+	ConverterDone     = "converter:done"     // payload: handlers.ConversionResult
+)
+
+// Eventos da Fila de Conversao
+const (
+	ConversionAdded     = "conversion:added"      // payload: storage.Conversion
+	ConversionUpdated   = "conversion:updated"    // payload: storage.Conversion
+	ConversionBatchDone = "conversion:batch-done" // payload: conversion.BatchStats
 )
@@ -4,18 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
 	_ "image/png"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	_ "golang.org/x/image/webp"
 
 	"kingo/internal/app"
+	"kingo/internal/conversion"
 	"kingo/internal/converter"
+	"kingo/internal/events"
+	"kingo/internal/mediainfo"
+	"kingo/internal/storage"
 
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"golang.org/x/image/draw"
 )
@@ -25,6 +33,14 @@ type ConverterHandler struct {
 	ctx            context.Context
 	paths          *app.Paths
 	consoleEmitter func(string)
+
+	mu         sync.Mutex
+	operations map[string]context.CancelFunc // Running conversions by operation ID
+
+	conversions     *conversion.Manager
+	presets         *conversion.PresetStore
+	presetConverter *conversion.PresetConverter
+	watermarks      *conversion.WatermarkStore
 }
 
 // NewConverterHandler creates a new ConverterHandler.
@@ -33,6 +49,7 @@ func NewConverterHandler(paths *app.Paths) *ConverterHandler {
 		ctx:            context.Background(),
 		paths:          paths,
 		consoleEmitter: func(s string) {},
+		operations:     make(map[string]context.CancelFunc),
 	}
 }
 
@@ -52,6 +69,91 @@ func (h *ConverterHandler) consoleLog(message string) {
 	}
 }
 
+// =============================================================================
+// OPERATIONS (PROGRESS / CANCELLATION)
+// =============================================================================
+
+// startOperation registers a cancellable conversion. An empty ID is replaced
+// by a generated one, which the frontend learns from the progress events. An
+// ID already in use is rejected so the running conversion stays cancellable.
+func (h *ConverterHandler) startOperation(id string) (string, context.Context, func(), error) {
+	if id == "" {
+		id = uuid.New().String()
+	}
+	h.mu.Lock()
+	if _, exists := h.operations[id]; exists {
+		h.mu.Unlock()
+		return "", nil, nil, fmt.Errorf("conversão já em andamento: %s", id)
+	}
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.operations[id] = cancel
+	h.mu.Unlock()
+	return id, ctx, func() {
+		h.mu.Lock()
+		delete(h.operations, id)
+		h.mu.Unlock()
+		cancel()
+	}, nil
+}
+
+// CancelConversion stops a running conversion. Its partial output is removed
+// and it finishes with a cancelled result. IDs of queued conversions cancel
+// that file of the batch.
+func (h *ConverterHandler) CancelConversion(id string) error {
+	h.mu.Lock()
+	cancel, ok := h.operations[id]
+	h.mu.Unlock()
+	if !ok {
+		if h.conversions != nil {
+			return h.conversions.CancelJob(id)
+		}
+		return fmt.Errorf("conversão não encontrada: %s", id)
+	}
+	h.consoleLog("[Converter] Cancelando conversão...")
+	cancel()
+	return nil
+}
+
+// progressReporter forwards converter progress as converter:progress events.
+func (h *ConverterHandler) progressReporter(id string) converter.ProgressFunc {
+	return func(progress converter.Progress) {
+		h.emitEvent(events.ConverterProgress, conversion.NewProgress(id, progress))
+	}
+}
+
+// succeeded completes a result and announces it on converter:done.
+func (h *ConverterHandler) succeeded(id string, result *ConversionResult) *ConversionResult {
+	result.OperationID = id
+	result.Success = true
+	if result.InputSize > 0 {
+		result.Compression = (1.0 - float64(result.OutputSize)/float64(result.InputSize)) * 100
+	}
+	h.emitEvent(events.ConverterDone, result)
+	return result
+}
+
+// failed turns a conversion error into a result and announces it on
+// converter:done.
+func (h *ConverterHandler) failed(id string, err error) *ConversionResult {
+	result := &ConversionResult{OperationID: id, ErrorMessage: err.Error()}
+	if errors.Is(err, context.Canceled) {
+		result.Cancelled = true
+		result.ErrorMessage = "conversão cancelada"
+		h.consoleLog("[Converter] Conversão cancelada")
+	} else {
+		h.consoleLog(fmt.Sprintf("[Converter] Erro: %s", err.Error()))
+	}
+	h.emitEvent(events.ConverterDone, result)
+	return result
+}
+
+// emitEvent tolerates a missing Wails application so the handler works in tests.
+func (h *ConverterHandler) emitEvent(eventName string, data any) {
+	if app := application.Get(); app != nil {
+		app.Event.Emit(eventName, data)
+	}
+}
+
 // =============================================================================
 // FILE SELECTION
 // =============================================================================
@@ -84,6 +186,20 @@ func (h *ConverterHandler) SelectImageFile() (string, error) {
 	return file, nil
 }
 
+// SelectSubtitleFile opens a file dialog to select a subtitle file.
+func (h *ConverterHandler) SelectSubtitleFile() (string, error) {
+	file, err := application.Get().Dialog.OpenFile().
+		SetTitle("Selecionar Legenda").
+		AddFilter("Legendas", "*.srt;*.vtt;*.ass;*.ssa;*.json").
+		AddFilter("Todos os Arquivos", "*.*").
+		PromptForSingleSelection()
+
+	if err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
 // SelectOutputDirectory opens a dialog to select output directory.
 func (h *ConverterHandler) SelectOutputDirectory() (string, error) {
 	dir, err := application.Get().Dialog.OpenFile().
@@ -110,6 +226,16 @@ func (h *ConverterHandler) GetFileSize(path string) (int64, error) {
 	return info.Size(), nil
 }
 
+// InspectMedia returns the container, streams, chapters and tags of a file so
+// the source details can be shown before converting.
+func (h *ConverterHandler) InspectMedia(path string) (*mediainfo.Info, error) {
+	info, err := mediainfo.Inspect(h.ctx, mediainfo.FFprobePath(h.paths.FFmpegPath()), path)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível ler o arquivo de mídia: %w", err)
+	}
+	return info, nil
+}
+
 // ReadImageThumbnail reads an image file, resizes it to maxSize pixels,
 // and returns a data:image/jpeg;base64,... string for use as a thumbnail.
 func (h *ConverterHandler) ReadImageThumbnail(inputPath string, maxSize int) (string, error) {
@@ -196,15 +322,30 @@ func (h *ConverterHandler) SelectImageFiles() ([]string, error) {
 
 // VideoConvertRequest represents a video conversion request from frontend.
 type VideoConvertRequest struct {
-	InputPath  string `json:"inputPath"`
-	OutputDir  string `json:"outputDir"`
-	Format     string `json:"format"`     // mp4, mkv, webm, avi, mov
-	Quality    string `json:"quality"`    // lossless, high, medium, low, tiny
-	CustomCRF  int    `json:"customCrf"`  // 0-51, overrides quality
-	Preset     string `json:"preset"`     // ultrafast, fast, medium, slow, veryslow
-	Resolution string `json:"resolution"` // e.g. "1920x1080"
-	KeepAudio  bool   `json:"keepAudio"`
-	CustomName string `json:"customName"` // Custom output filename (without extension)
+	InputPath   string `json:"inputPath"`
+	OutputDir   string `json:"outputDir"`
+	Format      string `json:"format"`     // mp4, mkv, webm, avi, mov
+	Quality     string `json:"quality"`    // lossless, high, medium, low, tiny
+	CustomCRF   int    `json:"customCrf"`  // Codec scale: 0-51, AV1/VP9 0-63; overrides quality
+	Preset      string `json:"preset"`     // ultrafast, fast, medium, slow, veryslow
+	Resolution  string `json:"resolution"` // e.g. "1920x1080"
+	KeepAudio   bool   `json:"keepAudio"`
+	CustomName  string `json:"customName"`  // Custom output filename (without extension)
+	OperationID string `json:"operationId"` // Optional; generated when empty, used by CancelConversion
+
+	TargetSizeMB float64 `json:"targetSizeMb"` // 0 = quality mode; otherwise two-pass encodes to fit this size
+	AudioBitrate int     `json:"audioBitrate"` // kbps; 0 = automatic
+
+	VideoCodec       string  `json:"videoCodec"`       // h264, hevc, av1, vp9; empty = format default
+	AudioCodec       string  `json:"audioCodec"`       // aac, mp3, opus, vorbis, flac; empty = format default
+	PixelFormat      string  `json:"pixelFormat"`      // e.g. yuv420p, yuv420p10le
+	TenBit           bool    `json:"tenBit"`           // 10-bit output
+	FPS              float64 `json:"fps"`              // 0 keeps the source frame rate
+	KeyframeInterval float64 `json:"keyframeInterval"` // Seconds; 0 = encoder default
+
+	Watermark       *converter.Watermark `json:"watermark"`       // Optional logo or text overlay
+	WatermarkPreset string               `json:"watermarkPreset"` // Saved watermark by name; overrides Watermark
+	Speed           *converter.Speed     `json:"speed"`           // Optional 0.25x-4x speed change or reverse
 }
 
 // ConversionResult represents the result of any conversion.
@@ -215,6 +356,12 @@ type ConversionResult struct {
 	Compression  float64 `json:"compression"` // Percentage saved
 	Success      bool    `json:"success"`
 	ErrorMessage string  `json:"errorMessage,omitempty"`
+	TargetSize   int64   `json:"targetSize,omitempty"` // Requested size limit in bytes, if any
+	TargetMet    bool    `json:"targetMet,omitempty"`  // Whether the output fits TargetSize
+	OperationID  string  `json:"operationId"`
+	Cancelled    bool    `json:"cancelled,omitempty"`
+
+	Parts []converter.SplitFile `json:"parts,omitempty"` // Written parts of an audio split
 }
 
 // ConvertVideo converts a video to another format.
@@ -258,46 +405,100 @@ func (h *ConverterHandler) ConvertVideo(req VideoConvertRequest) (*ConversionRes
 		quality = converter.VideoQualityMedium
 	}
 
-	result, err := converter.ConvertVideo(converter.VideoConvertOptions{
-		InputPath:  req.InputPath,
-		OutputDir:  req.OutputDir,
-		Format:     format,
-		Quality:    quality,
-		CustomCRF:  req.CustomCRF,
-		Preset:     req.Preset,
-		Resolution: req.Resolution,
-		KeepAudio:  req.KeepAudio,
-		FFmpegPath: ffmpegPath,
-		CustomName: req.CustomName,
+	watermark, err := h.resolveWatermark(req.Watermark, req.WatermarkPreset)
+	if err != nil {
+		return nil, err
+	}
+
+	targetSize := megabytes(req.TargetSizeMB)
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	result, err := converter.ConvertVideo(ctx, converter.VideoConvertOptions{
+		InputPath:       req.InputPath,
+		OutputDir:       req.OutputDir,
+		Format:          format,
+		Quality:         quality,
+		CustomCRF:       req.CustomCRF,
+		Preset:          req.Preset,
+		Resolution:      req.Resolution,
+		KeepAudio:       req.KeepAudio,
+		FFmpegPath:      ffmpegPath,
+		CustomName:      req.CustomName,
+		OnProgress:      h.progressReporter(id),
+		TargetSizeBytes: targetSize,
+		AudioBitrate:    req.AudioBitrate,
+
+		VideoCodec:       converter.VideoCodec(req.VideoCodec),
+		AudioCodec:       converter.AudioCodec(req.AudioCodec),
+		PixelFormat:      req.PixelFormat,
+		TenBit:           req.TenBit,
+		FPS:              req.FPS,
+		KeyframeInterval: req.KeyframeInterval,
+		Watermark:        watermark,
+		Speed:            req.Speed,
 	})
 
 	if err != nil {
-		h.consoleLog(fmt.Sprintf("[Converter] Erro: %s", err.Error()))
-		return &ConversionResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-		}, nil
+		return h.failed(id, err), nil
 	}
 
-	compression := 0.0
-	if result.InputSize > 0 {
-		compression = (1.0 - float64(result.OutputSize)/float64(result.InputSize)) * 100
+	converted := h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+		TargetSize: targetSize,
+		TargetMet:  result.TargetMet,
+	})
+	if targetSize > 0 {
+		h.logTargetSize(converted)
+	} else {
+		h.consoleLog(fmt.Sprintf("[Converter] ✓ Conversão concluída! Redução de %.1f%%", converted.Compression))
 	}
+	return converted, nil
+}
 
-	h.consoleLog(fmt.Sprintf("[Converter] ✓ Conversão concluída! Redução de %.1f%%", compression))
+// GetSupportedCodecs lists the selectable video and audio codecs, whether the
+// bundled FFmpeg can encode them and which formats hold them.
+func (h *ConverterHandler) GetSupportedCodecs() ([]converter.CodecSupport, error) {
+	return converter.SupportedCodecs(h.ctx, h.paths.FFmpegPath())
+}
 
-	return &ConversionResult{
-		OutputPath:  result.OutputPath,
-		InputSize:   result.InputSize,
-		OutputSize:  result.OutputSize,
-		Compression: compression,
-		Success:     true,
-	}, nil
+// CompressVideoRequest compresses a video keeping the same format, either to
+// a quality level or to fit a target size.
+type CompressVideoRequest struct {
+	InputPath    string  `json:"inputPath"`
+	Quality      string  `json:"quality"`      // high, medium, low, tiny; ignored with TargetSizeMB
+	TargetSizeMB float64 `json:"targetSizeMb"` // 0 = quality mode; otherwise two-pass encodes to fit this size
+	Preset       string  `json:"preset"`       // ultrafast, fast, medium, slow, veryslow
+	OperationID  string  `json:"operationId"`  // Optional; generated when empty, used by CancelConversion
 }
 
 // CompressVideo compresses a video keeping the same format.
 func (h *ConverterHandler) CompressVideo(inputPath string, quality string, preset string) (*ConversionResult, error) {
+	return h.CompressVideoFile(CompressVideoRequest{InputPath: inputPath, Quality: quality, Preset: preset})
+}
+
+// CompressVideoToSize compresses a video keeping the same format so it fits
+// targetSizeMB, e.g. 25 for chat apps or 100 for email. The result reports
+// whether the target was met.
+func (h *ConverterHandler) CompressVideoToSize(inputPath string, targetSizeMB float64, preset string) (*ConversionResult, error) {
+	if targetSizeMB == 0 {
+		return nil, fmt.Errorf("tamanho alvo inválido: %.1f MB", targetSizeMB)
+	}
+	return h.CompressVideoFile(CompressVideoRequest{InputPath: inputPath, TargetSizeMB: targetSizeMB, Preset: preset})
+}
+
+// CompressVideoFile compresses a video as CompressVideo or, with a target
+// size, as CompressVideoToSize, under a cancellable operation ID.
+func (h *ConverterHandler) CompressVideoFile(req CompressVideoRequest) (*ConversionResult, error) {
+	if req.TargetSizeMB != 0 {
+		return h.compressVideoToSize(req)
+	}
 	ffmpegPath := h.paths.FFmpegPath()
+	inputPath, quality, preset := req.InputPath, req.Quality, req.Preset
 
 	var q converter.VideoQuality
 	switch quality {
@@ -316,28 +517,74 @@ func (h *ConverterHandler) CompressVideo(inputPath string, quality string, prese
 	inputName := filepath.Base(inputPath)
 	h.consoleLog(fmt.Sprintf("[Converter] Comprimindo vídeo: %s (qualidade: %s)", inputName, quality))
 
-	result, err := converter.CompressVideo(inputPath, q, preset, ffmpegPath)
+	id, ctx, done, err := h.startOperation(req.OperationID)
 	if err != nil {
-		return &ConversionResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-		}, nil
+		return nil, err
+	}
+	defer done()
+	result, err := converter.CompressVideo(ctx, inputPath, q, preset, ffmpegPath, h.progressReporter(id))
+	if err != nil {
+		return h.failed(id, err), nil
 	}
 
-	compression := 0.0
-	if result.InputSize > 0 {
-		compression = (1.0 - float64(result.OutputSize)/float64(result.InputSize)) * 100
+	compressed := h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+	})
+	h.consoleLog(fmt.Sprintf("[Converter] ✓ Compressão concluída! Redução de %.1f%%", compressed.Compression))
+	return compressed, nil
+}
+
+func (h *ConverterHandler) compressVideoToSize(req CompressVideoRequest) (*ConversionResult, error) {
+	inputPath, targetSizeMB, preset := req.InputPath, req.TargetSizeMB, req.Preset
+	targetSize := megabytes(targetSizeMB)
+	if targetSize <= 0 {
+		return nil, fmt.Errorf("tamanho alvo inválido: %.1f MB", targetSizeMB)
 	}
 
-	h.consoleLog(fmt.Sprintf("[Converter] ✓ Compressão concluída! Redução de %.1f%%", compression))
+	inputName := filepath.Base(inputPath)
+	h.consoleLog(fmt.Sprintf("[Converter] Comprimindo vídeo: %s (alvo: %.1f MB, duas passadas)", inputName, targetSizeMB))
 
-	return &ConversionResult{
-		OutputPath:  result.OutputPath,
-		InputSize:   result.InputSize,
-		OutputSize:  result.OutputSize,
-		Compression: compression,
-		Success:     true,
-	}, nil
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	result, err := converter.CompressVideoToSize(ctx, inputPath, targetSize, preset, h.paths.FFmpegPath(), h.progressReporter(id))
+	if err != nil {
+		return h.failed(id, err), nil
+	}
+
+	compressed := h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+		TargetSize: targetSize,
+		TargetMet:  result.TargetMet,
+	})
+	h.logTargetSize(compressed)
+	return compressed, nil
+}
+
+// logTargetSize reports the achieved size against the requested one.
+func (h *ConverterHandler) logTargetSize(result *ConversionResult) {
+	achieved := float64(result.OutputSize) / 1e6
+	target := float64(result.TargetSize) / 1e6
+	if result.TargetMet {
+		h.consoleLog(fmt.Sprintf("[Converter] ✓ Vídeo com %.1f MB (alvo: %.1f MB)", achieved, target))
+	} else {
+		h.consoleLog(fmt.Sprintf("[Converter] ⚠ Vídeo com %.1f MB, acima do alvo de %.1f MB; reduza a resolução ou o trecho", achieved, target))
+	}
+}
+
+// megabytes converts a size in MB (10^6 bytes, as chat and email limits are
+// quoted) to bytes.
+func megabytes(mb float64) int64 {
+	if mb <= 0 {
+		return 0
+	}
+	return int64(mb * 1e6)
 }
 
 // =============================================================================
@@ -352,6 +599,9 @@ type AudioExtractRequest struct {
 	Quality       string `json:"quality"`       // low, medium, high, best
 	CustomBitrate int    `json:"customBitrate"` // kbps, overrides quality
 	CustomName    string `json:"customName"`    // Custom output filename (without extension)
+	OperationID   string `json:"operationId"`   // Optional; generated when empty, used by CancelConversion
+
+	Speed *converter.Speed `json:"speed"` // Optional 0.25x-4x speed change or reverse
 }
 
 // ExtractAudio extracts audio from a video file.
@@ -361,73 +611,302 @@ func (h *ConverterHandler) ExtractAudio(req AudioExtractRequest) (*ConversionRes
 
 	h.consoleLog(fmt.Sprintf("[Converter] Extraindo áudio: %s → %s", inputName, req.Format))
 
-	var format converter.AudioFormat
-	switch req.Format {
+	format, err := audioFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	result, err := converter.ExtractAudio(ctx, converter.AudioExtractOptions{
+		InputPath:     req.InputPath,
+		OutputDir:     req.OutputDir,
+		Format:        format,
+		Quality:       audioQuality(req.Quality),
+		CustomBitrate: req.CustomBitrate,
+		FFmpegPath:    ffmpegPath,
+		CustomName:    req.CustomName,
+		OnProgress:    h.progressReporter(id),
+		Speed:         req.Speed,
+	})
+
+	if err != nil {
+		return h.failed(id, err), nil
+	}
+
+	// Fix: calcular a economia de tamanho (vídeo → áudio é sempre drástico)
+	extracted := h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+	})
+	h.consoleLog(fmt.Sprintf("[Converter] ✓ Áudio extraído: %s (redução de %.1f%%)", filepath.Base(result.OutputPath), extracted.Compression))
+	return extracted, nil
+}
+
+// AudioSplitRequest splits the audio of a file into numbered parts.
+type AudioSplitRequest struct {
+	InputPath     string `json:"inputPath"`
+	OutputDir     string `json:"outputDir"`
+	Format        string `json:"format"`        // mp3, aac, m4a, flac, wav, ogg, opus
+	Quality       string `json:"quality"`       // low, medium, high, best
+	CustomBitrate int    `json:"customBitrate"` // kbps, overrides quality
+	Channels      int    `json:"channels"`      // 1 = mono, 2 = stereo, 0 keeps the source
+	CustomName    string `json:"customName"`    // Base name of the parts (without extension)
+	OperationID   string `json:"operationId"`   // Optional; generated when empty, used by CancelConversion
+
+	Mode             string  `json:"mode"`             // chapters, silence, length
+	SegmentSeconds   float64 `json:"segmentSeconds"`   // Length mode: seconds per part
+	SilenceThreshold float64 `json:"silenceThreshold"` // Silence mode: dB, 0 = -35
+	MinSilence       float64 `json:"minSilence"`       // Silence mode: shortest pause in seconds, 0 = 2
+	MinPartSeconds   float64 `json:"minPartSeconds"`   // Silence mode: shortest part in seconds, 0 = 5
+	Zip              bool    `json:"zip"`              // One zip instead of a folder of parts
+}
+
+func (h *ConverterHandler) audioSplitOptions(req AudioSplitRequest, onProgress converter.ProgressFunc) (converter.AudioSplitOptions, error) {
+	format, err := audioFormat(req.Format)
+	if err != nil {
+		return converter.AudioSplitOptions{}, err
+	}
+	return converter.AudioSplitOptions{
+		InputPath:        req.InputPath,
+		OutputDir:        req.OutputDir,
+		Format:           format,
+		Quality:          audioQuality(req.Quality),
+		CustomBitrate:    req.CustomBitrate,
+		Channels:         req.Channels,
+		FFmpegPath:       h.paths.FFmpegPath(),
+		CustomName:       req.CustomName,
+		OnProgress:       onProgress,
+		Mode:             req.Mode,
+		SegmentSeconds:   req.SegmentSeconds,
+		SilenceThreshold: req.SilenceThreshold,
+		MinSilence:       req.MinSilence,
+		MinPartSeconds:   req.MinPartSeconds,
+		Zip:              req.Zip,
+	}, nil
+}
+
+// PreviewAudioSplit returns the split points without writing any file.
+// Silence mode scans the whole input and reports progress while it does.
+func (h *ConverterHandler) PreviewAudioSplit(req AudioSplitRequest) ([]converter.SplitPart, error) {
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	opts, err := h.audioSplitOptions(req, h.progressReporter(id))
+	if err != nil {
+		return nil, err
+	}
+	return converter.PreviewAudioSplit(ctx, opts)
+}
+
+// SplitAudio writes the audio of a file as numbered parts, in a folder or
+// a zip.
+func (h *ConverterHandler) SplitAudio(req AudioSplitRequest) (*ConversionResult, error) {
+	h.consoleLog(fmt.Sprintf("[Converter] Dividindo áudio: %s", filepath.Base(req.InputPath)))
+
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	opts, err := h.audioSplitOptions(req, h.progressReporter(id))
+	if err != nil {
+		return nil, err
+	}
+	result, err := converter.SplitAudio(ctx, opts)
+	if err != nil {
+		return h.failed(id, err), nil
+	}
+
+	outputPath := result.OutputDir
+	if result.ZipPath != "" {
+		outputPath = result.ZipPath
+	}
+	split := h.succeeded(id, &ConversionResult{
+		OutputPath: outputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+		Parts:      result.Parts,
+	})
+	h.consoleLog(fmt.Sprintf("[Converter] ✓ Áudio dividido em %d parte(s): %s", len(result.Parts), filepath.Base(outputPath)))
+	return split, nil
+}
+
+func audioFormat(name string) (converter.AudioFormat, error) {
+	switch name {
 	case "mp3":
-		format = converter.AudioFormatMP3
+		return converter.AudioFormatMP3, nil
 	case "aac":
-		format = converter.AudioFormatAAC
+		return converter.AudioFormatAAC, nil
 	case "m4a":
-		format = converter.AudioFormatM4A
+		return converter.AudioFormatM4A, nil
 	case "flac":
-		format = converter.AudioFormatFLAC
+		return converter.AudioFormatFLAC, nil
 	case "wav":
-		format = converter.AudioFormatWAV
+		return converter.AudioFormatWAV, nil
 	case "ogg":
-		format = converter.AudioFormatOGG
+		return converter.AudioFormatOGG, nil
 	case "opus":
-		format = converter.AudioFormatOPUS
+		return converter.AudioFormatOPUS, nil
 	default:
-		return nil, fmt.Errorf("formato de áudio não suportado: %s", req.Format)
+		return "", fmt.Errorf("formato de áudio não suportado: %s", name)
 	}
+}
 
-	var quality converter.AudioQuality
-	switch req.Quality {
+func audioQuality(name string) converter.AudioQuality {
+	switch name {
 	case "low":
-		quality = converter.AudioQualityLow
-	case "medium":
-		quality = converter.AudioQualityMedium
+		return converter.AudioQualityLow
 	case "high":
-		quality = converter.AudioQualityHigh
+		return converter.AudioQualityHigh
 	case "best":
-		quality = converter.AudioQualityBest
+		return converter.AudioQualityBest
 	default:
-		quality = converter.AudioQualityMedium
+		return converter.AudioQualityMedium
 	}
+}
 
-	result, err := converter.ExtractAudio(converter.AudioExtractOptions{
-		InputPath:     req.InputPath,
-		OutputDir:     req.OutputDir,
-		Format:        format,
-		Quality:       quality,
-		CustomBitrate: req.CustomBitrate,
-		FFmpegPath:    ffmpegPath,
-		CustomName:    req.CustomName,
+// =============================================================================
+// ANIMATION EXPORT (GIF / WebP)
+// =============================================================================
+
+// AnimationExportRequest represents a GIF/animated WebP export request.
+type AnimationExportRequest struct {
+	InputPath     string  `json:"inputPath"`
+	OutputDir     string  `json:"outputDir"`
+	Format        string  `json:"format"`        // gif, webp
+	StartTime     float64 `json:"startTime"`     // seconds
+	EndTime       float64 `json:"endTime"`       // seconds, 0 = until the end
+	FPS           int     `json:"fps"`           // default 12
+	Width         int     `json:"width"`         // default 480
+	Loop          int     `json:"loop"`          // 0 = forever, N = play N times
+	Quality       int     `json:"quality"`       // 1-100
+	TargetSizeKB  int     `json:"targetSizeKb"`  // 0 = no size limit
+	SubtitlesPath string  `json:"subtitlesPath"` // Optional .ass/.srt/.vtt burned into the frames
+	CustomName    string  `json:"customName"`    // Custom output filename (without extension)
+	OperationID   string  `json:"operationId"`   // Optional; generated when empty, used by CancelConversion
+}
+
+// ExportAnimation renders a range of a video as an optimized GIF or animated WebP.
+func (h *ConverterHandler) ExportAnimation(req AnimationExportRequest) (*ConversionResult, error) {
+	ffmpegPath := h.paths.FFmpegPath()
+	inputName := filepath.Base(req.InputPath)
+
+	var format converter.AnimationFormat
+	switch req.Format {
+	case "gif", "":
+		format = converter.AnimationFormatGIF
+	case "webp":
+		format = converter.AnimationFormatWebP
+	default:
+		return nil, fmt.Errorf("formato de animação não suportado: %s", req.Format)
+	}
+
+	h.consoleLog(fmt.Sprintf("[Converter] Gerando %s animado: %s", format, inputName))
+
+	targetSize := int64(req.TargetSizeKB) * 1024
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	result, err := converter.ExportAnimation(ctx, converter.AnimationExportOptions{
+		InputPath:       req.InputPath,
+		OutputDir:       req.OutputDir,
+		Format:          format,
+		StartTime:       req.StartTime,
+		EndTime:         req.EndTime,
+		FPS:             req.FPS,
+		Width:           req.Width,
+		Loop:            req.Loop,
+		Quality:         req.Quality,
+		TargetSizeBytes: targetSize,
+		SubtitlesPath:   req.SubtitlesPath,
+		FFmpegPath:      ffmpegPath,
+		CustomName:      req.CustomName,
+		OnProgress:      h.progressReporter(id),
 	})
 
 	if err != nil {
-		h.consoleLog(fmt.Sprintf("[Converter] Erro: %s", err.Error()))
-		return &ConversionResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-		}, nil
+		return h.failed(id, err), nil
 	}
 
-	// Fix: calcular a economia de tamanho (vídeo → áudio é sempre drástico)
-	compression := 0.0
-	if result.InputSize > 0 {
-		compression = (1.0 - float64(result.OutputSize)/float64(result.InputSize)) * 100
+	if result.TargetMet {
+		h.consoleLog(fmt.Sprintf("[Converter] ✓ Animação gerada: %.1f KB (%d tentativa(s))", float64(result.OutputSize)/1024, result.Attempts))
+	} else {
+		h.consoleLog(fmt.Sprintf("[Converter] ⚠ Animação gerada com %.1f KB, acima do limite de %d KB", float64(result.OutputSize)/1024, req.TargetSizeKB))
 	}
 
-	h.consoleLog(fmt.Sprintf("[Converter] ✓ Áudio extraído: %s (redução de %.1f%%)", filepath.Base(result.OutputPath), compression))
+	return h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+		TargetSize: targetSize,
+		TargetMet:  result.TargetMet,
+	}), nil
+}
 
-	return &ConversionResult{
-		OutputPath:  result.OutputPath,
-		InputSize:   result.InputSize,
-		OutputSize:  result.OutputSize,
-		Compression: compression,
-		Success:     true,
-	}, nil
+// =============================================================================
+// MERGE / CONCAT
+// =============================================================================
+
+// ConcatRequest joins several videos or audio files into one.
+type ConcatRequest struct {
+	InputPaths    []string `json:"inputPaths"` // In playback order
+	OutputDir     string   `json:"outputDir"`
+	Format        string   `json:"format"`        // Output extension; empty = same as the first file
+	CustomName    string   `json:"customName"`    // Custom output filename (without extension)
+	Crossfade     float64  `json:"crossfade"`     // Seconds, 0 = hard cut
+	Chapters      bool     `json:"chapters"`      // One chapter per file
+	ForceReencode bool     `json:"forceReencode"` // Re-encode even when a lossless join is possible
+	Quality       string   `json:"quality"`       // Re-encode quality: high, medium, low, tiny
+	Preset        string   `json:"preset"`        // Re-encode speed preset
+	OperationID   string   `json:"operationId"`   // Optional; generated when empty, used by CancelConversion
+}
+
+// ConcatMedia joins files end to end, losslessly when their streams match.
+func (h *ConverterHandler) ConcatMedia(req ConcatRequest) (*ConversionResult, error) {
+	h.consoleLog(fmt.Sprintf("[Converter] Unindo %d arquivo(s)...", len(req.InputPaths)))
+
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	result, err := converter.Concat(ctx, converter.ConcatOptions{
+		InputPaths:    req.InputPaths,
+		OutputDir:     req.OutputDir,
+		Format:        req.Format,
+		CustomName:    req.CustomName,
+		Crossfade:     req.Crossfade,
+		Chapters:      req.Chapters,
+		ForceReencode: req.ForceReencode,
+		Quality:       converter.VideoQuality(req.Quality),
+		Preset:        req.Preset,
+		FFmpegPath:    h.paths.FFmpegPath(),
+		OnProgress:    h.progressReporter(id),
+	})
+	if err != nil {
+		return h.failed(id, err), nil
+	}
+
+	joined := h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+	})
+	if result.Lossless {
+		h.consoleLog(fmt.Sprintf("[Converter] ✓ Arquivos unidos sem recodificar: %s", filepath.Base(result.OutputPath)))
+	} else {
+		h.consoleLog(fmt.Sprintf("[Converter] ✓ Arquivos unidos e recodificados: %s", filepath.Base(result.OutputPath)))
+	}
+	return joined, nil
 }
 
 // =============================================================================
@@ -436,13 +915,17 @@ func (h *ConverterHandler) ExtractAudio(req AudioExtractRequest) (*ConversionRes
 
 // ImageConvertRequest represents an image conversion request.
 type ImageConvertRequest struct {
-	InputPath  string `json:"inputPath"`
-	OutputDir  string `json:"outputDir"`
-	Format     string `json:"format"`     // jpg, png, webp, avif, bmp, tiff
-	Quality    int    `json:"quality"`    // 0-100
-	Width      int    `json:"width"`      // 0 = keep original
-	Height     int    `json:"height"`     // 0 = keep original
-	CustomName string `json:"customName"` // Custom output filename (without extension)
+	InputPath   string `json:"inputPath"`
+	OutputDir   string `json:"outputDir"`
+	Format      string `json:"format"`      // jpg, png, webp, avif, bmp, tiff
+	Quality     int    `json:"quality"`     // 0-100
+	Width       int    `json:"width"`       // 0 = keep original
+	Height      int    `json:"height"`      // 0 = keep original
+	CustomName  string `json:"customName"`  // Custom output filename (without extension)
+	OperationID string `json:"operationId"` // Optional; generated when empty, used by CancelConversion
+
+	Watermark       *converter.Watermark `json:"watermark"`       // Optional logo or text overlay
+	WatermarkPreset string               `json:"watermarkPreset"` // Saved watermark by name; overrides Watermark
 }
 
 // ConvertImage converts an image to another format.
@@ -469,8 +952,17 @@ func (h *ConverterHandler) ConvertImage(req ImageConvertRequest) (*ConversionRes
 	default:
 		return nil, fmt.Errorf("formato de imagem não suportado: %s", req.Format)
 	}
+	watermark, err := h.resolveWatermark(req.Watermark, req.WatermarkPreset)
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := converter.ConvertImage(converter.ImageConvertOptions{
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	result, err := converter.ConvertImage(ctx, converter.ImageConvertOptions{
 		InputPath:   req.InputPath,
 		OutputDir:   req.OutputDir,
 		Format:      format,
@@ -480,56 +972,346 @@ func (h *ConverterHandler) ConvertImage(req ImageConvertRequest) (*ConversionRes
 		FFmpegPath:  ffmpegPath,
 		AvifencPath: h.paths.AvifencPath(),
 		CustomName:  req.CustomName,
+		OnProgress:  h.progressReporter(id),
+		Watermark:   watermark,
 	})
 
 	if err != nil {
-		return &ConversionResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-		}, nil
-	}
-
-	compression := 0.0
-	if result.InputSize > 0 {
-		compression = (1.0 - float64(result.OutputSize)/float64(result.InputSize)) * 100
+		return h.failed(id, err), nil
 	}
 
-	h.consoleLog(fmt.Sprintf("[Converter] ✓ Imagem convertida! Redução de %.1f%%", compression))
+	converted := h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+	})
+	h.consoleLog(fmt.Sprintf("[Converter] ✓ Imagem convertida! Redução de %.1f%%", converted.Compression))
+	return converted, nil
+}
 
-	return &ConversionResult{
-		OutputPath:  result.OutputPath,
-		InputSize:   result.InputSize,
-		OutputSize:  result.OutputSize,
-		Compression: compression,
-		Success:     true,
-	}, nil
+// CompressImageRequest compresses an image keeping the same format.
+type CompressImageRequest struct {
+	InputPath   string `json:"inputPath"`
+	Quality     int    `json:"quality"`     // 0-100
+	OperationID string `json:"operationId"` // Optional; generated when empty, used by CancelConversion
 }
 
 // CompressImage compresses an image keeping the same format.
 func (h *ConverterHandler) CompressImage(inputPath string, quality int) (*ConversionResult, error) {
+	return h.CompressImageFile(CompressImageRequest{InputPath: inputPath, Quality: quality})
+}
+
+// CompressImageFile compresses an image under a cancellable operation ID.
+func (h *ConverterHandler) CompressImageFile(req CompressImageRequest) (*ConversionResult, error) {
 	ffmpegPath := h.paths.FFmpegPath()
+	inputPath, quality := req.InputPath, req.Quality
 	inputName := filepath.Base(inputPath)
 
 	h.consoleLog(fmt.Sprintf("[Converter] Comprimindo imagem: %s (qualidade: %d%%)", inputName, quality))
 
-	result, err := converter.CompressImage(inputPath, quality, ffmpegPath, h.paths.AvifencPath())
+	id, ctx, done, err := h.startOperation(req.OperationID)
 	if err != nil {
-		return &ConversionResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-		}, nil
+		return nil, err
+	}
+	defer done()
+	result, err := converter.CompressImage(ctx, inputPath, quality, ffmpegPath, h.paths.AvifencPath())
+	if err != nil {
+		return h.failed(id, err), nil
 	}
 
-	compression := 0.0
-	if result.InputSize > 0 {
-		compression = (1.0 - float64(result.OutputSize)/float64(result.InputSize)) * 100
+	return h.succeeded(id, &ConversionResult{
+		OutputPath: result.OutputPath,
+		InputSize:  result.InputSize,
+		OutputSize: result.OutputSize,
+	}), nil
+}
+
+// =============================================================================
+// CONVERSION QUEUE (BATCH)
+// =============================================================================
+
+// ConversionBatchRequest applies one set of options to many files.
+type ConversionBatchRequest struct {
+	Kind       string             `json:"kind"` // video, audio, image
+	InputPaths []string           `json:"inputPaths"`
+	Options    conversion.Options `json:"options"`
+	Preset     string             `json:"preset"` // Named preset; replaces Kind and Options except OutputDir
+}
+
+// SetConversionManager enables the persistent conversion queue.
+func (h *ConverterHandler) SetConversionManager(manager *conversion.Manager) {
+	h.conversions = manager
+}
+
+func (h *ConverterHandler) conversionManager() (*conversion.Manager, error) {
+	if h.conversions == nil {
+		return nil, fmt.Errorf("fila de conversão não disponível")
 	}
+	return h.conversions, nil
+}
 
-	return &ConversionResult{
-		OutputPath:  result.OutputPath,
-		InputSize:   result.InputSize,
-		OutputSize:  result.OutputSize,
-		Compression: compression,
-		Success:     true,
-	}, nil
+// QueueConversions queues every file of a batch. Missing files are recorded
+// as failed; the others are converted regardless of earlier errors.
+func (h *ConverterHandler) QueueConversions(req ConversionBatchRequest) (*conversion.Batch, error) {
+	manager, err := h.conversionManager()
+	if err != nil {
+		return nil, err
+	}
+	if req.Preset != "" {
+		preset, err := h.conversionPresets().Get(req.Preset)
+		if err != nil {
+			return nil, fmt.Errorf("preset não encontrado: %s", req.Preset)
+		}
+		outputDir := req.Options.OutputDir
+		req.Kind, req.Options = preset.Kind, preset.Options
+		req.Options.OutputDir = outputDir
+	}
+	batch, err := manager.AddBatch(req.Kind, req.InputPaths, req.Options)
+	if err != nil {
+		h.consoleLog(fmt.Sprintf("[Converter] Erro ao enfileirar lote: %s", err.Error()))
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Converter] %d arquivo(s) adicionados à fila de conversão", len(batch.Conversions)))
+	return batch, nil
+}
+
+// CancelConversionBatch cancels every unfinished file of a batch.
+func (h *ConverterHandler) CancelConversionBatch(batchID string) error {
+	manager, err := h.conversionManager()
+	if err != nil {
+		return err
+	}
+	h.consoleLog("[Converter] Cancelando lote de conversão...")
+	return manager.CancelBatch(batchID)
+}
+
+// GetConversionQueue returns pending and running queued conversions.
+func (h *ConverterHandler) GetConversionQueue() ([]*storage.Conversion, error) {
+	manager, err := h.conversionManager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.GetQueue()
+}
+
+// GetConversionHistory returns finished queued conversions.
+func (h *ConverterHandler) GetConversionHistory(limit int) ([]*storage.Conversion, error) {
+	manager, err := h.conversionManager()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	return manager.GetHistory(limit)
+}
+
+// GetConversionBatch returns every file of a batch with its result.
+func (h *ConverterHandler) GetConversionBatch(batchID string) ([]*storage.Conversion, error) {
+	manager, err := h.conversionManager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.GetBatch(batchID)
+}
+
+// GetConversionBatchStats returns the aggregated results of a batch.
+func (h *ConverterHandler) GetConversionBatchStats(batchID string) (*conversion.BatchStats, error) {
+	manager, err := h.conversionManager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.GetBatchStats(batchID)
+}
+
+// ClearConversionHistory removes all finished queued conversions.
+func (h *ConverterHandler) ClearConversionHistory() error {
+	manager, err := h.conversionManager()
+	if err != nil {
+		return err
+	}
+	return manager.ClearHistory()
+}
+
+// =============================================================================
+// CONVERSION PRESETS
+// =============================================================================
+
+// SetConversionPresets enables user presets; without them only the built-in
+// presets are listed.
+func (h *ConverterHandler) SetConversionPresets(store *conversion.PresetStore, conv *conversion.PresetConverter) {
+	h.presets = store
+	h.presetConverter = conv
+}
+
+func (h *ConverterHandler) conversionPresets() *conversion.PresetStore {
+	if h.presets == nil {
+		return conversion.NewPresetStore(nil)
+	}
+	return h.presets
+}
+
+// ListConversionPresets returns the built-in presets followed by the user's.
+func (h *ConverterHandler) ListConversionPresets() ([]conversion.Preset, error) {
+	return h.conversionPresets().List()
+}
+
+// SaveConversionPreset creates a preset, or updates it when it has an ID.
+func (h *ConverterHandler) SaveConversionPreset(preset conversion.Preset) (*conversion.Preset, error) {
+	saved, err := h.conversionPresets().Save(preset)
+	if err != nil {
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Converter] Preset salvo: %s", saved.Name))
+	return saved, nil
+}
+
+// DeleteConversionPreset removes a user preset.
+func (h *ConverterHandler) DeleteConversionPreset(id string) error {
+	return h.conversionPresets().Delete(id)
+}
+
+// ExportConversionPresets writes user presets to a JSON file. An empty ids
+// exports all of them and an empty path asks where to save. It returns the
+// written file, or "" when the dialog was dismissed.
+func (h *ConverterHandler) ExportConversionPresets(ids []string, path string) (string, error) {
+	if path == "" {
+		var err error
+		path, err = application.Get().Dialog.SaveFile().
+			SetMessage("Exportar Presets").
+			SetFilename("kingo-presets.json").
+			AddFilter("JSON", "*.json").
+			PromptForSingleSelection()
+		if err != nil || path == "" {
+			return "", err
+		}
+		if !strings.HasSuffix(strings.ToLower(path), ".json") {
+			path += ".json"
+		}
+	}
+	count, err := h.conversionPresets().Export(path, ids)
+	if err != nil {
+		return "", err
+	}
+	h.consoleLog(fmt.Sprintf("[Converter] %d preset(s) exportado(s): %s", count, filepath.Base(path)))
+	return path, nil
+}
+
+// ImportConversionPresets reads presets exported by ExportConversionPresets.
+// An empty path asks for the file; nil is returned when it was dismissed.
+func (h *ConverterHandler) ImportConversionPresets(path string) (*conversion.ImportResult, error) {
+	if path == "" {
+		var err error
+		path, err = application.Get().Dialog.OpenFile().
+			SetTitle("Importar Presets").
+			AddFilter("JSON", "*.json").
+			AddFilter("Todos os Arquivos", "*.*").
+			PromptForSingleSelection()
+		if err != nil || path == "" {
+			return nil, err
+		}
+	}
+	result, err := h.conversionPresets().Import(path)
+	if err != nil {
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Converter] Presets importados: %d novo(s), %d atualizado(s), %d com erro",
+		len(result.Created), len(result.Updated), len(result.Failed)))
+	return result, nil
+}
+
+// PresetConvertRequest converts one file with a named preset.
+type PresetConvertRequest struct {
+	InputPath   string `json:"inputPath"`
+	Preset      string `json:"preset"`
+	OutputDir   string `json:"outputDir"`   // Empty writes next to the input
+	CustomName  string `json:"customName"`  // Custom output filename (without extension)
+	OperationID string `json:"operationId"` // Optional; generated when empty, used by CancelConversion
+}
+
+// ConvertWithPreset converts a file with a named preset.
+func (h *ConverterHandler) ConvertWithPreset(req PresetConvertRequest) (*ConversionResult, error) {
+	if h.presetConverter == nil {
+		return nil, fmt.Errorf("presets de conversão não disponíveis")
+	}
+	h.consoleLog(fmt.Sprintf("[Converter] Convertendo com o preset \"%s\": %s", req.Preset, filepath.Base(req.InputPath)))
+
+	id, ctx, done, err := h.startOperation(req.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	output, err := h.presetConverter.ConvertWithPreset(ctx, req.Preset, req.InputPath, req.OutputDir, req.CustomName, h.progressReporter(id))
+	if err != nil {
+		return h.failed(id, err), nil
+	}
+
+	converted := h.succeeded(id, &ConversionResult{
+		OutputPath: output.OutputPath,
+		InputSize:  output.InputSize,
+		OutputSize: output.OutputSize,
+	})
+	h.consoleLog(fmt.Sprintf("[Converter] ✓ Convertido: %s (redução de %.1f%%)", filepath.Base(output.OutputPath), converted.Compression))
+	return converted, nil
+}
+
+// =============================================================================
+// WATERMARKS
+// =============================================================================
+
+// SetWatermarkPresets enables saved watermarks.
+func (h *ConverterHandler) SetWatermarkPresets(store *conversion.WatermarkStore) {
+	h.watermarks = store
+}
+
+func (h *ConverterHandler) watermarkPresets() (*conversion.WatermarkStore, error) {
+	if h.watermarks == nil {
+		return nil, errors.New("marcas d'água salvas não estão disponíveis")
+	}
+	return h.watermarks, nil
+}
+
+// resolveWatermark returns the saved watermark named preset, or inline when
+// no preset is given.
+func (h *ConverterHandler) resolveWatermark(inline *converter.Watermark, preset string) (*converter.Watermark, error) {
+	if strings.TrimSpace(preset) == "" {
+		return inline, nil
+	}
+	store, err := h.watermarkPresets()
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(preset)
+}
+
+// ListWatermarkPresets returns the saved watermarks.
+func (h *ConverterHandler) ListWatermarkPresets() ([]conversion.WatermarkPreset, error) {
+	store, err := h.watermarkPresets()
+	if err != nil {
+		return nil, err
+	}
+	return store.List()
+}
+
+// SaveWatermarkPreset creates a watermark, or updates it when it has an ID.
+func (h *ConverterHandler) SaveWatermarkPreset(preset conversion.WatermarkPreset) (*conversion.WatermarkPreset, error) {
+	store, err := h.watermarkPresets()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := store.Save(preset)
+	if err != nil {
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Converter] Marca d'água salva: %s", saved.Name))
+	return saved, nil
+}
+
+// DeleteWatermarkPreset removes a saved watermark.
+func (h *ConverterHandler) DeleteWatermarkPreset(id string) error {
+	store, err := h.watermarkPresets()
+	if err != nil {
+		return err
+	}
+	return store.Delete(id)
 }
@@ -0,0 +1,20 @@
+package handlers
+
+import "testing"
+
+func TestStartOperationRejectsDuplicateID(t *testing.T) {
+	h := NewConverterHandler(nil)
+	id, _, done, err := h.startOperation("compress-1")
+	if err != nil || id != "compress-1" {
+		t.Fatalf("startOperation() = %q, %v", id, err)
+	}
+	if _, _, _, err := h.startOperation("compress-1"); err == nil {
+		t.Fatal("expected a duplicate operation ID to be rejected")
+	}
+	done()
+	if _, _, again, err := h.startOperation("compress-1"); err != nil {
+		t.Fatalf("ID was not released after the operation finished: %v", err)
+	} else {
+		again()
+	}
+}
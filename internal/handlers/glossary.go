@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"kingo/internal/storage"
+)
+
+// GlossaryHandler manages the named word lists used by Whisper.
+type GlossaryHandler struct {
+	ctx  context.Context
+	repo *storage.GlossaryRepository
+}
+
+// NewGlossaryHandler creates a new GlossaryHandler.
+func NewGlossaryHandler(repo *storage.GlossaryRepository) *GlossaryHandler {
+	return &GlossaryHandler{
+		ctx:  context.Background(),
+		repo: repo,
+	}
+}
+
+// SetContext sets the Wails runtime context.
+func (h *GlossaryHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// ListGlossaries returns every glossary ordered by name.
+func (h *GlossaryHandler) ListGlossaries() ([]*storage.Glossary, error) {
+	return h.repo.List()
+}
+
+// SaveGlossary creates the glossary when it has no ID and updates it otherwise.
+func (h *GlossaryHandler) SaveGlossary(glossary storage.Glossary) (*storage.Glossary, error) {
+	var err error
+	if glossary.ID == "" {
+		err = h.repo.Create(&glossary)
+	} else {
+		err = h.repo.Update(&glossary)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &glossary, nil
+}
+
+// DeleteGlossary removes a glossary and detaches it everywhere.
+func (h *GlossaryHandler) DeleteGlossary(id string) error {
+	return h.repo.Delete(id)
+}
+
+// AttachGlossary links a glossary to a transcription or download.
+// targetType is "transcription" or "download".
+func (h *GlossaryHandler) AttachGlossary(glossaryID, targetType, targetID string) error {
+	glossary, err := h.repo.GetByID(glossaryID)
+	if err != nil {
+		return err
+	}
+	if glossary == nil {
+		return fmt.Errorf("glossary not found: %s", glossaryID)
+	}
+	return h.repo.Attach(glossaryID, targetType, targetID)
+}
+
+// DetachGlossary removes a glossary link.
+func (h *GlossaryHandler) DetachGlossary(glossaryID, targetType, targetID string) error {
+	return h.repo.Detach(glossaryID, targetType, targetID)
+}
+
+// GetAttachedGlossaries returns the glossaries linked to a transcription or
+// download.
+func (h *GlossaryHandler) GetAttachedGlossaries(targetType, targetID string) ([]*storage.Glossary, error) {
+	return h.repo.ListFor(targetType, targetID)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"kingo/internal/storage"
+)
+
+// SearchHandler exposes full-text search over downloads and transcripts.
+type SearchHandler struct {
+	ctx  context.Context
+	repo *storage.SearchRepository
+}
+
+// NewSearchHandler creates a new SearchHandler.
+func NewSearchHandler(repo *storage.SearchRepository) *SearchHandler {
+	return &SearchHandler{
+		ctx:  context.Background(),
+		repo: repo,
+	}
+}
+
+// SetContext sets the Wails runtime context.
+func (h *SearchHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// Search returns downloads whose title, uploader or description match the
+// query, and transcript segments that contain it with their timestamps so the
+// player can seek straight to the moment it was said.
+func (h *SearchHandler) Search(query string, limit int) (*storage.SearchResults, error) {
+	return h.repo.Search(strings.TrimSpace(query), limit)
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"kingo/internal/app"
+	"kingo/internal/storage"
+	"kingo/internal/transcription"
 	"kingo/internal/whisper"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
@@ -17,6 +19,8 @@ type TranscriberHandler struct {
 	ctx            context.Context
 	paths          *app.Paths
 	whisper        *whisper.Client
+	queue          *transcription.Manager
+	glossaries     *storage.GlossaryRepository
 	consoleEmitter func(string)
 }
 
@@ -27,6 +31,31 @@ type TranscribeRequest struct {
 	Language     string `json:"language"`
 	OutputFormat string `json:"outputFormat"`
 	UseVAD       bool   `json:"useVad"`
+	Task         string `json:"task"`     // transcribe (default), translate (to English) or bilingual
+	JobID        string `json:"jobId"`    // Optional; echoed on whisper:transcribe-progress events
+	Speakers     string `json:"speakers"` // "", auto, tinydiarize, stereo or energy
+	Prompt       string `json:"prompt"`   // Initial prompt: names and jargon Whisper should spell right
+
+	// GlossaryIDs adds stored glossaries: their terms extend the prompt and
+	// their find/replace rules run over the segments.
+	GlossaryIDs []string `json:"glossaryIds"`
+}
+
+// TranscribeFolderRequest transcribes every media file of a folder with the
+// same settings.
+type TranscribeFolderRequest struct {
+	Dir         string   `json:"dir"`
+	Recursive   bool     `json:"recursive"`
+	Model       string   `json:"model"`
+	Language    string   `json:"language"`
+	UseVAD      bool     `json:"useVad"`
+	Task        string   `json:"task"`
+	Speakers    string   `json:"speakers"`
+	Prompt      string   `json:"prompt"`
+	GlossaryIDs []string `json:"glossaryIds"`
+	Formats     []string `json:"formats"`   // Export formats written for every file, txt when empty
+	OutputDir   string   `json:"outputDir"` // Root of a mirror tree; empty writes next to each file
+	Reprocess   bool     `json:"reprocess"` // Also transcribe files transcribed before
 }
 
 // NewTranscriberHandler creates a new TranscriberHandler.
@@ -50,6 +79,16 @@ func (h *TranscriberHandler) SetConsoleEmitter(emitter func(string)) {
 	h.consoleEmitter = emitter
 }
 
+// SetTranscriptionManager wires the persistent transcription queue.
+func (h *TranscriberHandler) SetTranscriptionManager(manager *transcription.Manager) {
+	h.queue = manager
+}
+
+// SetGlossaryRepository enables glossaries in transcription requests.
+func (h *TranscriberHandler) SetGlossaryRepository(repo *storage.GlossaryRepository) {
+	h.glossaries = repo
+}
+
 func (h *TranscriberHandler) consoleLog(message string) {
 	if h.consoleEmitter != nil {
 		h.consoleEmitter(message)
@@ -84,7 +123,28 @@ func (h *TranscriberHandler) TranscribeFile(req TranscribeRequest) (*whisper.Tra
 	inputName := filepath.Base(req.FilePath)
 	h.consoleLog(fmt.Sprintf("[Transcriber] Transcribing: %s (model: %s)", inputName, req.Model))
 
-	result, err := h.whisper.TranscribeFile(req.FilePath, req.Model, req.Language, req.OutputFormat, req.UseVAD)
+	opts := whisper.TranscribeOptions{
+		Model:        req.Model,
+		Language:     req.Language,
+		OutputFormat: req.OutputFormat,
+		UseVAD:       req.UseVAD,
+		Task:         req.Task,
+		JobID:        req.JobID,
+		Speakers:     req.Speakers,
+		Prompt:       req.Prompt,
+	}
+	if len(req.GlossaryIDs) > 0 {
+		if h.glossaries == nil {
+			return nil, fmt.Errorf("glossaries are not available")
+		}
+		glossaries, err := h.glossaries.GetMany(req.GlossaryIDs)
+		if err != nil {
+			return nil, err
+		}
+		opts = transcription.ApplyGlossaries(opts, glossaries)
+	}
+
+	result, err := h.whisper.Transcribe(h.ctx, req.FilePath, opts)
 	if err != nil {
 		h.consoleLog(fmt.Sprintf("[Transcriber] Error: %s", err.Error()))
 		return nil, err
@@ -94,6 +154,229 @@ func (h *TranscriberHandler) TranscribeFile(req TranscribeRequest) (*whisper.Tra
 	return result, nil
 }
 
+// QueueTranscription adds a transcription to the persistent queue and returns
+// immediately. Progress arrives on whisper:transcribe-progress with the job ID.
+func (h *TranscriberHandler) QueueTranscription(req TranscribeRequest) (*storage.Transcription, error) {
+	if h.queue == nil {
+		return nil, fmt.Errorf("transcription queue is not available")
+	}
+	record, err := h.queue.AddJob(req.FilePath, whisper.TranscribeOptions{
+		Model:        req.Model,
+		Language:     req.Language,
+		OutputFormat: req.OutputFormat,
+		UseVAD:       req.UseVAD,
+		Task:         req.Task,
+		Speakers:     req.Speakers,
+		Prompt:       req.Prompt,
+	}, req.GlossaryIDs...)
+	if err != nil {
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Transcriber] Queued: %s (model: %s)", filepath.Base(req.FilePath), req.Model))
+	return record, nil
+}
+
+// SelectTranscriptionFolder opens a dialog to pick a folder to transcribe or
+// watch.
+func (h *TranscriberHandler) SelectTranscriptionFolder() (string, error) {
+	return application.Get().Dialog.OpenFile().
+		SetTitle("Select Folder").
+		CanChooseDirectories(true).
+		CanChooseFiles(false).
+		PromptForSingleSelection()
+}
+
+// TranscribeFolder queues every media file of a folder. Files transcribed
+// before are skipped unless Reprocess is set.
+func (h *TranscriberHandler) TranscribeFolder(req TranscribeFolderRequest) (*transcription.BatchResult, error) {
+	if h.queue == nil {
+		return nil, fmt.Errorf("transcription queue is not available")
+	}
+	result, err := h.queue.TranscribeFolder(req.Dir, transcription.BatchOptions{
+		Options: whisper.TranscribeOptions{
+			Model:    req.Model,
+			Language: req.Language,
+			UseVAD:   req.UseVAD,
+			Task:     req.Task,
+			Speakers: req.Speakers,
+			Prompt:   req.Prompt,
+		},
+		Formats:     req.Formats,
+		OutputDir:   req.OutputDir,
+		Recursive:   req.Recursive,
+		Reprocess:   req.Reprocess,
+		GlossaryIDs: req.GlossaryIDs,
+	})
+	if err != nil {
+		h.consoleLog(fmt.Sprintf("[Transcriber] Folder error: %s", err.Error()))
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Transcriber] Folder queued: %s (%d files, %d skipped, %d failed)",
+		filepath.Base(req.Dir), len(result.Queued), len(result.Skipped), len(result.Failed)))
+	return result, nil
+}
+
+// ListTranscriptionWatches returns the watched folders.
+func (h *TranscriberHandler) ListTranscriptionWatches() ([]*storage.TranscriptionWatch, error) {
+	if h.queue == nil {
+		return nil, nil
+	}
+	return h.queue.ListWatches()
+}
+
+// SaveTranscriptionWatch starts watching a folder when the watch has no ID
+// and updates it otherwise. New media files are transcribed once they stop
+// growing.
+func (h *TranscriberHandler) SaveTranscriptionWatch(watch storage.TranscriptionWatch) (*storage.TranscriptionWatch, error) {
+	if h.queue == nil {
+		return nil, fmt.Errorf("transcription queue is not available")
+	}
+	var err error
+	if watch.ID == "" {
+		err = h.queue.AddWatch(&watch)
+	} else {
+		err = h.queue.UpdateWatch(&watch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Transcriber] Watching folder: %s", watch.Path))
+	return &watch, nil
+}
+
+// DeleteTranscriptionWatch stops watching a folder.
+func (h *TranscriberHandler) DeleteTranscriptionWatch(id string) error {
+	if h.queue == nil {
+		return fmt.Errorf("transcription queue is not available")
+	}
+	return h.queue.RemoveWatch(id)
+}
+
+// CancelTranscription cancels a queued or running transcription.
+func (h *TranscriberHandler) CancelTranscription(id string) error {
+	if h.queue == nil {
+		return fmt.Errorf("transcription queue is not available")
+	}
+	return h.queue.CancelJob(id)
+}
+
+// GetTranscriptionQueue returns pending and running transcriptions.
+func (h *TranscriberHandler) GetTranscriptionQueue() ([]*storage.Transcription, error) {
+	if h.queue == nil {
+		return nil, nil
+	}
+	return h.queue.GetQueue()
+}
+
+// GetTranscriptionHistory returns finished transcriptions, newest first.
+func (h *TranscriberHandler) GetTranscriptionHistory(limit int) ([]*storage.Transcription, error) {
+	if h.queue == nil {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	return h.queue.GetHistory(limit)
+}
+
+// GetTranscription reopens a stored transcription with its text and segments.
+func (h *TranscriberHandler) GetTranscription(id string) (*storage.Transcription, error) {
+	if h.queue == nil {
+		return nil, fmt.Errorf("transcription queue is not available")
+	}
+	return h.queue.Get(id)
+}
+
+// DeleteTranscription removes a finished transcription from the history.
+func (h *TranscriberHandler) DeleteTranscription(id string) error {
+	if h.queue == nil {
+		return fmt.Errorf("transcription queue is not available")
+	}
+	return h.queue.Delete(id)
+}
+
+// ClearTranscriptionHistory removes every finished transcription.
+func (h *TranscriberHandler) ClearTranscriptionHistory() error {
+	if h.queue == nil {
+		return nil
+	}
+	return h.queue.ClearHistory()
+}
+
+// ExportTranscription opens a save dialog and re-exports a stored transcript
+// in any whisper export format. It returns the written path, or "" if cancelled.
+func (h *TranscriberHandler) ExportTranscription(id, format string) (string, error) {
+	if h.queue == nil {
+		return "", fmt.Errorf("transcription queue is not available")
+	}
+	record, err := h.queue.Get(id)
+	if err != nil {
+		return "", err
+	}
+	savePath, err := promptTranscriptPath(record.FilePath, format)
+	if err != nil || savePath == "" {
+		return "", err
+	}
+	if err := h.queue.Export(id, format, savePath); err != nil {
+		h.consoleLog(fmt.Sprintf("[Transcriber] Export error: %s", err.Error()))
+		return "", err
+	}
+	h.consoleLog(fmt.Sprintf("[Transcriber] Transcription exported: %s", filepath.Base(savePath)))
+	return savePath, nil
+}
+
+// ExportTranscriptRequest exports a result returned by TranscribeFile.
+type ExportTranscriptRequest struct {
+	FilePath string            `json:"filePath"` // Source media, used for the suggested file name
+	Language string            `json:"language"`
+	Format   string            `json:"format"`
+	Segments []whisper.Segment `json:"segments"`
+}
+
+// ExportTranscript opens a save dialog and writes the given segments in the
+// requested format. It returns the written path, or "" if cancelled.
+func (h *TranscriberHandler) ExportTranscript(req ExportTranscriptRequest) (string, error) {
+	if len(req.Segments) == 0 {
+		return "", fmt.Errorf("no transcription segments to export")
+	}
+	savePath, err := promptTranscriptPath(req.FilePath, req.Format)
+	if err != nil || savePath == "" {
+		return "", err
+	}
+	if err := whisper.WriteTranscript(req.Segments, req.Language, req.Format, savePath); err != nil {
+		h.consoleLog(fmt.Sprintf("[Transcriber] Export error: %s", err.Error()))
+		return "", err
+	}
+	h.consoleLog(fmt.Sprintf("[Transcriber] Transcription exported: %s", filepath.Base(savePath)))
+	return savePath, nil
+}
+
+// promptTranscriptPath asks where to save a transcript, suggesting the media
+// name with the extension of the chosen format.
+func promptTranscriptPath(mediaPath, format string) (string, error) {
+	extension, ok := whisper.ExportExtension(format)
+	if !ok {
+		return "", fmt.Errorf("unsupported transcription output format: %s", format)
+	}
+	base := "transcription"
+	if mediaPath != "" {
+		base = strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath))
+	}
+	savePath, err := application.Get().Dialog.SaveFile().
+		SetMessage("Export Transcription").
+		SetFilename(base+extension).
+		AddFilter(strings.ToUpper(strings.TrimPrefix(extension, ".")), "*"+extension).
+		AddFilter("All Files", "*.*").
+		PromptForSingleSelection()
+	if err != nil || savePath == "" {
+		return "", err
+	}
+	if !strings.HasSuffix(strings.ToLower(savePath), extension) {
+		savePath += extension
+	}
+	return savePath, nil
+}
+
 // ListWhisperModels lists downloaded whisper models.
 func (h *TranscriberHandler) ListWhisperModels() ([]whisper.ModelInfo, error) {
 	return h.whisper.ListModels()
@@ -116,6 +399,33 @@ func (h *TranscriberHandler) DownloadWhisperModel(name string) error {
 	return nil
 }
 
+// ImportWhisperModel imports a ggml or gguf model supplied by the user. An
+// empty path opens a file dialog; an empty name is derived from the file name.
+func (h *TranscriberHandler) ImportWhisperModel(path, name string) (*whisper.ModelInfo, error) {
+	if path == "" {
+		selected, err := application.Get().Dialog.OpenFile().
+			SetTitle("Select Whisper Model").
+			AddFilter("Whisper Models", "*.bin;*.gguf").
+			AddFilter("All Files", "*.*").
+			PromptForSingleSelection()
+		if err != nil {
+			return nil, err
+		}
+		if selected == "" {
+			return nil, nil
+		}
+		path = selected
+	}
+	h.consoleLog(fmt.Sprintf("[Transcriber] Importing model: %s", filepath.Base(path)))
+	model, err := h.whisper.ImportModel(path, name)
+	if err != nil {
+		h.consoleLog(fmt.Sprintf("[Transcriber] Import error: %s", err.Error()))
+		return nil, err
+	}
+	h.consoleLog(fmt.Sprintf("[Transcriber] Model imported: %s", model.Name))
+	return model, nil
+}
+
 // DeleteWhisperModel deletes a whisper model.
 func (h *TranscriberHandler) DeleteWhisperModel(name string) error {
 	h.consoleLog(fmt.Sprintf("[Transcriber] Deleting model: %s", name))
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	apperr "kingo/internal/errors"
@@ -45,6 +46,78 @@ func (h *VideoHandler) GetVideoSubtitles(url, language string) (*youtube.Subtitl
 	return result, nil
 }
 
+// ExportSubtitles saves the edited cues as an SRT/VTT/ASS/JSON sidecar with
+// the timeline cuts applied, returning the written path.
+func (h *VideoHandler) ExportSubtitles(opts youtube.SubtitleExportOptions) (string, error) {
+	const op = "VideoHandler.ExportSubtitles"
+	path, err := youtube.ExportSubtitles(opts)
+	if err != nil {
+		h.consoleLog(fmt.Sprintf("[Legendas] Falha ao exportar: %s", err.Error()))
+		return "", apperr.Wrap(op, err)
+	}
+	h.consoleLog(fmt.Sprintf("[Legendas] Legenda exportada: %s", filepath.Base(path)))
+	return path, nil
+}
+
+// ImportSubtitleFile loads a local SRT/VTT/ASS/JSON file into the editor.
+func (h *VideoHandler) ImportSubtitleFile(path string) (*youtube.SubtitleResult, error) {
+	const op = "VideoHandler.ImportSubtitleFile"
+	result, err := youtube.ImportSubtitleFile(path)
+	if err != nil {
+		h.consoleLog(fmt.Sprintf("[Legendas] Não foi possível importar %s.", filepath.Base(path)))
+		return nil, apperr.Wrap(op, err)
+	}
+	h.consoleLog(fmt.Sprintf("[Legendas] %d trechos importados de %s.", len(result.Cues), filepath.Base(path)))
+	return result, nil
+}
+
+// ShiftSubtitles moves cues starting in [from, to] by offset seconds; to <= 0
+// means until the end of the track.
+func (h *VideoHandler) ShiftSubtitles(cues []youtube.SubtitleCue, offset, from, to float64) (*youtube.SubtitleEditResult, error) {
+	return youtube.NewSubtitleEditResult(youtube.ShiftSubtitleCues(cues, offset, from, to), 0), nil
+}
+
+// StretchSubtitles re-times all cues linearly between two sync points.
+func (h *VideoHandler) StretchSubtitles(cues []youtube.SubtitleCue, first, second youtube.SubtitleSyncPoint) (*youtube.SubtitleEditResult, error) {
+	const op = "VideoHandler.StretchSubtitles"
+	stretched, err := youtube.StretchSubtitleCues(cues, first, second)
+	if err != nil {
+		return nil, apperr.Wrap(op, err)
+	}
+	return youtube.NewSubtitleEditResult(stretched, 0), nil
+}
+
+// SplitSubtitle splits one cue at the given time.
+func (h *VideoHandler) SplitSubtitle(cues []youtube.SubtitleCue, index int, at float64) (*youtube.SubtitleEditResult, error) {
+	const op = "VideoHandler.SplitSubtitle"
+	split, err := youtube.SplitSubtitleCue(cues, index, at)
+	if err != nil {
+		return nil, apperr.Wrap(op, err)
+	}
+	return youtube.NewSubtitleEditResult(split, 0), nil
+}
+
+// MergeSubtitles joins cues first..last into a single cue.
+func (h *VideoHandler) MergeSubtitles(cues []youtube.SubtitleCue, first, last int) (*youtube.SubtitleEditResult, error) {
+	const op = "VideoHandler.MergeSubtitles"
+	merged, err := youtube.MergeSubtitleCues(cues, first, last)
+	if err != nil {
+		return nil, apperr.Wrap(op, err)
+	}
+	return youtube.NewSubtitleEditResult(merged, 0), nil
+}
+
+// WrapSubtitles re-flows cue text to the line limits and reports cues that
+// exceed the reading-speed limit.
+func (h *VideoHandler) WrapSubtitles(cues []youtube.SubtitleCue, options youtube.SubtitleWrapOptions) (*youtube.SubtitleEditResult, error) {
+	wrapped := youtube.WrapSubtitleCues(cues, options)
+	result := youtube.NewSubtitleEditResult(wrapped, options.MaxCPS)
+	if len(result.Warnings) > 0 {
+		h.consoleLog(fmt.Sprintf("[Legendas] %d trechos acima da velocidade de leitura recomendada.", len(result.Warnings)))
+	}
+	return result, nil
+}
+
 // DownloadManagerInterface defines what VideoHandler needs from a download manager.
 type DownloadManagerInterface interface {
 	AddJob(opts youtube.DownloadOptions) (*storage.Download, error)
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package mediainfo
+
+import "os/exec"
+
+func setSysProcAttr(cmd *exec.Cmd) {
+	// No special attributes needed for Unix-like systems (Linux/macOS)
+}
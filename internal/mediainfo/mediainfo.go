@@ -0,0 +1,347 @@
+// Package mediainfo inspects media files with ffprobe: container, streams,
+// chapters, tags and cover art.
+package mediainfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Stream types as reported by ffprobe
+const (
+	StreamVideo      = "video"
+	StreamAudio      = "audio"
+	StreamSubtitle   = "subtitle"
+	StreamData       = "data"
+	StreamAttachment = "attachment"
+)
+
+// Info describes a media file.
+type Info struct {
+	Path          string            `json:"path"`
+	Container     string            `json:"container"`     // ffprobe format name, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	ContainerName string            `json:"containerName"` // e.g. "QuickTime / MOV"
+	Duration      float64           `json:"duration"`      // Seconds; 0 when unknown (e.g. still images)
+	Size          int64             `json:"size"`          // Bytes
+	Bitrate       int64             `json:"bitrate"`       // Overall bits per second
+	Streams       []Stream          `json:"streams"`
+	Chapters      []Chapter         `json:"chapters"`
+	Tags          map[string]string `json:"tags"` // Container tags with lower-case keys
+}
+
+// Stream is one stream of a media file. Video fields are zero for audio
+// streams and the other way around.
+type Stream struct {
+	Index       int    `json:"index"`
+	Type        string `json:"type"` // video, audio, subtitle, data, attachment
+	Codec       string `json:"codec"`
+	CodecName   string `json:"codecName"` // Long codec name
+	Profile     string `json:"profile"`
+	Bitrate     int64  `json:"bitrate"` // Bits per second; 0 when the container does not say
+	Language    string `json:"language"`
+	Title       string `json:"title"`
+	Default     bool   `json:"default"`
+	AttachedPic bool   `json:"attachedPic"` // Cover art stored as a video stream
+
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	FPS            float64 `json:"fps"`
+	PixelFormat    string  `json:"pixelFormat"`
+	BitDepth       int     `json:"bitDepth"`
+	ColorSpace     string  `json:"colorSpace"`
+	ColorTransfer  string  `json:"colorTransfer"`
+	ColorPrimaries string  `json:"colorPrimaries"`
+	ColorRange     string  `json:"colorRange"`
+	HDR            string  `json:"hdr"` // HDR10, HLG, Dolby Vision, or empty for SDR
+
+	Channels      int    `json:"channels"`
+	ChannelLayout string `json:"channelLayout"`
+	SampleRate    int    `json:"sampleRate"`
+}
+
+// Chapter is a named range of the timeline.
+type Chapter struct {
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`
+	Title string  `json:"title"`
+}
+
+// Video returns the first real video stream, skipping cover art.
+func (i *Info) Video() *Stream {
+	for index := range i.Streams {
+		if stream := &i.Streams[index]; stream.Type == StreamVideo && !stream.AttachedPic {
+			return stream
+		}
+	}
+	return nil
+}
+
+// Audio returns the default audio stream, or the first one.
+func (i *Info) Audio() *Stream {
+	var first *Stream
+	for index := range i.Streams {
+		stream := &i.Streams[index]
+		if stream.Type != StreamAudio {
+			continue
+		}
+		if stream.Default {
+			return stream
+		}
+		if first == nil {
+			first = stream
+		}
+	}
+	return first
+}
+
+// HasVideo reports whether the file has a video stream other than cover art.
+func (i *Info) HasVideo() bool { return i.Video() != nil }
+
+// HasAudio reports whether the file has an audio stream.
+func (i *Info) HasAudio() bool { return i.Audio() != nil }
+
+// AttachedPictures returns the cover art streams.
+func (i *Info) AttachedPictures() []Stream {
+	var pictures []Stream
+	for _, stream := range i.Streams {
+		if stream.AttachedPic {
+			pictures = append(pictures, stream)
+		}
+	}
+	return pictures
+}
+
+// Inspect runs ffprobe on path.
+func Inspect(ctx context.Context, ffprobePath, path string) (*Info, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("media file not found: %s", path)
+	}
+	cmd := exec.CommandContext(ctx, ffprobePath,
+		"-v", "error", "-print_format", "json", "-show_format", "-show_streams", "-show_chapters", path)
+	setSysProcAttr(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("ffprobe error: %v | output: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("ffprobe error: %w", err)
+	}
+	info, err := Parse(output)
+	if err != nil {
+		return nil, err
+	}
+	info.Path = path
+	return info, nil
+}
+
+// FFprobePath finds ffprobe next to the FFmpeg binary, falling back to PATH.
+func FFprobePath(ffmpegPath string) string {
+	name := "ffprobe"
+	if strings.EqualFold(filepath.Ext(ffmpegPath), ".exe") {
+		name += ".exe"
+	}
+	path := filepath.Join(filepath.Dir(ffmpegPath), name)
+	if _, err := os.Stat(path); err != nil {
+		return name
+	}
+	return path
+}
+
+// probeOutput mirrors the parts of `ffprobe -print_format json` we use.
+// Numbers arrive as strings.
+type probeOutput struct {
+	Format struct {
+		FormatName     string            `json:"format_name"`
+		FormatLongName string            `json:"format_long_name"`
+		Duration       string            `json:"duration"`
+		Size           string            `json:"size"`
+		BitRate        string            `json:"bit_rate"`
+		Tags           map[string]string `json:"tags"`
+	} `json:"format"`
+	Streams []struct {
+		Index            int               `json:"index"`
+		CodecType        string            `json:"codec_type"`
+		CodecName        string            `json:"codec_name"`
+		CodecLongName    string            `json:"codec_long_name"`
+		Profile          string            `json:"profile"`
+		BitRate          string            `json:"bit_rate"`
+		Width            int               `json:"width"`
+		Height           int               `json:"height"`
+		AvgFrameRate     string            `json:"avg_frame_rate"`
+		RFrameRate       string            `json:"r_frame_rate"`
+		PixFmt           string            `json:"pix_fmt"`
+		BitsPerRawSample string            `json:"bits_per_raw_sample"`
+		ColorSpace       string            `json:"color_space"`
+		ColorTransfer    string            `json:"color_transfer"`
+		ColorPrimaries   string            `json:"color_primaries"`
+		ColorRange       string            `json:"color_range"`
+		Channels         int               `json:"channels"`
+		ChannelLayout    string            `json:"channel_layout"`
+		SampleRate       string            `json:"sample_rate"`
+		Disposition      map[string]int    `json:"disposition"`
+		Tags             map[string]string `json:"tags"`
+		SideDataList     []struct {
+			SideDataType string `json:"side_data_type"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Chapters []struct {
+		StartTime string            `json:"start_time"`
+		EndTime   string            `json:"end_time"`
+		Tags      map[string]string `json:"tags"`
+	} `json:"chapters"`
+}
+
+// Parse decodes ffprobe JSON output.
+func Parse(data []byte) (*Info, error) {
+	var probe probeOutput
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+
+	info := &Info{
+		Container:     probe.Format.FormatName,
+		ContainerName: probe.Format.FormatLongName,
+		Duration:      parseFloat(probe.Format.Duration),
+		Size:          parseInt(probe.Format.Size),
+		Bitrate:       parseInt(probe.Format.BitRate),
+		Streams:       make([]Stream, 0, len(probe.Streams)),
+		Chapters:      make([]Chapter, 0, len(probe.Chapters)),
+		Tags:          lowerKeys(probe.Format.Tags),
+	}
+	for _, s := range probe.Streams {
+		tags := lowerKeys(s.Tags)
+		stream := Stream{
+			Index:       s.Index,
+			Type:        s.CodecType,
+			Codec:       s.CodecName,
+			CodecName:   s.CodecLongName,
+			Profile:     s.Profile,
+			Bitrate:     parseInt(s.BitRate),
+			Language:    tags["language"],
+			Title:       tags["title"],
+			Default:     s.Disposition["default"] == 1,
+			AttachedPic: s.Disposition["attached_pic"] == 1,
+		}
+		if stream.Bitrate == 0 {
+			// Matroska keeps per-stream bitrates in tags only.
+			stream.Bitrate = parseInt(tags["bps"])
+			if stream.Bitrate == 0 {
+				stream.Bitrate = parseInt(tags["bps-eng"])
+			}
+		}
+		switch s.CodecType {
+		case StreamVideo:
+			stream.Width = s.Width
+			stream.Height = s.Height
+			stream.FPS = parseRate(s.AvgFrameRate)
+			if stream.FPS == 0 {
+				stream.FPS = parseRate(s.RFrameRate)
+			}
+			stream.PixelFormat = s.PixFmt
+			stream.BitDepth = bitDepth(s.BitsPerRawSample, s.PixFmt)
+			stream.ColorSpace = s.ColorSpace
+			stream.ColorTransfer = s.ColorTransfer
+			stream.ColorPrimaries = s.ColorPrimaries
+			stream.ColorRange = s.ColorRange
+			for _, side := range s.SideDataList {
+				if strings.Contains(side.SideDataType, "DOVI") {
+					stream.HDR = "Dolby Vision"
+				}
+			}
+			if stream.HDR == "" {
+				stream.HDR = hdrFormat(s.ColorTransfer)
+			}
+		case StreamAudio:
+			stream.Channels = s.Channels
+			stream.ChannelLayout = s.ChannelLayout
+			stream.SampleRate = int(parseInt(s.SampleRate))
+		}
+		info.Streams = append(info.Streams, stream)
+	}
+	for _, c := range probe.Chapters {
+		info.Chapters = append(info.Chapters, Chapter{
+			Start: parseFloat(c.StartTime),
+			End:   parseFloat(c.EndTime),
+			Title: lowerKeys(c.Tags)["title"],
+		})
+	}
+	return info, nil
+}
+
+// hdrFormat names the HDR format signalled by a transfer characteristic.
+func hdrFormat(transfer string) string {
+	switch transfer {
+	case "smpte2084":
+		return "HDR10"
+	case "arib-std-b67":
+		return "HLG"
+	default:
+		return ""
+	}
+}
+
+// bitDepth prefers the coded sample size and falls back to the pixel format
+// name, e.g. yuv420p10le.
+func bitDepth(bitsPerRawSample, pixFmt string) int {
+	if bits := int(parseInt(bitsPerRawSample)); bits > 0 {
+		return bits
+	}
+	if pixFmt == "" {
+		return 0
+	}
+	for _, depth := range []int{16, 14, 12, 10, 9} {
+		if strings.Contains(pixFmt, "p"+strconv.Itoa(depth)) {
+			return depth
+		}
+	}
+	return 8
+}
+
+// parseRate parses an ffprobe rational such as "30000/1001".
+func parseRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		return parseFloat(rate)
+	}
+	n, d := parseFloat(num), parseFloat(den)
+	if d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+func parseFloat(value string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || f < 0 {
+		return 0
+	}
+	return f
+}
+
+func parseInt(value string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// lowerKeys normalizes tag names, which vary in case between containers.
+func lowerKeys(tags map[string]string) map[string]string {
+	lowered := make(map[string]string, len(tags))
+	for key, value := range tags {
+		lowered[strings.ToLower(key)] = value
+	}
+	return lowered
+}
@@ -0,0 +1,74 @@
+package mediainfo
+
+import "testing"
+
+const sampleProbe = `{
+  "streams": [
+    {"index": 0, "codec_type": "video", "codec_name": "hevc", "profile": "Main 10", "width": 3840, "height": 2160,
+     "avg_frame_rate": "30000/1001", "r_frame_rate": "30/1", "pix_fmt": "yuv420p10le",
+     "color_space": "bt2020nc", "color_transfer": "smpte2084", "color_primaries": "bt2020", "color_range": "tv",
+     "disposition": {"default": 1, "attached_pic": 0}, "tags": {"BPS": "25000000"}},
+    {"index": 1, "codec_type": "audio", "codec_name": "aac", "channels": 2, "channel_layout": "stereo",
+     "sample_rate": "48000", "bit_rate": "128000", "disposition": {"default": 0}, "tags": {"language": "eng"}},
+    {"index": 2, "codec_type": "audio", "codec_name": "eac3", "channels": 6, "channel_layout": "5.1(side)",
+     "sample_rate": "48000", "disposition": {"default": 1}, "tags": {"LANGUAGE": "por", "title": "Dublado"}},
+    {"index": 3, "codec_type": "video", "codec_name": "mjpeg", "width": 600, "height": 600,
+     "avg_frame_rate": "0/0", "r_frame_rate": "90000/1", "pix_fmt": "yuvj420p", "disposition": {"attached_pic": 1}}
+  ],
+  "chapters": [
+    {"start_time": "0.000000", "end_time": "61.500000", "tags": {"title": "Intro"}},
+    {"start_time": "61.500000", "end_time": "300.000000", "tags": {"TITLE": "Main"}}
+  ],
+  "format": {"format_name": "matroska,webm", "format_long_name": "Matroska / WebM", "duration": "300.000000",
+    "size": "1048576000", "bit_rate": "27962026", "tags": {"TITLE": "Sample", "encoder": "libebml"}}
+}`
+
+func TestParse(t *testing.T) {
+	info, err := Parse([]byte(sampleProbe))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if info.Container != "matroska,webm" || info.Duration != 300 || info.Size != 1048576000 || info.Tags["title"] != "Sample" {
+		t.Fatalf("unexpected format: %#v", info)
+	}
+
+	video := info.Video()
+	if video == nil || video.Codec != "hevc" || video.Width != 3840 || video.BitDepth != 10 || video.HDR != "HDR10" {
+		t.Fatalf("unexpected video stream: %#v", video)
+	}
+	if video.FPS < 29.97 || video.FPS > 29.98 || video.Bitrate != 25000000 {
+		t.Fatalf("fps/bitrate = %v/%d", video.FPS, video.Bitrate)
+	}
+
+	audio := info.Audio()
+	if audio == nil || audio.Index != 2 || audio.Language != "por" || audio.Title != "Dublado" || audio.Channels != 6 {
+		t.Fatalf("default audio stream not chosen: %#v", audio)
+	}
+
+	pictures := info.AttachedPictures()
+	if len(pictures) != 1 || pictures[0].Index != 3 || pictures[0].BitDepth != 8 {
+		t.Fatalf("unexpected attached pictures: %#v", pictures)
+	}
+	if len(info.Chapters) != 2 || info.Chapters[1].Title != "Main" || info.Chapters[1].Start != 61.5 {
+		t.Fatalf("unexpected chapters: %#v", info.Chapters)
+	}
+}
+
+func TestCoverArtIsNotVideo(t *testing.T) {
+	info, err := Parse([]byte(`{"streams": [
+		{"index": 0, "codec_type": "audio", "codec_name": "mp3", "disposition": {"default": 0}},
+		{"index": 1, "codec_type": "video", "codec_name": "png", "disposition": {"attached_pic": 1}}
+	], "format": {"format_name": "mp3", "duration": "180.5"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.HasVideo() || !info.HasAudio() || info.Audio().Index != 0 {
+		t.Fatalf("an mp3 with cover art is audio only: %#v", info.Streams)
+	}
+}
+
+func TestParseRejectsInvalidJSON(t *testing.T) {
+	if _, err := Parse([]byte("Invalid data found when processing input")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
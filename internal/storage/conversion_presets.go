@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConversionPreset is a named set of conversion options created by the user.
+// Options holds a JSON conversion.Options.
+type ConversionPreset struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Kind        string          `json:"kind"` // video, audio or image
+	Description string          `json:"description"`
+	Options     json.RawMessage `json:"options"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+const conversionPresetColumns = `id, name, kind, COALESCE(description,''), COALESCE(options,'{}'), created_at, updated_at`
+
+// ConversionPresetRepository handles conversion preset CRUD
+type ConversionPresetRepository struct {
+	db *DB
+}
+
+// NewConversionPresetRepository creates a new conversion preset repository
+func NewConversionPresetRepository(db *DB) *ConversionPresetRepository {
+	return &ConversionPresetRepository{db: db}
+}
+
+// Create inserts a new preset. Names are unique, ignoring case.
+func (r *ConversionPresetRepository) Create(p *ConversionPreset) error {
+	if err := normalizeConversionPreset(p); err != nil {
+		return err
+	}
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	_, err := r.db.conn.Exec(`INSERT INTO conversion_presets (id, name, kind, description, options, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, p.ID, p.Name, p.Kind, p.Description, string(p.Options), p.CreatedAt, p.UpdatedAt)
+	return err
+}
+
+// Update replaces the name, kind, description and options of a preset
+func (r *ConversionPresetRepository) Update(p *ConversionPreset) error {
+	if err := normalizeConversionPreset(p); err != nil {
+		return err
+	}
+	p.UpdatedAt = time.Now()
+	result, err := r.db.conn.Exec(`UPDATE conversion_presets SET name = ?, kind = ?, description = ?, options = ?, updated_at = ?
+		WHERE id = ?`, p.Name, p.Kind, p.Description, string(p.Options), p.UpdatedAt, p.ID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("conversion preset not found: %s", p.ID)
+	}
+	return nil
+}
+
+// GetByID retrieves a preset, or nil when it does not exist
+func (r *ConversionPresetRepository) GetByID(id string) (*ConversionPreset, error) {
+	presets, err := r.query(`SELECT `+conversionPresetColumns+` FROM conversion_presets WHERE id = ?`, id)
+	if err != nil || len(presets) == 0 {
+		return nil, err
+	}
+	return presets[0], nil
+}
+
+// GetByName retrieves a preset ignoring case, or nil when it does not exist
+func (r *ConversionPresetRepository) GetByName(name string) (*ConversionPreset, error) {
+	presets, err := r.query(`SELECT `+conversionPresetColumns+` FROM conversion_presets WHERE name = ?`, strings.TrimSpace(name))
+	if err != nil || len(presets) == 0 {
+		return nil, err
+	}
+	return presets[0], nil
+}
+
+// List returns every preset ordered by name
+func (r *ConversionPresetRepository) List() ([]*ConversionPreset, error) {
+	return r.query(`SELECT ` + conversionPresetColumns + ` FROM conversion_presets ORDER BY name`)
+}
+
+// Delete removes a preset
+func (r *ConversionPresetRepository) Delete(id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM conversion_presets WHERE id = ?", id)
+	return err
+}
+
+func (r *ConversionPresetRepository) query(query string, args ...interface{}) ([]*ConversionPreset, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presets := []*ConversionPreset{}
+	for rows.Next() {
+		p := &ConversionPreset{}
+		var options string
+		if err := rows.Scan(&p.ID, &p.Name, &p.Kind, &p.Description, &options, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Options = json.RawMessage(options)
+		presets = append(presets, p)
+	}
+	return presets, rows.Err()
+}
+
+func normalizeConversionPreset(p *ConversionPreset) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("conversion preset name is required")
+	}
+	if len(p.Options) == 0 {
+		p.Options = json.RawMessage("{}")
+	}
+	if !json.Valid(p.Options) {
+		return fmt.Errorf("conversion preset options are not valid JSON")
+	}
+	return nil
+}
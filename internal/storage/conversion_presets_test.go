@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConversionPresetRepository_CRUD(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewConversionPresetRepository(db)
+
+	preset := &ConversionPreset{Name: "  Reels 1080p ", Kind: "video", Options: json.RawMessage(`{"format":"mp4","resolution":"1080x1920"}`)}
+	if err := repo.Create(preset); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if preset.ID == "" || preset.Name != "Reels 1080p" {
+		t.Fatalf("unexpected created preset: %#v", preset)
+	}
+	if err := repo.Create(&ConversionPreset{Name: "reels 1080P", Kind: "video"}); err == nil {
+		t.Fatal("expected duplicate name to be rejected ignoring case")
+	}
+
+	got, err := repo.GetByName("REELS 1080p")
+	if err != nil || got == nil || got.ID != preset.ID || string(got.Options) != string(preset.Options) {
+		t.Fatalf("GetByName() = %#v, %v", got, err)
+	}
+
+	preset.Description = "Vertical"
+	preset.Options = json.RawMessage(`{"format":"mp4","resolution":"720x1280"}`)
+	if err := repo.Update(preset); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	got, _ = repo.GetByID(preset.ID)
+	if got.Description != "Vertical" || string(got.Options) != `{"format":"mp4","resolution":"720x1280"}` {
+		t.Fatalf("update not stored: %#v", got)
+	}
+
+	if err := repo.Update(&ConversionPreset{ID: "missing", Name: "x", Kind: "audio"}); err == nil {
+		t.Fatal("expected update of unknown preset to fail")
+	}
+	if err := repo.Create(&ConversionPreset{Name: "bad", Kind: "audio", Options: json.RawMessage(`{`)}); err == nil {
+		t.Fatal("expected invalid options to be rejected")
+	}
+
+	if err := repo.Delete(preset.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if presets, _ := repo.List(); len(presets) != 0 {
+		t.Fatalf("preset not deleted: %#v", presets)
+	}
+}
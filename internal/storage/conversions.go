@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConversionStatus represents the state of a queued conversion
+type ConversionStatus string
+
+const (
+	ConversionPending    ConversionStatus = "pending"
+	ConversionProcessing ConversionStatus = "processing"
+	ConversionCompleted  ConversionStatus = "completed"
+	ConversionFailed     ConversionStatus = "failed"
+	ConversionCancelled  ConversionStatus = "cancelled"
+)
+
+// Conversion is one file of a conversion batch. Every file of a batch shares
+// the same kind and options.
+type Conversion struct {
+	ID           string           `json:"id"`
+	BatchID      string           `json:"batchId"`
+	Kind         string           `json:"kind"` // video, audio or image
+	InputPath    string           `json:"inputPath"`
+	Options      json.RawMessage  `json:"options"`
+	Status       ConversionStatus `json:"status"`
+	OutputPath   string           `json:"outputPath"`
+	InputSize    int64            `json:"inputSize"`
+	OutputSize   int64            `json:"outputSize"`
+	ErrorMessage string           `json:"errorMessage"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	StartedAt    *time.Time       `json:"startedAt"`
+	CompletedAt  *time.Time       `json:"completedAt"`
+}
+
+const conversionColumns = `id, batch_id, kind, input_path, COALESCE(options,'{}'), status, COALESCE(output_path,''),
+	input_size, output_size, COALESCE(error_message,''), created_at, started_at, completed_at`
+
+// ConversionRepository handles conversion queue operations
+type ConversionRepository struct {
+	db *DB
+}
+
+// NewConversionRepository creates a new conversion repository
+func NewConversionRepository(db *DB) *ConversionRepository {
+	return &ConversionRepository{db: db}
+}
+
+// Create inserts a new conversion
+func (r *ConversionRepository) Create(c *Conversion) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	if c.Status == "" {
+		c.Status = ConversionPending
+	}
+	c.CreatedAt = time.Now()
+	var options interface{}
+	if len(c.Options) > 0 {
+		options = string(c.Options)
+	}
+	_, err := r.db.conn.Exec(`INSERT INTO conversions (id, batch_id, kind, input_path, options, status,
+		input_size, error_message, created_at, completed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.ID, c.BatchID, c.Kind, c.InputPath, options, c.Status, c.InputSize, c.ErrorMessage, c.CreatedAt, c.CompletedAt)
+	return err
+}
+
+// Update persists status, result and timing fields
+func (r *ConversionRepository) Update(c *Conversion) error {
+	_, err := r.db.conn.Exec(`UPDATE conversions SET status = ?, output_path = ?, input_size = ?, output_size = ?,
+		error_message = ?, started_at = ?, completed_at = ? WHERE id = ?`,
+		c.Status, c.OutputPath, c.InputSize, c.OutputSize, c.ErrorMessage, c.StartedAt, c.CompletedAt, c.ID)
+	return err
+}
+
+// GetByID retrieves a conversion, or nil when it does not exist
+func (r *ConversionRepository) GetByID(id string) (*Conversion, error) {
+	conversions, err := r.query(`SELECT `+conversionColumns+` FROM conversions WHERE id = ?`, id)
+	if err != nil || len(conversions) == 0 {
+		return nil, err
+	}
+	return conversions[0], nil
+}
+
+// GetPending retrieves pending conversions ordered by creation time
+func (r *ConversionRepository) GetPending() ([]*Conversion, error) {
+	return r.query(`SELECT `+conversionColumns+` FROM conversions WHERE status = ? ORDER BY created_at ASC`, ConversionPending)
+}
+
+// RequeueInterrupted moves conversions left in "processing" by a previous
+// session back to "pending" so they are restored on startup.
+func (r *ConversionRepository) RequeueInterrupted() error {
+	_, err := r.db.conn.Exec(`UPDATE conversions SET status = ?, started_at = NULL WHERE status = ?`,
+		ConversionPending, ConversionProcessing)
+	return err
+}
+
+// GetQueue retrieves all unfinished conversions (pending + processing)
+func (r *ConversionRepository) GetQueue() ([]*Conversion, error) {
+	return r.query(`SELECT ` + conversionColumns + ` FROM conversions
+		WHERE status NOT IN ('completed', 'failed', 'cancelled') ORDER BY created_at ASC`)
+}
+
+// GetHistory retrieves finished conversions, newest first
+func (r *ConversionRepository) GetHistory(limit int) ([]*Conversion, error) {
+	return r.query(`SELECT `+conversionColumns+` FROM conversions
+		WHERE status IN ('completed', 'failed', 'cancelled') ORDER BY completed_at DESC LIMIT ?`, limit)
+}
+
+// GetBatch retrieves every conversion of a batch in the order it was queued
+func (r *ConversionRepository) GetBatch(batchID string) ([]*Conversion, error) {
+	return r.query(`SELECT `+conversionColumns+` FROM conversions WHERE batch_id = ? ORDER BY created_at ASC, rowid ASC`, batchID)
+}
+
+// ClearHistory removes all finished conversions
+func (r *ConversionRepository) ClearHistory() error {
+	_, err := r.db.conn.Exec("DELETE FROM conversions WHERE status IN ('completed', 'failed', 'cancelled')")
+	return err
+}
+
+func (r *ConversionRepository) query(query string, args ...interface{}) ([]*Conversion, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversions := []*Conversion{}
+	for rows.Next() {
+		c := &Conversion{}
+		var options string
+		if err := rows.Scan(&c.ID, &c.BatchID, &c.Kind, &c.InputPath, &options, &c.Status, &c.OutputPath,
+			&c.InputSize, &c.OutputSize, &c.ErrorMessage, &c.CreatedAt, &c.StartedAt, &c.CompletedAt); err != nil {
+			return nil, err
+		}
+		c.Options = json.RawMessage(options)
+		conversions = append(conversions, c)
+	}
+	return conversions, rows.Err()
+}
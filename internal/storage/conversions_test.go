@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestConversionRepository_BatchLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewConversionRepository(db)
+
+	options := json.RawMessage(`{"format":"webp","imageQuality":80}`)
+	first := &Conversion{BatchID: "batch-1", Kind: "image", InputPath: "/photos/a.png", Options: options, InputSize: 2000}
+	now := time.Now()
+	missing := &Conversion{BatchID: "batch-1", Kind: "image", InputPath: "/photos/b.png", Options: options,
+		Status: ConversionFailed, ErrorMessage: "file not found", CompletedAt: &now}
+	for _, record := range []*Conversion{first, missing} {
+		if err := repo.Create(record); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+	}
+	if first.ID == "" || first.Status != ConversionPending {
+		t.Fatalf("unexpected created record: %#v", first)
+	}
+
+	queue, err := repo.GetQueue()
+	if err != nil || len(queue) != 1 || queue[0].ID != first.ID {
+		t.Fatalf("GetQueue() = %v, %v", queue, err)
+	}
+
+	first.Status = ConversionCompleted
+	first.OutputPath = "/photos/a.webp"
+	first.OutputSize = 500
+	first.CompletedAt = &now
+	if err := repo.Update(first); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	batch, err := repo.GetBatch("batch-1")
+	if err != nil {
+		t.Fatalf("GetBatch() error: %v", err)
+	}
+	if len(batch) != 2 || batch[0].ID != first.ID || batch[1].ErrorMessage != "file not found" {
+		t.Fatalf("unexpected batch: %#v", batch)
+	}
+	if batch[0].OutputSize != 500 || batch[0].InputSize != 2000 || string(batch[0].Options) != string(options) {
+		t.Fatalf("result not stored: %#v", batch[0])
+	}
+
+	if err := repo.ClearHistory(); err != nil {
+		t.Fatalf("ClearHistory() error: %v", err)
+	}
+	if history, _ := repo.GetHistory(10); len(history) != 0 {
+		t.Fatalf("history not cleared: %#v", history)
+	}
+}
+
+func TestConversionRepository_RequeueInterrupted(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewConversionRepository(db)
+
+	started := time.Now()
+	running := &Conversion{BatchID: "batch-1", Kind: "video", InputPath: "/videos/a.mp4", Status: ConversionProcessing}
+	if err := repo.Create(running); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	running.StartedAt = &started
+	if err := repo.Update(running); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if err := repo.RequeueInterrupted(); err != nil {
+		t.Fatalf("RequeueInterrupted() error: %v", err)
+	}
+	pending, err := repo.GetPending()
+	if err != nil {
+		t.Fatalf("GetPending() error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].StartedAt != nil {
+		t.Fatalf("interrupted conversion was not requeued: %#v", pending)
+	}
+}
@@ -93,6 +93,122 @@ func (db *DB) migrate() error {
 	CREATE INDEX IF NOT EXISTS idx_downloads_status ON downloads(status);
 	CREATE INDEX IF NOT EXISTS idx_downloads_created_at ON downloads(created_at DESC);
 
+	-- Transcription queue and stored transcripts
+	CREATE TABLE IF NOT EXISTS transcriptions (
+		id TEXT PRIMARY KEY,
+		file_path TEXT NOT NULL,
+		model TEXT NOT NULL,
+		language TEXT DEFAULT 'auto',
+		output_format TEXT DEFAULT 'txt',
+		use_vad BOOLEAN DEFAULT FALSE,
+		task TEXT DEFAULT 'transcribe',
+		status TEXT DEFAULT 'pending', -- pending, processing, completed, failed, cancelled
+		detected_language TEXT,
+		duration REAL DEFAULT 0,
+		text TEXT,
+		segments TEXT, -- JSON array of whisper segments
+		error_message TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		completed_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_transcriptions_status ON transcriptions(status);
+	CREATE INDEX IF NOT EXISTS idx_transcriptions_created_at ON transcriptions(created_at DESC);
+
+	-- Conversion queue: one row per file, grouped by batch
+	CREATE TABLE IF NOT EXISTS conversions (
+		id TEXT PRIMARY KEY,
+		batch_id TEXT NOT NULL,
+		kind TEXT NOT NULL, -- video, audio, image
+		input_path TEXT NOT NULL,
+		options TEXT, -- JSON conversion.Options shared by the batch
+		status TEXT DEFAULT 'pending', -- pending, processing, completed, failed, cancelled
+		output_path TEXT,
+		input_size INTEGER DEFAULT 0,
+		output_size INTEGER DEFAULT 0,
+		error_message TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		completed_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_conversions_status ON conversions(status);
+	CREATE INDEX IF NOT EXISTS idx_conversions_batch ON conversions(batch_id);
+
+	-- Conversion presets: named option sets created by the user
+	CREATE TABLE IF NOT EXISTS conversion_presets (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		kind TEXT NOT NULL, -- video, audio, image
+		description TEXT DEFAULT '',
+		options TEXT, -- JSON conversion.Options
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Watermark presets: named logo or text overlays created by the user
+	CREATE TABLE IF NOT EXISTS watermark_presets (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		settings TEXT, -- JSON converter.Watermark
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Glossaries: named word lists for the Whisper prompt and find/replace rules
+	CREATE TABLE IF NOT EXISTS glossaries (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		terms TEXT, -- JSON array of words
+		replacements TEXT, -- JSON array of find/replace rules
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS glossary_links (
+		glossary_id TEXT NOT NULL REFERENCES glossaries(id) ON DELETE CASCADE,
+		target_type TEXT NOT NULL, -- transcription, download
+		target_id TEXT NOT NULL,
+		PRIMARY KEY (glossary_id, target_type, target_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_glossary_links_target ON glossary_links(target_type, target_id);
+
+	CREATE TRIGGER IF NOT EXISTS glossary_links_transcription_delete AFTER DELETE ON transcriptions BEGIN
+		DELETE FROM glossary_links WHERE target_type = 'transcription' AND target_id = old.id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS glossary_links_download_delete AFTER DELETE ON downloads BEGIN
+		DELETE FROM glossary_links WHERE target_type = 'download' AND target_id = old.id;
+	END;
+
+	-- Watched folders: new media files are transcribed once they stop growing
+	CREATE TABLE IF NOT EXISTS transcription_watches (
+		id TEXT PRIMARY KEY,
+		path TEXT NOT NULL UNIQUE,
+		recursive BOOLEAN DEFAULT FALSE,
+		model TEXT NOT NULL,
+		language TEXT DEFAULT 'auto',
+		use_vad BOOLEAN DEFAULT FALSE,
+		task TEXT DEFAULT 'transcribe',
+		speakers TEXT DEFAULT '',
+		prompt TEXT DEFAULT '',
+		formats TEXT DEFAULT 'txt', -- comma-separated export formats
+		output_dir TEXT DEFAULT '', -- root of a mirror tree; empty writes next to each file
+		enabled BOOLEAN DEFAULT TRUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Media files already sent to the transcription queue by a batch or watcher
+	CREATE TABLE IF NOT EXISTS transcribed_files (
+		path TEXT PRIMARY KEY,
+		size INTEGER NOT NULL,
+		mod_time INTEGER NOT NULL, -- unix nanoseconds
+		transcription_id TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
 	-- Subscriptions (for future Fase 4)
 	CREATE TABLE IF NOT EXISTS subscriptions (
 		id TEXT PRIMARY KEY,
@@ -125,7 +241,50 @@ func (db *DB) migrate() error {
 	);
 	`
 
-	_, err := db.conn.Exec(schema)
+	if _, err := db.conn.Exec(schema); err != nil {
+		return err
+	}
+
+	// Columns added after the first release
+	if err := db.addColumn("downloads", "description", "TEXT"); err != nil {
+		return err
+	}
+	if err := db.addColumn("transcriptions", "speakers", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := db.addColumn("transcriptions", "prompt", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := db.addColumn("transcriptions", "export_dir", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := db.addColumn("transcriptions", "export_formats", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	return db.migrateSearch()
+}
+
+// addColumn adds a column to an existing table unless it is already there
+func (db *DB) addColumn(table, column, definition string) error {
+	rows, err := db.conn.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
@@ -154,6 +313,7 @@ type Download struct {
 	Thumbnail    string         `json:"thumbnail"`
 	Duration     int            `json:"duration"`
 	Uploader     string         `json:"uploader"`
+	Description  string         `json:"description"`
 	Format       string         `json:"format"`
 	AudioOnly    bool           `json:"audioOnly"`
 	Status       DownloadStatus `json:"status"`
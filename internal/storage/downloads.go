@@ -9,7 +9,7 @@ import (
 
 // downloadColumns is the standard SELECT column list using COALESCE to avoid sql.NullString overhead.
 // This eliminates ~8 NullString allocations per row scanned.
-const downloadColumns = `id, url, COALESCE(title,''), COALESCE(thumbnail,''), duration, COALESCE(uploader,''),
+const downloadColumns = `id, url, COALESCE(title,''), COALESCE(thumbnail,''), duration, COALESCE(uploader,''), COALESCE(description,''),
 	COALESCE(format,'best'), audio_only, status, progress, COALESCE(speed,''), COALESCE(eta,''),
 	COALESCE(file_path,''), file_size, COALESCE(error_message,''),
 	created_at, started_at, completed_at`
@@ -48,7 +48,7 @@ func (r *DownloadRepository) Create(d *Download) error {
 func (r *DownloadRepository) Update(d *Download) error {
 	query := `
 		UPDATE downloads SET
-			title = ?, thumbnail = ?, duration = ?, uploader = ?,
+			title = ?, thumbnail = ?, duration = ?, uploader = ?, description = ?,
 			status = ?, progress = ?, speed = ?, eta = ?,
 			file_path = ?, file_size = ?, error_message = ?,
 			started_at = ?, completed_at = ?
@@ -56,7 +56,7 @@ func (r *DownloadRepository) Update(d *Download) error {
 	`
 
 	_, err := r.db.conn.Exec(query,
-		d.Title, d.Thumbnail, d.Duration, d.Uploader,
+		d.Title, d.Thumbnail, d.Duration, d.Uploader, d.Description,
 		d.Status, d.Progress, d.Speed, d.ETA,
 		d.FilePath, d.FileSize, d.ErrorMessage,
 		d.StartedAt, d.CompletedAt, d.ID,
@@ -73,7 +73,7 @@ func (r *DownloadRepository) ExistsActiveByURL(url string) (*Download, error) {
 		LIMIT 1`
 	d := &Download{}
 	err := r.db.conn.QueryRow(query, url).Scan(
-		&d.ID, &d.URL, &d.Title, &d.Thumbnail, &d.Duration, &d.Uploader,
+		&d.ID, &d.URL, &d.Title, &d.Thumbnail, &d.Duration, &d.Uploader, &d.Description,
 		&d.Format, &d.AudioOnly, &d.Status, &d.Progress, &d.Speed, &d.ETA,
 		&d.FilePath, &d.FileSize, &d.ErrorMessage,
 		&d.CreatedAt, &d.StartedAt, &d.CompletedAt,
@@ -107,7 +107,7 @@ func (r *DownloadRepository) GetByID(id string) (*Download, error) {
 
 	d := &Download{}
 	err := r.db.conn.QueryRow(query, id).Scan(
-		&d.ID, &d.URL, &d.Title, &d.Thumbnail, &d.Duration, &d.Uploader,
+		&d.ID, &d.URL, &d.Title, &d.Thumbnail, &d.Duration, &d.Uploader, &d.Description,
 		&d.Format, &d.AudioOnly, &d.Status, &d.Progress, &d.Speed, &d.ETA,
 		&d.FilePath, &d.FileSize, &d.ErrorMessage,
 		&d.CreatedAt, &d.StartedAt, &d.CompletedAt,
@@ -196,7 +196,7 @@ func (r *DownloadRepository) scanDownloads(rows *sql.Rows) ([]*Download, error)
 	for rows.Next() {
 		d := &Download{}
 		err := rows.Scan(
-			&d.ID, &d.URL, &d.Title, &d.Thumbnail, &d.Duration, &d.Uploader,
+			&d.ID, &d.URL, &d.Title, &d.Thumbnail, &d.Duration, &d.Uploader, &d.Description,
 			&d.Format, &d.AudioOnly, &d.Status, &d.Progress, &d.Speed, &d.ETA,
 			&d.FilePath, &d.FileSize, &d.ErrorMessage,
 			&d.CreatedAt, &d.StartedAt, &d.CompletedAt,
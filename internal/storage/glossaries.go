@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Glossary link targets
+const (
+	GlossaryTargetTranscription = "transcription"
+	GlossaryTargetDownload      = "download"
+)
+
+// GlossaryRule is a find/replace rule applied to transcript segments.
+type GlossaryRule struct {
+	Find      string `json:"find"`
+	Replace   string `json:"replace"`
+	MatchCase bool   `json:"matchCase"`
+	WholeWord bool   `json:"wholeWord"`
+}
+
+// Glossary is a named word list. Terms are fed to Whisper as part of the
+// initial prompt; Replacements fix what Whisper still gets wrong.
+type Glossary struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	Terms        []string       `json:"terms"`
+	Replacements []GlossaryRule `json:"replacements"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+const glossaryColumns = `id, name, COALESCE(terms,'[]'), COALESCE(replacements,'[]'), created_at, updated_at`
+
+// GlossaryRepository handles glossary CRUD and attachments
+type GlossaryRepository struct {
+	db *DB
+}
+
+// NewGlossaryRepository creates a new glossary repository
+func NewGlossaryRepository(db *DB) *GlossaryRepository {
+	return &GlossaryRepository{db: db}
+}
+
+// Create inserts a new glossary. Names are unique, ignoring case.
+func (r *GlossaryRepository) Create(g *Glossary) error {
+	if err := normalizeGlossary(g); err != nil {
+		return err
+	}
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	g.CreatedAt = time.Now()
+	g.UpdatedAt = g.CreatedAt
+	terms, replacements, err := encodeGlossary(g)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.conn.Exec(`INSERT INTO glossaries (id, name, terms, replacements, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, g.ID, g.Name, terms, replacements, g.CreatedAt, g.UpdatedAt)
+	return err
+}
+
+// Update replaces the name, terms and rules of a glossary
+func (r *GlossaryRepository) Update(g *Glossary) error {
+	if err := normalizeGlossary(g); err != nil {
+		return err
+	}
+	g.UpdatedAt = time.Now()
+	terms, replacements, err := encodeGlossary(g)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.conn.Exec(`UPDATE glossaries SET name = ?, terms = ?, replacements = ?, updated_at = ? WHERE id = ?`,
+		g.Name, terms, replacements, g.UpdatedAt, g.ID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("glossary not found: %s", g.ID)
+	}
+	return nil
+}
+
+// GetByID retrieves a glossary, or nil when it does not exist
+func (r *GlossaryRepository) GetByID(id string) (*Glossary, error) {
+	glossaries, err := r.query(`SELECT `+glossaryColumns+` FROM glossaries WHERE id = ?`, id)
+	if err != nil || len(glossaries) == 0 {
+		return nil, err
+	}
+	return glossaries[0], nil
+}
+
+// GetMany retrieves glossaries in the given order. Unknown IDs are an error
+// so a deleted glossary is not silently skipped.
+func (r *GlossaryRepository) GetMany(ids []string) ([]*Glossary, error) {
+	glossaries := make([]*Glossary, 0, len(ids))
+	for _, id := range ids {
+		glossary, err := r.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if glossary == nil {
+			return nil, fmt.Errorf("glossary not found: %s", id)
+		}
+		glossaries = append(glossaries, glossary)
+	}
+	return glossaries, nil
+}
+
+// List returns every glossary ordered by name
+func (r *GlossaryRepository) List() ([]*Glossary, error) {
+	return r.query(`SELECT ` + glossaryColumns + ` FROM glossaries ORDER BY name`)
+}
+
+// Delete removes a glossary and its attachments
+func (r *GlossaryRepository) Delete(id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM glossaries WHERE id = ?", id)
+	return err
+}
+
+// Attach links a glossary to a transcription or download
+func (r *GlossaryRepository) Attach(glossaryID, targetType, targetID string) error {
+	if err := validGlossaryTarget(targetType); err != nil {
+		return err
+	}
+	_, err := r.db.conn.Exec(`INSERT OR IGNORE INTO glossary_links (glossary_id, target_type, target_id) VALUES (?, ?, ?)`,
+		glossaryID, targetType, targetID)
+	return err
+}
+
+// Detach removes a glossary link
+func (r *GlossaryRepository) Detach(glossaryID, targetType, targetID string) error {
+	_, err := r.db.conn.Exec(`DELETE FROM glossary_links WHERE glossary_id = ? AND target_type = ? AND target_id = ?`,
+		glossaryID, targetType, targetID)
+	return err
+}
+
+// ListFor returns the glossaries attached to a transcription or download
+func (r *GlossaryRepository) ListFor(targetType, targetID string) ([]*Glossary, error) {
+	if err := validGlossaryTarget(targetType); err != nil {
+		return nil, err
+	}
+	return r.query(`SELECT `+glossaryColumns+` FROM glossaries
+		WHERE id IN (SELECT glossary_id FROM glossary_links WHERE target_type = ? AND target_id = ?)
+		ORDER BY name`, targetType, targetID)
+}
+
+func (r *GlossaryRepository) query(query string, args ...interface{}) ([]*Glossary, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	glossaries := []*Glossary{}
+	for rows.Next() {
+		g := &Glossary{}
+		var terms, replacements string
+		if err := rows.Scan(&g.ID, &g.Name, &terms, &replacements, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(terms), &g.Terms); err != nil {
+			return nil, fmt.Errorf("glossary %s has corrupt terms: %w", g.ID, err)
+		}
+		if err := json.Unmarshal([]byte(replacements), &g.Replacements); err != nil {
+			return nil, fmt.Errorf("glossary %s has corrupt replacements: %w", g.ID, err)
+		}
+		glossaries = append(glossaries, g)
+	}
+	return glossaries, rows.Err()
+}
+
+// normalizeGlossary trims the name, drops empty or repeated terms and rules
+// without a search text.
+func normalizeGlossary(g *Glossary) error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return fmt.Errorf("glossary name is required")
+	}
+	terms := make([]string, 0, len(g.Terms))
+	seen := make(map[string]bool, len(g.Terms))
+	for _, term := range g.Terms {
+		term = strings.TrimSpace(term)
+		if term == "" || seen[strings.ToLower(term)] {
+			continue
+		}
+		seen[strings.ToLower(term)] = true
+		terms = append(terms, term)
+	}
+	g.Terms = terms
+	rules := make([]GlossaryRule, 0, len(g.Replacements))
+	for _, rule := range g.Replacements {
+		if strings.TrimSpace(rule.Find) == "" {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	g.Replacements = rules
+	return nil
+}
+
+func encodeGlossary(g *Glossary) (string, string, error) {
+	terms, err := json.Marshal(g.Terms)
+	if err != nil {
+		return "", "", err
+	}
+	replacements, err := json.Marshal(g.Replacements)
+	if err != nil {
+		return "", "", err
+	}
+	return string(terms), string(replacements), nil
+}
+
+func validGlossaryTarget(targetType string) error {
+	switch targetType {
+	case GlossaryTargetTranscription, GlossaryTargetDownload:
+		return nil
+	default:
+		return fmt.Errorf("unsupported glossary target: %s", targetType)
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import "testing"
+
+func TestGlossaryRepository_CRUDAndAttachments(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewGlossaryRepository(db)
+
+	glossary := &Glossary{
+		Name:  "  Produto ",
+		Terms: []string{"DownKingo", " ", "downkingo", "yt-dlp"},
+		Replacements: []GlossaryRule{
+			{Find: "down kingo", Replace: "DownKingo"},
+			{Find: " ", Replace: "ignored"},
+		},
+	}
+	if err := repo.Create(glossary); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if glossary.Name != "Produto" || len(glossary.Terms) != 2 || len(glossary.Replacements) != 1 {
+		t.Fatalf("glossary was not normalized: %#v", glossary)
+	}
+	if err := repo.Create(&Glossary{Name: "produto"}); err == nil {
+		t.Fatal("expected duplicate names to be rejected")
+	}
+	if err := repo.Create(&Glossary{Name: " "}); err == nil {
+		t.Fatal("expected a name to be required")
+	}
+
+	glossary.Terms = append(glossary.Terms, "whisper.cpp")
+	if err := repo.Update(glossary); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	got, err := repo.GetByID(glossary.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetByID() = %#v, %v", got, err)
+	}
+	if len(got.Terms) != 3 || got.Replacements[0].Find != "down kingo" {
+		t.Fatalf("unexpected stored glossary: %#v", got)
+	}
+
+	download := newTestDownload("https://example.com/watch?v=glossary")
+	if err := NewDownloadRepository(db).Create(download); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Attach(glossary.ID, GlossaryTargetDownload, download.ID); err != nil {
+		t.Fatalf("Attach() error: %v", err)
+	}
+	if err := repo.Attach(glossary.ID, GlossaryTargetDownload, download.ID); err != nil {
+		t.Fatalf("Attach() twice error: %v", err)
+	}
+	if err := repo.Attach(glossary.ID, "playlist", download.ID); err == nil {
+		t.Fatal("expected an unknown target type to be rejected")
+	}
+	attached, err := repo.ListFor(GlossaryTargetDownload, download.ID)
+	if err != nil || len(attached) != 1 || attached[0].ID != glossary.ID {
+		t.Fatalf("ListFor() = %#v, %v", attached, err)
+	}
+
+	// Deleting the download drops its links.
+	if err := NewDownloadRepository(db).Delete(download.ID); err != nil {
+		t.Fatal(err)
+	}
+	if attached, _ := repo.ListFor(GlossaryTargetDownload, download.ID); len(attached) != 0 {
+		t.Fatalf("links survived the download: %#v", attached)
+	}
+
+	if _, err := repo.GetMany([]string{glossary.ID, "missing"}); err == nil {
+		t.Fatal("expected GetMany to report a missing glossary")
+	}
+	if err := repo.Delete(glossary.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if all, _ := repo.List(); len(all) != 0 {
+		t.Fatalf("glossary was not deleted: %#v", all)
+	}
+}
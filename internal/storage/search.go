@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Snippet markers are private-use characters so the snippet can be HTML
+// escaped before they are turned into <mark> tags.
+const (
+	snippetOpen  = "\ue000"
+	snippetClose = "\ue001"
+)
+
+const searchSchema = `
+	-- Full-text index over download metadata
+	CREATE VIRTUAL TABLE IF NOT EXISTS downloads_fts USING fts5(
+		title, uploader, description, download_id UNINDEXED,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS downloads_fts_insert AFTER INSERT ON downloads BEGIN
+		INSERT INTO downloads_fts (title, uploader, description, download_id)
+		VALUES (COALESCE(new.title,''), COALESCE(new.uploader,''), COALESCE(new.description,''), new.id);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS downloads_fts_update AFTER UPDATE OF title, uploader, description ON downloads BEGIN
+		DELETE FROM downloads_fts WHERE download_id = old.id;
+		INSERT INTO downloads_fts (title, uploader, description, download_id)
+		VALUES (COALESCE(new.title,''), COALESCE(new.uploader,''), COALESCE(new.description,''), new.id);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS downloads_fts_delete AFTER DELETE ON downloads BEGIN
+		DELETE FROM downloads_fts WHERE download_id = old.id;
+	END;
+
+	-- Full-text index over transcript segments, one row per segment
+	CREATE VIRTUAL TABLE IF NOT EXISTS transcript_segments_fts USING fts5(
+		text, transcription_id UNINDEXED, segment_index UNINDEXED, start_time UNINDEXED, end_time UNINDEXED,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS transcriptions_fts_delete AFTER DELETE ON transcriptions BEGIN
+		DELETE FROM transcript_segments_fts WHERE transcription_id = old.id;
+	END;
+`
+
+// migrateSearch creates the FTS5 indexes and fills them from existing rows
+// the first time they are created.
+func (db *DB) migrateSearch() error {
+	var existing int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'downloads_fts'`).Scan(&existing); err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec(searchSchema); err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	if _, err := db.conn.Exec(`INSERT INTO downloads_fts (title, uploader, description, download_id)
+		SELECT COALESCE(title,''), COALESCE(uploader,''), COALESCE(description,''), id FROM downloads`); err != nil {
+		return err
+	}
+
+	rows, err := db.conn.Query(`SELECT id, segments FROM transcriptions WHERE status = 'completed' AND segments IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]string)
+	for rows.Next() {
+		var id, segments string
+		if err := rows.Scan(&id, &segments); err != nil {
+			rows.Close()
+			return err
+		}
+		stored[id] = segments
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, segments := range stored {
+		if err := indexTranscriptSegments(db.conn, id, json.RawMessage(segments)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// indexTranscriptSegments replaces the indexed segments of one transcription.
+func indexTranscriptSegments(conn execer, id string, data json.RawMessage) error {
+	if _, err := conn.Exec(`DELETE FROM transcript_segments_fts WHERE transcription_id = ?`, id); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	}
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return err
+	}
+	for index, segment := range segments {
+		if strings.TrimSpace(segment.Text) == "" {
+			continue
+		}
+		if _, err := conn.Exec(`INSERT INTO transcript_segments_fts (text, transcription_id, segment_index, start_time, end_time)
+			VALUES (?, ?, ?, ?, ?)`, segment.Text, id, index, segment.Start, segment.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchResult is one match from the download history or a transcript.
+// Snippet is HTML-escaped text with matches wrapped in <mark> tags.
+type SearchResult struct {
+	Kind            string  `json:"kind"` // "download" or "segment"
+	DownloadID      string  `json:"downloadId,omitempty"`
+	TranscriptionID string  `json:"transcriptionId,omitempty"`
+	Title           string  `json:"title"`
+	Uploader        string  `json:"uploader,omitempty"`
+	Thumbnail       string  `json:"thumbnail,omitempty"`
+	FilePath        string  `json:"filePath"`
+	Snippet         string  `json:"snippet"`
+	SegmentIndex    int     `json:"segmentIndex"`
+	Start           float64 `json:"start"` // Segment timestamps in seconds
+	End             float64 `json:"end"`
+}
+
+// SearchResults groups download and transcript matches, best match first.
+type SearchResults struct {
+	Downloads []SearchResult `json:"downloads"`
+	Segments  []SearchResult `json:"segments"`
+}
+
+// SearchRepository runs full-text queries over downloads and transcripts
+type SearchRepository struct {
+	db *DB
+}
+
+// NewSearchRepository creates a new search repository
+func NewSearchRepository(db *DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// Search matches every word of query (as a prefix) against download titles,
+// uploaders and descriptions, and against transcript segment text.
+func (r *SearchRepository) Search(query string, limit int) (*SearchResults, error) {
+	results := &SearchResults{Downloads: []SearchResult{}, Segments: []SearchResult{}}
+	match := ftsQuery(query)
+	if match == "" {
+		return results, nil
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	var err error
+	if results.Downloads, err = r.searchDownloads(match, limit); err != nil {
+		return nil, err
+	}
+	if results.Segments, err = r.searchSegments(match, limit); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *SearchRepository) searchDownloads(match string, limit int) ([]SearchResult, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT d.id, COALESCE(d.title,''), COALESCE(d.uploader,''), COALESCE(d.thumbnail,''), COALESCE(d.file_path,''),
+			snippet(downloads_fts, -1, ?, ?, '…', 16)
+		FROM downloads_fts
+		JOIN downloads d ON d.id = downloads_fts.download_id
+		WHERE downloads_fts MATCH ?
+		ORDER BY bm25(downloads_fts, 10.0, 5.0, 1.0)
+		LIMIT ?`, snippetOpen, snippetClose, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		result := SearchResult{Kind: "download"}
+		if err := rows.Scan(&result.DownloadID, &result.Title, &result.Uploader, &result.Thumbnail,
+			&result.FilePath, &result.Snippet); err != nil {
+			return nil, err
+		}
+		result.Snippet = highlightSnippet(result.Snippet)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+func (r *SearchRepository) searchSegments(match string, limit int) ([]SearchResult, error) {
+	// A transcript is linked back to its download through the media path.
+	rows, err := r.db.conn.Query(`
+		SELECT f.transcription_id, f.segment_index, f.start_time, f.end_time, t.file_path,
+			COALESCE((SELECT d.id FROM downloads d WHERE d.file_path = t.file_path ORDER BY d.created_at DESC LIMIT 1), ''),
+			COALESCE((SELECT d.title FROM downloads d WHERE d.file_path = t.file_path ORDER BY d.created_at DESC LIMIT 1), ''),
+			snippet(transcript_segments_fts, 0, ?, ?, '…', 24)
+		FROM transcript_segments_fts f
+		JOIN transcriptions t ON t.id = f.transcription_id
+		WHERE transcript_segments_fts MATCH ?
+		ORDER BY f.rank
+		LIMIT ?`, snippetOpen, snippetClose, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		result := SearchResult{Kind: "segment"}
+		if err := rows.Scan(&result.TranscriptionID, &result.SegmentIndex, &result.Start, &result.End,
+			&result.FilePath, &result.DownloadID, &result.Title, &result.Snippet); err != nil {
+			return nil, err
+		}
+		if result.Title == "" {
+			result.Title = mediaTitle(result.FilePath)
+		}
+		result.Snippet = highlightSnippet(result.Snippet)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// ftsQuery turns free text into an FTS5 query where every word must match as
+// a prefix. Operators and quotes typed by the user are treated as text.
+func ftsQuery(input string) string {
+	words := strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, snippetOpen, "<mark>")
+	return strings.ReplaceAll(escaped, snippetClose, "</mark>")
+}
+
+// mediaTitle derives a display title from a file path when the transcript
+// has no matching download.
+func mediaTitle(path string) string {
+	name := path[strings.LastIndexAny(path, `/\`)+1:]
+	if dot := strings.LastIndex(name, "."); dot > 0 {
+		name = name[:dot]
+	}
+	return name
+}
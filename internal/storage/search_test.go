@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSearchRepository_MatchesDownloadsAndSegments(t *testing.T) {
+	db := setupTestDB(t)
+	downloads := NewDownloadRepository(db)
+	transcriptions := NewTranscriptionRepository(db)
+	search := NewSearchRepository(db)
+
+	d := newTestDownload("https://youtube.com/watch?v=search")
+	if err := downloads.Create(d); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	d.Title = "Receita de pão caseiro"
+	d.Uploader = "Cozinha <Fácil>"
+	d.Description = "Fermentação natural passo a passo"
+	d.FilePath = "/media/pao.mp4"
+	if err := downloads.Update(d); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	record := &Transcription{FilePath: "/media/pao.mp4", Model: "base"}
+	if err := transcriptions.Create(record); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	record.Status = TranscriptionCompleted
+	record.Segments = json.RawMessage(`[{"start":0,"end":4,"text":"Hoje vamos sovar a massa"},{"start":61.5,"end":64,"text":"Agora a fermentação começa"}]`)
+	if err := transcriptions.Update(record); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	// Diacritics and case are ignored, and words match as prefixes.
+	results, err := search.Search("FERMENTACAO", 10)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results.Downloads) != 1 || results.Downloads[0].DownloadID != d.ID {
+		t.Fatalf("downloads = %#v", results.Downloads)
+	}
+	if !strings.Contains(results.Downloads[0].Snippet, "<mark>Fermentação</mark>") {
+		t.Fatalf("download snippet = %q", results.Downloads[0].Snippet)
+	}
+	if len(results.Segments) != 1 {
+		t.Fatalf("segments = %#v", results.Segments)
+	}
+	segment := results.Segments[0]
+	if segment.TranscriptionID != record.ID || segment.SegmentIndex != 1 || segment.Start != 61.5 ||
+		segment.DownloadID != d.ID || segment.Title != d.Title {
+		t.Fatalf("unexpected segment result: %#v", segment)
+	}
+
+	// Markup in stored text is escaped; a stray quote is not FTS syntax.
+	results, err = search.Search(`cozinha "fácil`, 10)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results.Downloads) != 1 || !strings.Contains(results.Downloads[0].Snippet, "&lt;<mark>Fácil</mark>&gt;") {
+		t.Fatalf("escaped snippet missing: %#v", results.Downloads)
+	}
+
+	// Deleting the transcript drops it from the index.
+	if err := transcriptions.Delete(record.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	results, err = search.Search("massa", 10)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results.Segments) != 0 {
+		t.Fatalf("deleted transcript still indexed: %#v", results.Segments)
+	}
+}
+
+func TestSearchMigrationBackfillsExistingRows(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.conn.Exec(`INSERT INTO downloads (id, url, title) VALUES ('old', 'https://example.com', 'Documentário antigo')`); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a database created before the search index existed.
+	for _, statement := range []string{
+		"DROP TRIGGER downloads_fts_insert", "DROP TRIGGER downloads_fts_update", "DROP TRIGGER downloads_fts_delete",
+		"DROP TABLE downloads_fts",
+	} {
+		if _, err := db.conn.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	db, err = New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	results, err := NewSearchRepository(db).Search("documentario", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Downloads) != 1 || results.Downloads[0].DownloadID != "old" {
+		t.Fatalf("existing download was not backfilled: %#v", results.Downloads)
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TranscriptionWatch is a folder whose new media files are transcribed
+// automatically with the stored settings.
+type TranscriptionWatch struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Recursive bool      `json:"recursive"`
+	Model     string    `json:"model"`
+	Language  string    `json:"language"`
+	UseVAD    bool      `json:"useVad"`
+	Task      string    `json:"task"`
+	Speakers  string    `json:"speakers"`
+	Prompt    string    `json:"prompt"`
+	Formats   []string  `json:"formats"`   // Export formats written for every file
+	OutputDir string    `json:"outputDir"` // Root of a mirror tree, empty to write next to each file
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const transcriptionWatchColumns = `id, path, recursive, model, COALESCE(language,'auto'), use_vad,
+	COALESCE(task,'transcribe'), COALESCE(speakers,''), COALESCE(prompt,''), COALESCE(formats,'txt'),
+	COALESCE(output_dir,''), enabled, created_at`
+
+// TranscriptionWatchRepository stores watched folders and the media files
+// already queued from them, so no file is transcribed twice.
+type TranscriptionWatchRepository struct {
+	db *DB
+}
+
+// NewTranscriptionWatchRepository creates a new watched folder repository
+func NewTranscriptionWatchRepository(db *DB) *TranscriptionWatchRepository {
+	return &TranscriptionWatchRepository{db: db}
+}
+
+// Create inserts a watched folder. A folder can only be watched once.
+func (r *TranscriptionWatchRepository) Create(w *TranscriptionWatch) error {
+	if err := normalizeTranscriptionWatch(w); err != nil {
+		return err
+	}
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	w.CreatedAt = time.Now()
+	_, err := r.db.conn.Exec(`INSERT INTO transcription_watches (id, path, recursive, model, language, use_vad, task,
+		speakers, prompt, formats, output_dir, enabled, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		w.ID, w.Path, w.Recursive, w.Model, w.Language, w.UseVAD, w.Task,
+		w.Speakers, w.Prompt, strings.Join(w.Formats, ","), w.OutputDir, w.Enabled, w.CreatedAt)
+	return err
+}
+
+// Update replaces the settings of a watched folder
+func (r *TranscriptionWatchRepository) Update(w *TranscriptionWatch) error {
+	if err := normalizeTranscriptionWatch(w); err != nil {
+		return err
+	}
+	result, err := r.db.conn.Exec(`UPDATE transcription_watches SET path = ?, recursive = ?, model = ?, language = ?,
+		use_vad = ?, task = ?, speakers = ?, prompt = ?, formats = ?, output_dir = ?, enabled = ? WHERE id = ?`,
+		w.Path, w.Recursive, w.Model, w.Language, w.UseVAD, w.Task, w.Speakers, w.Prompt,
+		strings.Join(w.Formats, ","), w.OutputDir, w.Enabled, w.ID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("watched folder not found: %s", w.ID)
+	}
+	return nil
+}
+
+// GetByID retrieves a watched folder, or nil when it does not exist
+func (r *TranscriptionWatchRepository) GetByID(id string) (*TranscriptionWatch, error) {
+	watches, err := r.query(`SELECT `+transcriptionWatchColumns+` FROM transcription_watches WHERE id = ?`, id)
+	if err != nil || len(watches) == 0 {
+		return nil, err
+	}
+	return watches[0], nil
+}
+
+// List returns every watched folder ordered by path
+func (r *TranscriptionWatchRepository) List() ([]*TranscriptionWatch, error) {
+	return r.query(`SELECT ` + transcriptionWatchColumns + ` FROM transcription_watches ORDER BY path`)
+}
+
+// ListEnabled returns the watched folders that are not paused
+func (r *TranscriptionWatchRepository) ListEnabled() ([]*TranscriptionWatch, error) {
+	return r.query(`SELECT `+transcriptionWatchColumns+` FROM transcription_watches WHERE enabled = ? ORDER BY path`, true)
+}
+
+// Delete stops watching a folder. Files already queued stay recorded.
+func (r *TranscriptionWatchRepository) Delete(id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM transcription_watches WHERE id = ?", id)
+	return err
+}
+
+// MarkFileTranscribed records that a media file was queued for transcription.
+// A file counts as the same file while its size and modification time match.
+func (r *TranscriptionWatchRepository) MarkFileTranscribed(path string, size int64, modTime time.Time, transcriptionID string) error {
+	_, err := r.db.conn.Exec(`INSERT OR REPLACE INTO transcribed_files (path, size, mod_time, transcription_id, created_at)
+		VALUES (?, ?, ?, ?, ?)`, filepath.Clean(path), size, modTime.UnixNano(), transcriptionID, time.Now())
+	return err
+}
+
+// ForgetTranscribedFile removes the record of the file queued as the given
+// transcription, so a failed or cancelled file is picked up again.
+func (r *TranscriptionWatchRepository) ForgetTranscribedFile(transcriptionID string) error {
+	_, err := r.db.conn.Exec(`DELETE FROM transcribed_files WHERE transcription_id = ?`, transcriptionID)
+	return err
+}
+
+// IsFileTranscribed reports whether this version of a media file was already
+// queued for transcription.
+func (r *TranscriptionWatchRepository) IsFileTranscribed(path string, size int64, modTime time.Time) (bool, error) {
+	var count int
+	err := r.db.conn.QueryRow(`SELECT COUNT(*) FROM transcribed_files WHERE path = ? AND size = ? AND mod_time = ?`,
+		filepath.Clean(path), size, modTime.UnixNano()).Scan(&count)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *TranscriptionWatchRepository) query(query string, args ...interface{}) ([]*TranscriptionWatch, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	watches := []*TranscriptionWatch{}
+	for rows.Next() {
+		w := &TranscriptionWatch{}
+		var formats string
+		if err := rows.Scan(&w.ID, &w.Path, &w.Recursive, &w.Model, &w.Language, &w.UseVAD, &w.Task,
+			&w.Speakers, &w.Prompt, &formats, &w.OutputDir, &w.Enabled, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		w.Formats = splitFormats(formats)
+		watches = append(watches, w)
+	}
+	return watches, rows.Err()
+}
+
+// normalizeTranscriptionWatch cleans the paths and fills defaults.
+func normalizeTranscriptionWatch(w *TranscriptionWatch) error {
+	if strings.TrimSpace(w.Path) == "" {
+		return fmt.Errorf("watched folder path is required")
+	}
+	if w.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	w.Path = filepath.Clean(w.Path)
+	if w.OutputDir != "" {
+		w.OutputDir = filepath.Clean(w.OutputDir)
+	}
+	if w.Language == "" {
+		w.Language = "auto"
+	}
+	if w.Task == "" {
+		w.Task = "transcribe"
+	}
+	if len(w.Formats) == 0 {
+		w.Formats = []string{"txt"}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTranscriptionWatchRepository_StoresWatchesAndFiles(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewTranscriptionWatchRepository(db)
+
+	watch := &TranscriptionWatch{Path: "/recordings/", Model: "base", Formats: []string{"srt", "txt"}, Enabled: true}
+	if err := repo.Create(watch); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if err := repo.Create(&TranscriptionWatch{Path: "/recordings", Model: "small"}); err == nil {
+		t.Fatal("expected a folder to be watched only once")
+	}
+	got, err := repo.GetByID(watch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "/recordings" || got.Language != "auto" || len(got.Formats) != 2 || got.Formats[1] != "txt" || !got.Enabled {
+		t.Fatalf("unexpected stored watch: %#v", got)
+	}
+	got.Enabled = false
+	if err := repo.Update(got); err != nil {
+		t.Fatal(err)
+	}
+	enabled, err := repo.ListEnabled()
+	if err != nil || len(enabled) != 0 {
+		t.Fatalf("paused watch is still enabled: %#v, %v", enabled, err)
+	}
+
+	modTime := time.Unix(1700000000, 123)
+	if done, _ := repo.IsFileTranscribed("/recordings/a.mp3", 10, modTime); done {
+		t.Fatal("unknown file reported as transcribed")
+	}
+	if err := repo.MarkFileTranscribed("/recordings/a.mp3", 10, modTime, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if done, _ := repo.IsFileTranscribed("/recordings/a.mp3", 10, modTime); !done {
+		t.Fatal("marked file was not reported as transcribed")
+	}
+	if done, _ := repo.IsFileTranscribed("/recordings/a.mp3", 11, modTime); done {
+		t.Fatal("a rewritten file must be transcribed again")
+	}
+	if err := repo.ForgetTranscribedFile("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if done, _ := repo.IsFileTranscribed("/recordings/a.mp3", 10, modTime); done {
+		t.Fatal("forgotten file is still reported as transcribed")
+	}
+}
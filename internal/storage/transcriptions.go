@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TranscriptionStatus represents the state of a queued transcription
+type TranscriptionStatus string
+
+const (
+	TranscriptionPending    TranscriptionStatus = "pending"
+	TranscriptionProcessing TranscriptionStatus = "processing"
+	TranscriptionCompleted  TranscriptionStatus = "completed"
+	TranscriptionFailed     TranscriptionStatus = "failed"
+	TranscriptionCancelled  TranscriptionStatus = "cancelled"
+)
+
+// Transcription represents a queued or finished transcription. Text and
+// Segments are only loaded by GetByID; list queries leave them empty so the
+// queue and history stay cheap to poll.
+type Transcription struct {
+	ID               string              `json:"id"`
+	FilePath         string              `json:"filePath"`
+	Model            string              `json:"model"`
+	Language         string              `json:"language"`
+	OutputFormat     string              `json:"outputFormat"`
+	UseVAD           bool                `json:"useVad"`
+	Task             string              `json:"task"`
+	Speakers         string              `json:"speakers"`      // Speaker detection mode, empty when off
+	Prompt           string              `json:"prompt"`        // Initial prompt given to Whisper
+	ExportDir        string              `json:"exportDir"`     // Transcripts are written here on completion when set
+	ExportFormats    []string            `json:"exportFormats"` // Formats written to ExportDir
+	Status           TranscriptionStatus `json:"status"`
+	DetectedLanguage string              `json:"detectedLanguage"`
+	Duration         float64             `json:"duration"`
+	Text             string              `json:"text,omitempty"`
+	Segments         json.RawMessage     `json:"segments,omitempty"`
+	ErrorMessage     string              `json:"errorMessage"`
+	CreatedAt        time.Time           `json:"createdAt"`
+	StartedAt        *time.Time          `json:"startedAt"`
+	CompletedAt      *time.Time          `json:"completedAt"`
+}
+
+// transcriptionSummaryColumns omits the transcript body for list queries.
+const transcriptionSummaryColumns = `id, file_path, model, COALESCE(language,'auto'), COALESCE(output_format,'txt'),
+	use_vad, COALESCE(task,'transcribe'), COALESCE(speakers,''), COALESCE(prompt,''),
+	COALESCE(export_dir,''), COALESCE(export_formats,''), status, COALESCE(detected_language,''), duration,
+	COALESCE(error_message,''), created_at, started_at, completed_at`
+
+// TranscriptionRepository handles transcription CRUD operations
+type TranscriptionRepository struct {
+	db *DB
+}
+
+// NewTranscriptionRepository creates a new transcription repository
+func NewTranscriptionRepository(db *DB) *TranscriptionRepository {
+	return &TranscriptionRepository{db: db}
+}
+
+// Create inserts a new transcription record
+func (r *TranscriptionRepository) Create(t *Transcription) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.Status == "" {
+		t.Status = TranscriptionPending
+	}
+	t.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO transcriptions (id, file_path, model, language, output_format, use_vad, task, speakers, prompt,
+			export_dir, export_formats, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.conn.Exec(query,
+		t.ID, t.FilePath, t.Model, t.Language, t.OutputFormat, t.UseVAD, t.Task, t.Speakers, t.Prompt,
+		t.ExportDir, strings.Join(t.ExportFormats, ","), t.Status, t.CreatedAt,
+	)
+	return err
+}
+
+// Update persists status, result and timing fields. The segment search
+// index is rewritten in the same transaction.
+func (r *TranscriptionRepository) Update(t *Transcription) error {
+	query := `
+		UPDATE transcriptions SET
+			status = ?, detected_language = ?, duration = ?, text = ?, segments = ?,
+			error_message = ?, started_at = ?, completed_at = ?
+		WHERE id = ?
+	`
+	var segments interface{}
+	if len(t.Segments) > 0 {
+		segments = string(t.Segments)
+	}
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query,
+		t.Status, t.DetectedLanguage, t.Duration, t.Text, segments,
+		t.ErrorMessage, t.StartedAt, t.CompletedAt, t.ID,
+	); err != nil {
+		return err
+	}
+	var indexed json.RawMessage
+	if t.Status == TranscriptionCompleted {
+		indexed = t.Segments
+	}
+	if err := indexTranscriptSegments(tx, t.ID, indexed); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateStatus updates only the status of a transcription
+func (r *TranscriptionRepository) UpdateStatus(id string, status TranscriptionStatus) error {
+	_, err := r.db.conn.Exec(`UPDATE transcriptions SET status = ? WHERE id = ?`, status, id)
+	return err
+}
+
+// Cancel marks a pending or processing transcription as cancelled. It reports
+// false when the transcription does not exist or has already finished.
+func (r *TranscriptionRepository) Cancel(id string) (bool, error) {
+	result, err := r.db.conn.Exec(`UPDATE transcriptions SET status = ?, completed_at = ?
+		WHERE id = ? AND status IN (?, ?)`,
+		TranscriptionCancelled, time.Now(), id, TranscriptionPending, TranscriptionProcessing)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetByID retrieves a transcription with its full transcript
+func (r *TranscriptionRepository) GetByID(id string) (*Transcription, error) {
+	query := `SELECT ` + transcriptionSummaryColumns + `, COALESCE(text,''), COALESCE(segments,'')
+		FROM transcriptions WHERE id = ?`
+
+	t := &Transcription{}
+	var formats, segments string
+	err := r.db.conn.QueryRow(query, id).Scan(
+		&t.ID, &t.FilePath, &t.Model, &t.Language, &t.OutputFormat,
+		&t.UseVAD, &t.Task, &t.Speakers, &t.Prompt, &t.ExportDir, &formats, &t.Status, &t.DetectedLanguage, &t.Duration,
+		&t.ErrorMessage, &t.CreatedAt, &t.StartedAt, &t.CompletedAt,
+		&t.Text, &segments,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.ExportFormats = splitFormats(formats)
+	if segments != "" {
+		t.Segments = json.RawMessage(segments)
+	}
+	return t, nil
+}
+
+// GetPending retrieves pending transcriptions ordered by creation time
+func (r *TranscriptionRepository) GetPending() ([]*Transcription, error) {
+	query := `SELECT ` + transcriptionSummaryColumns + ` FROM transcriptions
+		WHERE status = ? ORDER BY created_at ASC`
+	return r.query(query, TranscriptionPending)
+}
+
+// RequeueInterrupted moves transcriptions left in "processing" by a previous
+// session back to "pending" so they are restored on startup.
+func (r *TranscriptionRepository) RequeueInterrupted() error {
+	_, err := r.db.conn.Exec(`UPDATE transcriptions SET status = ?, started_at = NULL WHERE status = ?`,
+		TranscriptionPending, TranscriptionProcessing)
+	return err
+}
+
+// GetQueue retrieves all unfinished transcriptions (pending + processing)
+func (r *TranscriptionRepository) GetQueue() ([]*Transcription, error) {
+	query := `SELECT ` + transcriptionSummaryColumns + ` FROM transcriptions
+		WHERE status NOT IN ('completed', 'failed', 'cancelled') ORDER BY created_at ASC`
+	return r.query(query)
+}
+
+// GetHistory retrieves finished transcriptions, newest first
+func (r *TranscriptionRepository) GetHistory(limit int) ([]*Transcription, error) {
+	query := `SELECT ` + transcriptionSummaryColumns + ` FROM transcriptions
+		WHERE status IN ('completed', 'failed', 'cancelled') ORDER BY completed_at DESC LIMIT ?`
+	return r.query(query, limit)
+}
+
+// Delete removes a transcription record
+func (r *TranscriptionRepository) Delete(id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM transcriptions WHERE id = ?", id)
+	return err
+}
+
+// ClearHistory removes all finished transcriptions
+func (r *TranscriptionRepository) ClearHistory() error {
+	_, err := r.db.conn.Exec("DELETE FROM transcriptions WHERE status IN ('completed', 'failed', 'cancelled')")
+	return err
+}
+
+func (r *TranscriptionRepository) query(query string, args ...interface{}) ([]*Transcription, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transcriptions []*Transcription
+	for rows.Next() {
+		t := &Transcription{}
+		var formats string
+		if err := rows.Scan(
+			&t.ID, &t.FilePath, &t.Model, &t.Language, &t.OutputFormat,
+			&t.UseVAD, &t.Task, &t.Speakers, &t.Prompt, &t.ExportDir, &formats, &t.Status, &t.DetectedLanguage, &t.Duration,
+			&t.ErrorMessage, &t.CreatedAt, &t.StartedAt, &t.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		t.ExportFormats = splitFormats(formats)
+		transcriptions = append(transcriptions, t)
+	}
+	return transcriptions, rows.Err()
+}
+
+// splitFormats decodes a comma-separated list of export formats.
+func splitFormats(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTranscriptionRepository_StoresResultAndKeepsListsLight(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewTranscriptionRepository(db)
+
+	record := &Transcription{FilePath: "/media/talk.mp3", Model: "base", Language: "pt", OutputFormat: "srt", Task: "transcribe"}
+	if err := repo.Create(record); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if record.ID == "" || record.Status != TranscriptionPending {
+		t.Fatalf("unexpected created record: %#v", record)
+	}
+
+	now := time.Now()
+	record.Status = TranscriptionCompleted
+	record.Text = "Olá mundo"
+	record.Segments = json.RawMessage(`[{"start":0,"end":1.5,"text":"Olá mundo"}]`)
+	record.DetectedLanguage = "pt"
+	record.Duration = 1.5
+	record.CompletedAt = &now
+	if err := repo.Update(record); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	got, err := repo.GetByID(record.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error: %v", err)
+	}
+	if got.Text != "Olá mundo" || string(got.Segments) != string(record.Segments) || got.Duration != 1.5 || got.Model != "base" {
+		t.Fatalf("stored transcript was not reopened: %#v", got)
+	}
+
+	history, err := repo.GetHistory(10)
+	if err != nil {
+		t.Fatalf("GetHistory() error: %v", err)
+	}
+	if len(history) != 1 || history[0].Text != "" || history[0].Segments != nil {
+		t.Fatalf("history should omit transcript body: %#v", history)
+	}
+}
+
+func TestTranscriptionRepository_RequeueInterrupted(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewTranscriptionRepository(db)
+
+	running := &Transcription{FilePath: "/media/a.wav", Model: "base", Status: TranscriptionProcessing}
+	cancelled := &Transcription{FilePath: "/media/b.wav", Model: "base", Status: TranscriptionCancelled}
+	for _, record := range []*Transcription{running, cancelled} {
+		if err := repo.Create(record); err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+	}
+	if err := repo.RequeueInterrupted(); err != nil {
+		t.Fatalf("RequeueInterrupted() error: %v", err)
+	}
+	pending, err := repo.GetPending()
+	if err != nil {
+		t.Fatalf("GetPending() error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != running.ID {
+		t.Fatalf("pending = %#v", pending)
+	}
+}
+
+func TestTranscriptionRepository_CancelOnlyUnfinished(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewTranscriptionRepository(db)
+
+	pending := &Transcription{FilePath: "/media/a.mp3", Model: "base"}
+	done := &Transcription{FilePath: "/media/b.mp3", Model: "base", Status: TranscriptionCompleted}
+	for _, record := range []*Transcription{pending, done} {
+		if err := repo.Create(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if cancelled, err := repo.Cancel(pending.ID); err != nil || !cancelled {
+		t.Fatalf("Cancel(pending) = %v, %v", cancelled, err)
+	}
+	if cancelled, err := repo.Cancel(done.ID); err != nil || cancelled {
+		t.Fatalf("Cancel(completed) = %v, %v", cancelled, err)
+	}
+	got, err := repo.GetByID(done.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != TranscriptionCompleted {
+		t.Fatalf("completed transcription became %s", got.Status)
+	}
+}
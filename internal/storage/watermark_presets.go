@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WatermarkPreset is a named watermark saved by the user. Settings holds a
+// JSON converter.Watermark.
+type WatermarkPreset struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Settings  json.RawMessage `json:"settings"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+const watermarkPresetColumns = `id, name, COALESCE(settings,'{}'), created_at, updated_at`
+
+// WatermarkPresetRepository handles watermark preset CRUD
+type WatermarkPresetRepository struct {
+	db *DB
+}
+
+// NewWatermarkPresetRepository creates a new watermark preset repository
+func NewWatermarkPresetRepository(db *DB) *WatermarkPresetRepository {
+	return &WatermarkPresetRepository{db: db}
+}
+
+// Create inserts a new preset. Names are unique, ignoring case.
+func (r *WatermarkPresetRepository) Create(p *WatermarkPreset) error {
+	if err := normalizeWatermarkPreset(p); err != nil {
+		return err
+	}
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	_, err := r.db.conn.Exec(`INSERT INTO watermark_presets (id, name, settings, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`, p.ID, p.Name, string(p.Settings), p.CreatedAt, p.UpdatedAt)
+	return err
+}
+
+// Update replaces the name and settings of a preset
+func (r *WatermarkPresetRepository) Update(p *WatermarkPreset) error {
+	if err := normalizeWatermarkPreset(p); err != nil {
+		return err
+	}
+	p.UpdatedAt = time.Now()
+	result, err := r.db.conn.Exec(`UPDATE watermark_presets SET name = ?, settings = ?, updated_at = ? WHERE id = ?`,
+		p.Name, string(p.Settings), p.UpdatedAt, p.ID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("watermark preset not found: %s", p.ID)
+	}
+	return nil
+}
+
+// GetByName retrieves a preset ignoring case, or nil when it does not exist
+func (r *WatermarkPresetRepository) GetByName(name string) (*WatermarkPreset, error) {
+	presets, err := r.query(`SELECT `+watermarkPresetColumns+` FROM watermark_presets WHERE name = ?`, strings.TrimSpace(name))
+	if err != nil || len(presets) == 0 {
+		return nil, err
+	}
+	return presets[0], nil
+}
+
+// List returns every preset ordered by name
+func (r *WatermarkPresetRepository) List() ([]*WatermarkPreset, error) {
+	return r.query(`SELECT ` + watermarkPresetColumns + ` FROM watermark_presets ORDER BY name`)
+}
+
+// Delete removes a preset
+func (r *WatermarkPresetRepository) Delete(id string) error {
+	_, err := r.db.conn.Exec("DELETE FROM watermark_presets WHERE id = ?", id)
+	return err
+}
+
+func (r *WatermarkPresetRepository) query(query string, args ...interface{}) ([]*WatermarkPreset, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presets := []*WatermarkPreset{}
+	for rows.Next() {
+		p := &WatermarkPreset{}
+		var settings string
+		if err := rows.Scan(&p.ID, &p.Name, &settings, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Settings = json.RawMessage(settings)
+		presets = append(presets, p)
+	}
+	return presets, rows.Err()
+}
+
+func normalizeWatermarkPreset(p *WatermarkPreset) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("watermark preset name is required")
+	}
+	if len(p.Settings) == 0 {
+		p.Settings = json.RawMessage("{}")
+	}
+	if !json.Valid(p.Settings) {
+		return fmt.Errorf("watermark preset settings are not valid JSON")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWatermarkPresetRepository_CRUD(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWatermarkPresetRepository(db)
+
+	preset := &WatermarkPreset{Name: " Canal ", Settings: json.RawMessage(`{"text":"@canal","position":"top-right"}`)}
+	if err := repo.Create(preset); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if preset.ID == "" || preset.Name != "Canal" {
+		t.Fatalf("unexpected created preset: %#v", preset)
+	}
+	if err := repo.Create(&WatermarkPreset{Name: "CANAL"}); err == nil {
+		t.Fatal("expected duplicate name to be rejected ignoring case")
+	}
+
+	preset.Settings = json.RawMessage(`{"imagePath":"logo.png","opacity":0.5}`)
+	if err := repo.Update(preset); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	got, err := repo.GetByName("canal")
+	if err != nil || got == nil || string(got.Settings) != `{"imagePath":"logo.png","opacity":0.5}` {
+		t.Fatalf("GetByName() = %#v, %v", got, err)
+	}
+
+	if err := repo.Update(&WatermarkPreset{ID: "missing", Name: "x"}); err == nil {
+		t.Fatal("expected update of unknown preset to fail")
+	}
+	if err := repo.Create(&WatermarkPreset{Name: "bad", Settings: json.RawMessage(`{`)}); err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+
+	if err := repo.Delete(preset.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if presets, _ := repo.List(); len(presets) != 0 {
+		t.Fatalf("preset not deleted: %#v", presets)
+	}
+}
@@ -0,0 +1,181 @@
+package transcription
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kingo/internal/logger"
+	"kingo/internal/storage"
+	"kingo/internal/whisper"
+)
+
+// mediaExtensions are the files picked up by folder batches and watchers.
+var mediaExtensions = map[string]bool{
+	".mp3": true, ".wav": true, ".m4a": true, ".ogg": true, ".opus": true, ".flac": true,
+	".aac": true, ".wma": true, ".mp4": true, ".mkv": true, ".avi": true, ".mov": true,
+	".webm": true, ".wmv": true, ".flv": true,
+}
+
+// BatchOptions configures the transcription of every media file in a folder.
+type BatchOptions struct {
+	Options     whisper.TranscribeOptions // Shared by every file; OutputFormat is replaced by the first format
+	Formats     []string                  // Export formats written for every file, txt when empty
+	OutputDir   string                    // Root of a mirror tree; empty writes next to each file
+	Recursive   bool
+	Reprocess   bool // Queue files that were already transcribed
+	GlossaryIDs []string
+}
+
+// BatchResult lists what happened to each media file of a folder.
+type BatchResult struct {
+	Queued  []*storage.Transcription `json:"queued"`
+	Skipped []string                 `json:"skipped"` // Already transcribed
+	Failed  []BatchFailure           `json:"failed"`
+}
+
+// BatchFailure is a media file that could not be queued.
+type BatchFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// SetWatchRepository enables watched folders and the record of transcribed
+// files. When unset, batches cannot skip files transcribed before.
+func (m *Manager) SetWatchRepository(repo *storage.TranscriptionWatchRepository) {
+	m.watches = repo
+}
+
+// TranscribeFolder queues every media file in dir. Transcripts are written in
+// each format next to the media, or into the same relative folder under
+// OutputDir. A file that cannot be queued does not stop the others.
+func (m *Manager) TranscribeFolder(dir string, opts BatchOptions) (*BatchResult, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("folder not found: %s", dir)
+	}
+	formats, err := normalizeFormats(opts.Formats)
+	if err != nil {
+		return nil, err
+	}
+	opts.Formats = formats
+	files, err := findMediaFiles(dir, opts.Recursive, opts.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{Queued: []*storage.Transcription{}, Skipped: []string{}, Failed: []BatchFailure{}}
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			result.Failed = append(result.Failed, BatchFailure{Path: path, Error: err.Error()})
+			continue
+		}
+		if !opts.Reprocess && m.watches != nil {
+			done, err := m.watches.IsFileTranscribed(path, info.Size(), info.ModTime())
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				result.Skipped = append(result.Skipped, path)
+				continue
+			}
+		}
+		record, err := m.queueMediaFile(path, info, dir, opts)
+		if err != nil {
+			result.Failed = append(result.Failed, BatchFailure{Path: path, Error: err.Error()})
+			continue
+		}
+		result.Queued = append(result.Queued, record)
+	}
+	logger.Log.Info().
+		Str("dir", dir).
+		Int("queued", len(result.Queued)).
+		Int("skipped", len(result.Skipped)).
+		Int("failed", len(result.Failed)).
+		Msg("folder transcription queued")
+	return result, nil
+}
+
+// queueMediaFile queues one file of a batch or watched folder and records it
+// as transcribed.
+func (m *Manager) queueMediaFile(path string, info os.FileInfo, root string, opts BatchOptions) (*storage.Transcription, error) {
+	options := opts.Options
+	options.OutputFormat = opts.Formats[0]
+	record, err := newRecord(path, options)
+	if err != nil {
+		return nil, err
+	}
+	record.ExportDir = exportDir(path, root, opts.OutputDir)
+	record.ExportFormats = opts.Formats
+	if err := m.enqueue(record, opts.GlossaryIDs); err != nil {
+		return nil, err
+	}
+	if m.watches != nil {
+		if err := m.watches.MarkFileTranscribed(path, info.Size(), info.ModTime(), record.ID); err != nil {
+			logger.Log.Error().Err(err).Str("path", path).Msg("failed to record transcribed file")
+		}
+	}
+	return record, nil
+}
+
+// exportDir returns where the transcripts of a media file go: next to it, or
+// the same relative folder under outputDir.
+func exportDir(path, root, outputDir string) string {
+	dir := filepath.Dir(path)
+	if outputDir == "" {
+		return dir
+	}
+	relative, err := filepath.Rel(root, dir)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		return outputDir
+	}
+	return filepath.Join(outputDir, relative)
+}
+
+// findMediaFiles lists the media files in dir, skipping hidden files and the
+// output tree when it lives inside dir.
+func findMediaFiles(dir string, recursive bool, outputDir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil // Unreadable subfolders are skipped
+		}
+		hidden := strings.HasPrefix(entry.Name(), ".") || strings.HasPrefix(entry.Name(), "~")
+		if entry.IsDir() {
+			if path != dir && (!recursive || hidden || (outputDir != "" && filepath.Clean(path) == filepath.Clean(outputDir))) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hidden && entry.Type().IsRegular() && mediaExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// normalizeFormats validates export formats, defaulting to plain text.
+func normalizeFormats(formats []string) ([]string, error) {
+	normalized := make([]string, 0, len(formats))
+	seen := make(map[string]bool, len(formats))
+	for _, format := range formats {
+		format = whisper.NormalizeExportFormat(format)
+		if _, ok := whisper.ExportExtension(format); !ok {
+			return nil, fmt.Errorf("unsupported transcription output format: %s", format)
+		}
+		if !seen[format] {
+			seen[format] = true
+			normalized = append(normalized, format)
+		}
+	}
+	if len(normalized) == 0 {
+		normalized = append(normalized, whisper.FormatTXT)
+	}
+	return normalized, nil
+}
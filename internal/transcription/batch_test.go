@@ -0,0 +1,183 @@
+package transcription
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kingo/internal/storage"
+	"kingo/internal/whisper"
+)
+
+func writeMedia(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("fixture"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranscribeFolderMirrorsTreeAndSkipsDoneFiles(t *testing.T) {
+	m, _ := testManager(t, &fakeTranscriber{})
+	m.Start()
+	dir := t.TempDir()
+	writeMedia(t, filepath.Join(dir, "monday.mp3"))
+	writeMedia(t, filepath.Join(dir, "team", "standup.m4a"))
+	writeMedia(t, filepath.Join(dir, "notes.txt"))
+	writeMedia(t, filepath.Join(dir, ".hidden.mp3"))
+	output := filepath.Join(t.TempDir(), "transcripts")
+
+	opts := BatchOptions{
+		Options:   whisper.TranscribeOptions{Model: "base"},
+		Formats:   []string{"srt", "txt"},
+		OutputDir: output,
+		Recursive: true,
+	}
+	result, err := m.TranscribeFolder(dir, opts)
+	if err != nil {
+		t.Fatalf("TranscribeFolder() error: %v", err)
+	}
+	if len(result.Queued) != 2 || len(result.Skipped) != 0 || len(result.Failed) != 0 {
+		t.Fatalf("unexpected batch result: %#v", result)
+	}
+	for _, record := range result.Queued {
+		waitForStatus(t, m, record.ID, storage.TranscriptionCompleted)
+	}
+	for _, name := range []string{"monday.srt", "monday.txt", filepath.Join("team", "standup.srt"), filepath.Join("team", "standup.txt")} {
+		if _, err := os.Stat(filepath.Join(output, name)); err != nil {
+			t.Fatalf("missing export %s: %v", name, err)
+		}
+	}
+
+	again, err := m.TranscribeFolder(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Queued) != 0 || len(again.Skipped) != 2 {
+		t.Fatalf("transcribed files were queued again: %#v", again)
+	}
+	opts.Reprocess = true
+	opts.Recursive = false
+	forced, err := m.TranscribeFolder(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forced.Queued) != 1 {
+		t.Fatalf("reprocess did not queue the top-level file: %#v", forced)
+	}
+}
+
+func TestTranscribeFolderWritesNextToMedia(t *testing.T) {
+	m, _ := testManager(t, &fakeTranscriber{})
+	m.Start()
+	dir := t.TempDir()
+	writeMedia(t, filepath.Join(dir, "talk.wav"))
+
+	result, err := m.TranscribeFolder(dir, BatchOptions{Options: whisper.TranscribeOptions{Model: "base"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Queued) != 1 {
+		t.Fatalf("unexpected batch result: %#v", result)
+	}
+	waitForStatus(t, m, result.Queued[0].ID, storage.TranscriptionCompleted)
+	data, err := os.ReadFile(filepath.Join(dir, "talk.txt"))
+	if err != nil || !strings.Contains(string(data), "Olá") {
+		t.Fatalf("transcript next to media = %q, %v", data, err)
+	}
+
+	again, err := m.TranscribeFolder(dir, BatchOptions{Options: whisper.TranscribeOptions{Model: "base"}, Reprocess: true})
+	if err != nil || len(again.Queued) != 1 {
+		t.Fatalf("reprocess = %#v, %v", again, err)
+	}
+	waitForStatus(t, m, again.Queued[0].ID, storage.TranscriptionCompleted)
+	if exports, _ := filepath.Glob(filepath.Join(dir, "talk_*.txt")); len(exports) != 1 {
+		t.Fatalf("existing transcript was not kept: %v", exports)
+	}
+	if _, err := m.TranscribeFolder(dir, BatchOptions{Options: whisper.TranscribeOptions{Model: "base"}, Formats: []string{"pdf"}}); err == nil {
+		t.Fatal("expected an unknown format to be rejected")
+	}
+}
+
+func TestWatcherQueuesStableFilesOnce(t *testing.T) {
+	m, _ := testManager(t, &fakeTranscriber{})
+	m.watchInterval = 20 * time.Millisecond
+	dir := t.TempDir()
+	watch := &storage.TranscriptionWatch{Path: dir, Model: "base", Formats: []string{"vtt"}}
+	if err := m.AddWatch(watch); err != nil {
+		t.Fatalf("AddWatch() error: %v", err)
+	}
+	m.Start()
+	writeMedia(t, filepath.Join(dir, "call.mp3"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "call.vtt")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("watched file was never transcribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	history, err := m.GetHistory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("watched file was transcribed %d times", len(history))
+	}
+}
+
+func TestWatcherRetriesFailedFile(t *testing.T) {
+	m, _ := testManager(t, &fakeTranscriber{err: errors.New("model not installed")})
+	watch := &storage.TranscriptionWatch{Path: t.TempDir(), Model: "base"}
+	if err := m.AddWatch(watch); err != nil {
+		t.Fatal(err)
+	}
+	writeMedia(t, filepath.Join(watch.Path, "call.mp3"))
+	observed := make(map[string]observedFile)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		m.scanWatches(observed)
+		m.scanWatches(observed)
+		job := m.next()
+		if job == nil {
+			t.Fatalf("attempt %d: stable file was not queued", attempt)
+		}
+		m.processJob(job)
+		if job.Transcription.Status != storage.TranscriptionFailed {
+			t.Fatalf("attempt %d: status = %s", attempt, job.Transcription.Status)
+		}
+	}
+}
+
+func TestWatcherWaitsForGrowingFile(t *testing.T) {
+	m, _ := testManager(t, &fakeTranscriber{})
+	watch := &storage.TranscriptionWatch{Path: t.TempDir(), Model: "base"}
+	if err := m.AddWatch(watch); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(watch.Path, "live.mp3")
+	writeMedia(t, path)
+	observed := make(map[string]observedFile)
+
+	m.scanWatches(observed)
+	if err := os.WriteFile(path, []byte("fixture, still recording"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.scanWatches(observed)
+	if queue, _ := m.GetQueue(); len(queue) != 0 {
+		t.Fatalf("growing file was queued: %#v", queue)
+	}
+	m.scanWatches(observed)
+	if queue, _ := m.GetQueue(); len(queue) != 1 {
+		t.Fatalf("stable file was not queued: %#v", queue)
+	}
+}
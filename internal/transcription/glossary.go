@@ -0,0 +1,30 @@
+package transcription
+
+import (
+	"kingo/internal/storage"
+	"kingo/internal/whisper"
+)
+
+// ApplyGlossaries adds the glossary terms to the initial prompt and appends
+// their find/replace rules to opts. Rules already in opts run first.
+func ApplyGlossaries(opts whisper.TranscribeOptions, glossaries []*storage.Glossary) whisper.TranscribeOptions {
+	if len(glossaries) == 0 {
+		return opts
+	}
+	var terms []string
+	rules := append([]whisper.Replacement(nil), opts.Replacements...)
+	for _, glossary := range glossaries {
+		terms = append(terms, glossary.Terms...)
+		for _, rule := range glossary.Replacements {
+			rules = append(rules, whisper.Replacement{
+				Find:      rule.Find,
+				Replace:   rule.Replace,
+				MatchCase: rule.MatchCase,
+				WholeWord: rule.WholeWord,
+			})
+		}
+	}
+	opts.Prompt = whisper.BuildPrompt(opts.Prompt, terms)
+	opts.Replacements = rules
+	return opts
+}
@@ -0,0 +1,530 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"kingo/internal/events"
+	"kingo/internal/logger"
+	"kingo/internal/storage"
+	"kingo/internal/whisper"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// Transcriber runs a single transcription. *whisper.Client implements it.
+type Transcriber interface {
+	Transcribe(ctx context.Context, filePath string, opts whisper.TranscribeOptions) (*whisper.TranscribeResult, error)
+}
+
+// Job represents a transcription job in the queue
+type Job struct {
+	Transcription *storage.Transcription
+	Ctx           context.Context
+	Cancel        context.CancelFunc
+}
+
+// Manager runs queued transcriptions with a fixed number of workers. Every
+// job is persisted, so the queue survives restarts and finished transcripts
+// can be reopened and exported again later.
+type Manager struct {
+	ctx         context.Context
+	repo        *storage.TranscriptionRepository
+	glossaries  *storage.GlossaryRepository
+	watches     *storage.TranscriptionWatchRepository
+	transcriber Transcriber
+	workers     int
+
+	watchInterval time.Duration
+	rescan        chan struct{}
+
+	// pending is unbounded so restoring a long queue or adding a large folder
+	// never blocks the caller.
+	pending   []*Job
+	wake      chan struct{}
+	jobs      map[string]*Job
+	mu        sync.RWMutex
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewManager creates a new transcription manager. Whisper is CPU and memory
+// heavy, so a single worker is the default.
+func NewManager(repo *storage.TranscriptionRepository, transcriber Transcriber, workers int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Manager{
+		ctx:         context.Background(),
+		repo:        repo,
+		transcriber: transcriber,
+		workers:     workers,
+
+		watchInterval: defaultWatchInterval,
+		rescan:        make(chan struct{}, 1),
+
+		wake: make(chan struct{}, 1),
+		jobs: make(map[string]*Job),
+		quit: make(chan struct{}),
+	}
+}
+
+// SetContext sets the parent context of every job
+func (m *Manager) SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.ctx = ctx
+}
+
+// SetGlossaryRepository enables glossaries attached to transcriptions. When
+// unset, jobs run with their own prompt only.
+func (m *Manager) SetGlossaryRepository(repo *storage.GlossaryRepository) {
+	m.glossaries = repo
+}
+
+// Start launches the workers and restores jobs from the previous session
+func (m *Manager) Start() {
+	m.startOnce.Do(func() {
+		logger.Log.Info().Int("workers", m.workers).Msg("transcription manager started")
+		m.wg.Add(m.workers)
+		for range m.workers {
+			go func() {
+				defer m.wg.Done()
+				m.workerLoop()
+			}()
+		}
+		m.restorePendingJobs()
+		if m.watches != nil {
+			m.wg.Add(1)
+			go func() {
+				defer m.wg.Done()
+				m.watchLoop()
+			}()
+		}
+	})
+}
+
+// Stop cancels running jobs and waits for the workers to exit. Jobs still
+// waiting stay pending in the database and resume on the next start.
+func (m *Manager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.quit)
+
+		m.mu.RLock()
+		cancels := make([]context.CancelFunc, 0, len(m.jobs))
+		for _, job := range m.jobs {
+			cancels = append(cancels, job.Cancel)
+		}
+		m.mu.RUnlock()
+		for _, cancel := range cancels {
+			cancel()
+		}
+
+		m.wg.Wait()
+		logger.Log.Info().Msg("transcription manager stopped")
+	})
+}
+
+func (m *Manager) workerLoop() {
+	for {
+		if job := m.next(); job != nil {
+			m.processJob(job)
+			continue
+		}
+		select {
+		case <-m.quit:
+			return
+		case <-m.wake:
+		}
+	}
+}
+
+// next pops the oldest waiting job, or returns nil when there is none or the
+// manager is stopping.
+func (m *Manager) next() *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.quit:
+		return nil
+	default:
+	}
+	if len(m.pending) == 0 {
+		return nil
+	}
+	job := m.pending[0]
+	m.pending[0] = nil
+	m.pending = m.pending[1:]
+	if len(m.pending) > 0 {
+		m.signal() // Another worker may be idle
+	}
+	return job
+}
+
+// signal wakes one idle worker.
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// AddJob persists a transcription request and queues it. The given glossaries
+// are attached to the new transcription before it can start.
+func (m *Manager) AddJob(filePath string, opts whisper.TranscribeOptions, glossaryIDs ...string) (*storage.Transcription, error) {
+	record, err := newRecord(filePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.enqueue(record, glossaryIDs); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// newRecord validates a request and builds its pending transcription.
+func newRecord(filePath string, opts whisper.TranscribeOptions) (*storage.Transcription, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("file path is required")
+	}
+	if opts.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if info, err := os.Stat(filePath); err != nil || info.IsDir() {
+		return nil, fmt.Errorf("media file not found: %s", filePath)
+	}
+	if _, ok := whisper.ExportExtension(opts.OutputFormat); !ok {
+		return nil, fmt.Errorf("unsupported transcription output format: %s", opts.OutputFormat)
+	}
+	language := opts.Language
+	if language == "" {
+		language = "auto"
+	}
+	task := opts.Task
+	if task == "" {
+		task = whisper.TaskTranscribe
+	}
+
+	return &storage.Transcription{
+		FilePath:     filePath,
+		Model:        opts.Model,
+		Language:     language,
+		OutputFormat: opts.OutputFormat,
+		UseVAD:       opts.UseVAD,
+		Task:         task,
+		Speakers:     opts.Speakers,
+		Prompt:       opts.Prompt,
+		Status:       storage.TranscriptionPending,
+	}, nil
+}
+
+// enqueue persists a new transcription, attaches its glossaries and queues it.
+func (m *Manager) enqueue(record *storage.Transcription, glossaryIDs []string) error {
+	if err := m.repo.Create(record); err != nil {
+		return err
+	}
+	if len(glossaryIDs) > 0 && m.glossaries == nil {
+		_ = m.repo.Delete(record.ID)
+		return fmt.Errorf("glossaries are not available")
+	}
+	for _, glossaryID := range glossaryIDs {
+		if err := m.glossaries.Attach(glossaryID, storage.GlossaryTargetTranscription, record.ID); err != nil {
+			_ = m.repo.Delete(record.ID)
+			return fmt.Errorf("attach glossary %s: %w", glossaryID, err)
+		}
+	}
+
+	m.queueJob(record)
+	m.emitEvent(events.TranscriptionAdded, record)
+
+	logger.Log.Info().
+		Str("traceID", record.ID).
+		Str("phase", "enqueue").
+		Str("model", record.Model).
+		Msg("transcription added to queue")
+	return nil
+}
+
+// CancelJob cancels a queued or running transcription
+func (m *Manager) CancelJob(id string) error {
+	m.mu.Lock()
+	job, exists := m.jobs[id]
+	waiting := exists && m.removePending(id)
+	m.mu.Unlock()
+
+	if exists {
+		job.Cancel()
+	}
+	if waiting {
+		m.finishJob(job.Transcription, storage.TranscriptionCancelled, "")
+		return nil
+	}
+	cancelled, err := m.repo.Cancel(id)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return fmt.Errorf("transcription %s is not pending or running", id)
+	}
+	return nil
+}
+
+// removePending drops a waiting job from the queue. The caller holds mu.
+func (m *Manager) removePending(id string) bool {
+	for i, job := range m.pending {
+		if job.Transcription.ID == id {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetQueue returns pending and running transcriptions
+func (m *Manager) GetQueue() ([]*storage.Transcription, error) {
+	return m.repo.GetQueue()
+}
+
+// GetHistory returns finished transcriptions without their transcript body
+func (m *Manager) GetHistory(limit int) ([]*storage.Transcription, error) {
+	return m.repo.GetHistory(limit)
+}
+
+// Get reopens a stored transcription with its text and segments
+func (m *Manager) Get(id string) (*storage.Transcription, error) {
+	record, err := m.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("transcription not found: %s", id)
+	}
+	return record, nil
+}
+
+// Delete removes a finished transcription
+func (m *Manager) Delete(id string) error {
+	m.mu.RLock()
+	_, running := m.jobs[id]
+	m.mu.RUnlock()
+	if running {
+		return fmt.Errorf("transcription is still in the queue; cancel it first")
+	}
+	return m.repo.Delete(id)
+}
+
+// ClearHistory removes all finished transcriptions
+func (m *Manager) ClearHistory() error {
+	return m.repo.ClearHistory()
+}
+
+// Export renders a stored transcript in the given format and writes it to
+// outputPath.
+func (m *Manager) Export(id, format, outputPath string) error {
+	record, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	if record.Status != storage.TranscriptionCompleted {
+		return fmt.Errorf("transcription %s has no result (status %s)", id, record.Status)
+	}
+	segments, err := decodeSegments(record.Segments)
+	if err != nil {
+		return err
+	}
+	return whisper.WriteTranscript(segments, record.DetectedLanguage, format, outputPath)
+}
+
+func (m *Manager) processJob(job *Job) {
+	record := job.Transcription
+	select {
+	case <-job.Ctx.Done():
+		m.finishJob(record, storage.TranscriptionCancelled, "")
+		return
+	default:
+	}
+
+	now := time.Now()
+	record.Status = storage.TranscriptionProcessing
+	record.StartedAt = &now
+	if err := m.repo.Update(record); err != nil {
+		logger.Log.Error().Err(err).Str("id", record.ID).Msg("failed to update transcription")
+	}
+	m.emitEvent(events.TranscriptionUpdated, record)
+	logger.Log.Info().Str("traceID", record.ID).Str("phase", "start").Msg("processing transcription")
+
+	opts := whisper.TranscribeOptions{
+		Model:        record.Model,
+		Language:     record.Language,
+		OutputFormat: record.OutputFormat,
+		UseVAD:       record.UseVAD,
+		Task:         record.Task,
+		Speakers:     record.Speakers,
+		Prompt:       record.Prompt,
+		JobID:        record.ID,
+	}
+	if m.glossaries != nil {
+		// Glossaries are read when the job starts so edits made while it
+		// waited in the queue still apply.
+		glossaries, err := m.glossaries.ListFor(storage.GlossaryTargetTranscription, record.ID)
+		if err != nil {
+			m.finishJob(record, storage.TranscriptionFailed, err.Error())
+			return
+		}
+		opts = ApplyGlossaries(opts, glossaries)
+	}
+	result, err := m.transcriber.Transcribe(job.Ctx, record.FilePath, opts)
+	if err != nil {
+		if job.Ctx.Err() != nil {
+			m.finishJob(record, storage.TranscriptionCancelled, "")
+			return
+		}
+		m.finishJob(record, storage.TranscriptionFailed, err.Error())
+		return
+	}
+
+	segments, err := json.Marshal(result.Segments)
+	if err != nil {
+		m.finishJob(record, storage.TranscriptionFailed, err.Error())
+		return
+	}
+	record.Text = result.Text
+	record.Segments = segments
+	record.DetectedLanguage = result.Language
+	record.Duration = result.Duration
+	if record.ExportDir != "" {
+		// The transcript is stored either way, so a failed export can be
+		// retried from the history.
+		if err := exportTranscripts(record, result); err != nil {
+			m.finishJob(record, storage.TranscriptionFailed, err.Error())
+			return
+		}
+	}
+	m.finishJob(record, storage.TranscriptionCompleted, "")
+}
+
+// exportTranscripts writes every export format of a finished transcription
+// into its export directory, named after the media file. Existing files are
+// kept; the new transcript gets a timestamped name instead.
+func exportTranscripts(record *storage.Transcription, result *whisper.TranscribeResult) error {
+	base := strings.TrimSuffix(filepath.Base(record.FilePath), filepath.Ext(record.FilePath))
+	for _, format := range record.ExportFormats {
+		extension, ok := whisper.ExportExtension(format)
+		if !ok {
+			return fmt.Errorf("unsupported transcription output format: %s", format)
+		}
+		outputPath := exportPath(record.ExportDir, base, extension)
+		if err := whisper.WriteTranscript(result.Segments, result.Language, format, outputPath); err != nil {
+			return fmt.Errorf("export %s: %w", filepath.Base(outputPath), err)
+		}
+	}
+	return nil
+}
+
+// exportPath returns dir/base+extension, or a timestamped name when that file
+// already exists.
+func exportPath(dir, base, extension string) string {
+	candidate := filepath.Join(dir, base+extension)
+	if _, err := os.Stat(candidate); os.IsNotExist(err) {
+		return candidate
+	}
+	return filepath.Join(dir, base+"_"+time.Now().Format("20060102_150405")+extension)
+}
+
+func (m *Manager) finishJob(record *storage.Transcription, status storage.TranscriptionStatus, errMsg string) {
+	now := time.Now()
+	record.Status = status
+	record.ErrorMessage = errMsg
+	record.CompletedAt = &now
+	if err := m.repo.Update(record); err != nil {
+		logger.Log.Error().Err(err).Str("id", record.ID).Msg("failed to persist transcription")
+	}
+	m.cleanupJob(record.ID)
+	if m.watches != nil && (status == storage.TranscriptionFailed || status == storage.TranscriptionCancelled) {
+		// Only finished files count as transcribed; the others are retried
+		// by the next batch or watcher scan.
+		if err := m.watches.ForgetTranscribedFile(record.ID); err != nil {
+			logger.Log.Error().Err(err).Str("id", record.ID).Msg("failed to clear transcribed file")
+		}
+	}
+
+	// The event carries the summary only; the frontend reopens the transcript
+	// through Get when it needs the text.
+	summary := *record
+	summary.Text = ""
+	summary.Segments = nil
+	m.emitEvent(events.TranscriptionUpdated, &summary)
+
+	logger.Log.Info().
+		Str("traceID", record.ID).
+		Str("phase", string(status)).
+		Str("error", errMsg).
+		Msg("transcription finished")
+}
+
+// queueJob tracks a pending transcription and hands it to the workers.
+func (m *Manager) queueJob(record *storage.Transcription) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	job := &Job{Transcription: record, Ctx: ctx, Cancel: cancel}
+	m.mu.Lock()
+	m.jobs[record.ID] = job
+	m.pending = append(m.pending, job)
+	m.mu.Unlock()
+	m.signal()
+}
+
+func (m *Manager) cleanupJob(id string) {
+	m.mu.Lock()
+	job := m.jobs[id]
+	delete(m.jobs, id)
+	m.mu.Unlock()
+	if job != nil {
+		job.Cancel()
+	}
+}
+
+// restorePendingJobs requeues jobs that were pending or interrupted mid-run
+// when the app last closed.
+func (m *Manager) restorePendingJobs() {
+	if err := m.repo.RequeueInterrupted(); err != nil {
+		logger.Log.Error().Err(err).Msg("failed to requeue interrupted transcriptions")
+	}
+	pending, err := m.repo.GetPending()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("failed to restore pending transcriptions")
+		return
+	}
+	for _, record := range pending {
+		m.queueJob(record)
+	}
+	if len(pending) > 0 {
+		logger.Log.Info().Int("count", len(pending)).Msg("restored pending transcriptions")
+	}
+}
+
+func decodeSegments(data json.RawMessage) ([]whisper.Segment, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var segments []whisper.Segment
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return nil, fmt.Errorf("stored transcript is corrupt: %w", err)
+	}
+	return segments, nil
+}
+
+// emitEvent tolerates a missing Wails application so the manager works in tests.
+func (m *Manager) emitEvent(eventName string, data any) {
+	if app := application.Get(); app != nil {
+		app.Event.Emit(eventName, data)
+	}
+}
@@ -0,0 +1,201 @@
+package transcription
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kingo/internal/storage"
+	"kingo/internal/whisper"
+)
+
+type fakeTranscriber struct {
+	block   chan struct{}
+	options chan whisper.TranscribeOptions // Receives the options of each run when set
+	err     error                          // Returned by every run when set
+}
+
+func (f *fakeTranscriber) Transcribe(ctx context.Context, filePath string, opts whisper.TranscribeOptions) (*whisper.TranscribeResult, error) {
+	if f.options != nil {
+		f.options <- opts
+	}
+	if f.block != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.block:
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	segments := []whisper.Segment{{Start: 0, End: 2, Text: "Olá"}, {Start: 2, End: 4, Text: "mundo"}}
+	return &whisper.TranscribeResult{Text: "Olá mundo", Segments: segments, Language: "pt", Duration: 4, JobID: opts.JobID}, nil
+}
+
+func testManager(t *testing.T, transcriber Transcriber) (*Manager, string) {
+	t.Helper()
+	db, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	media := filepath.Join(t.TempDir(), "talk.mp3")
+	if err := os.WriteFile(media, []byte("fixture"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(storage.NewTranscriptionRepository(db), transcriber, 1)
+	m.SetGlossaryRepository(storage.NewGlossaryRepository(db))
+	m.SetWatchRepository(storage.NewTranscriptionWatchRepository(db))
+	t.Cleanup(m.Stop)
+	return m, media
+}
+
+func waitForStatus(t *testing.T, m *Manager, id string, status storage.TranscriptionStatus) *storage.Transcription {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		record, err := m.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.Status == status {
+			return record
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("transcription %s never reached %s", id, status)
+	return nil
+}
+
+func TestManagerStoresResultAndReExports(t *testing.T) {
+	m, media := testManager(t, &fakeTranscriber{})
+	m.Start()
+
+	record, err := m.AddJob(media, whisper.TranscribeOptions{Model: "base", OutputFormat: "txt"})
+	if err != nil {
+		t.Fatalf("AddJob() error: %v", err)
+	}
+	done := waitForStatus(t, m, record.ID, storage.TranscriptionCompleted)
+	if done.Text != "Olá mundo" || done.DetectedLanguage != "pt" || done.Duration != 4 || done.Language != "auto" {
+		t.Fatalf("unexpected stored result: %#v", done)
+	}
+
+	output := filepath.Join(t.TempDir(), "talk.srt")
+	if err := m.Export(record.ID, "srt", output); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "1\n00:00:00,000 --> 00:00:02,000\nOlá") {
+		t.Fatalf("unexpected export:\n%s", data)
+	}
+}
+
+func TestManagerCancelsRunningJob(t *testing.T) {
+	transcriber := &fakeTranscriber{block: make(chan struct{})}
+	m, media := testManager(t, transcriber)
+	m.Start()
+
+	record, err := m.AddJob(media, whisper.TranscribeOptions{Model: "base"})
+	if err != nil {
+		t.Fatalf("AddJob() error: %v", err)
+	}
+	waitForStatus(t, m, record.ID, storage.TranscriptionProcessing)
+	if err := m.CancelJob(record.ID); err != nil {
+		t.Fatalf("CancelJob() error: %v", err)
+	}
+	waitForStatus(t, m, record.ID, storage.TranscriptionCancelled)
+	if err := m.Export(record.ID, "txt", filepath.Join(t.TempDir(), "out.txt")); err == nil {
+		t.Fatal("expected export of a cancelled transcription to fail")
+	}
+}
+
+func TestManagerRestoresInterruptedJobs(t *testing.T) {
+	m, media := testManager(t, &fakeTranscriber{})
+	interrupted := &storage.Transcription{FilePath: media, Model: "base", Status: storage.TranscriptionProcessing}
+	if err := m.repo.Create(interrupted); err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	waitForStatus(t, m, interrupted.ID, storage.TranscriptionCompleted)
+}
+
+func TestManagerRestoresLongQueueWithoutBlocking(t *testing.T) {
+	transcriber := &fakeTranscriber{block: make(chan struct{})}
+	m, media := testManager(t, transcriber)
+	var last *storage.Transcription
+	for range 150 {
+		last = &storage.Transcription{FilePath: media, Model: "base"}
+		if err := m.repo.Create(last); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	started := make(chan struct{})
+	go func() {
+		m.Start()
+		close(started)
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() blocked restoring the queue")
+	}
+	close(transcriber.block)
+	waitForStatus(t, m, last.ID, storage.TranscriptionCompleted)
+}
+
+func TestManagerCancelRejectsFinishedJob(t *testing.T) {
+	m, media := testManager(t, &fakeTranscriber{})
+	m.Start()
+
+	record, err := m.AddJob(media, whisper.TranscribeOptions{Model: "base"})
+	if err != nil {
+		t.Fatalf("AddJob() error: %v", err)
+	}
+	waitForStatus(t, m, record.ID, storage.TranscriptionCompleted)
+	if err := m.CancelJob(record.ID); err == nil {
+		t.Fatal("expected cancelling a completed transcription to fail")
+	}
+	waitForStatus(t, m, record.ID, storage.TranscriptionCompleted)
+}
+
+func TestManagerAppliesAttachedGlossaries(t *testing.T) {
+	transcriber := &fakeTranscriber{options: make(chan whisper.TranscribeOptions, 1)}
+	m, media := testManager(t, transcriber)
+	glossary := &storage.Glossary{
+		Name:         "Produto",
+		Terms:        []string{"DownKingo", "yt-dlp"},
+		Replacements: []storage.GlossaryRule{{Find: "down kingo", Replace: "DownKingo"}},
+	}
+	if err := m.glossaries.Create(glossary); err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+
+	record, err := m.AddJob(media, whisper.TranscribeOptions{Model: "base", OutputFormat: "txt", Prompt: "Podcast sobre downloads."}, glossary.ID)
+	if err != nil {
+		t.Fatalf("AddJob() error: %v", err)
+	}
+	opts := <-transcriber.options
+	if opts.Prompt != "Podcast sobre downloads. DownKingo, yt-dlp." {
+		t.Fatalf("prompt = %q", opts.Prompt)
+	}
+	if len(opts.Replacements) != 1 || opts.Replacements[0].Replace != "DownKingo" {
+		t.Fatalf("replacements = %#v", opts.Replacements)
+	}
+	done := waitForStatus(t, m, record.ID, storage.TranscriptionCompleted)
+	if done.Prompt != "Podcast sobre downloads." {
+		t.Fatalf("stored prompt = %q", done.Prompt)
+	}
+
+	if _, err := m.AddJob(media, whisper.TranscribeOptions{Model: "base", OutputFormat: "txt"}, "missing"); err == nil {
+		t.Fatal("expected an unknown glossary to be rejected")
+	}
+}
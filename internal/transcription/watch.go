@@ -0,0 +1,178 @@
+package transcription
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"kingo/internal/logger"
+	"kingo/internal/storage"
+	"kingo/internal/whisper"
+)
+
+// defaultWatchInterval is how often watched folders are scanned. A new file
+// is queued once its size and modification time are unchanged for a whole
+// interval, so recordings still being copied are left alone.
+const defaultWatchInterval = 10 * time.Second
+
+// observedFile is the last seen state of a file that is not yet stable.
+type observedFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// AddWatch starts watching a folder. Media files already in it that were
+// never transcribed are picked up on the first scan.
+func (m *Manager) AddWatch(watch *storage.TranscriptionWatch) error {
+	if m.watches == nil {
+		return fmt.Errorf("watched folders are not available")
+	}
+	if err := validateWatch(watch); err != nil {
+		return err
+	}
+	watch.Enabled = true
+	if err := m.watches.Create(watch); err != nil {
+		return err
+	}
+	logger.Log.Info().Str("path", watch.Path).Msg("watching folder for transcription")
+	m.requestScan()
+	return nil
+}
+
+// UpdateWatch changes the settings of a watched folder or pauses it.
+func (m *Manager) UpdateWatch(watch *storage.TranscriptionWatch) error {
+	if m.watches == nil {
+		return fmt.Errorf("watched folders are not available")
+	}
+	if err := validateWatch(watch); err != nil {
+		return err
+	}
+	if err := m.watches.Update(watch); err != nil {
+		return err
+	}
+	m.requestScan()
+	return nil
+}
+
+// RemoveWatch stops watching a folder. Queued files keep running.
+func (m *Manager) RemoveWatch(id string) error {
+	if m.watches == nil {
+		return fmt.Errorf("watched folders are not available")
+	}
+	return m.watches.Delete(id)
+}
+
+// ListWatches returns every watched folder.
+func (m *Manager) ListWatches() ([]*storage.TranscriptionWatch, error) {
+	if m.watches == nil {
+		return []*storage.TranscriptionWatch{}, nil
+	}
+	return m.watches.List()
+}
+
+func validateWatch(watch *storage.TranscriptionWatch) error {
+	if info, err := os.Stat(watch.Path); err != nil || !info.IsDir() {
+		return fmt.Errorf("folder not found: %s", watch.Path)
+	}
+	formats, err := normalizeFormats(watch.Formats)
+	if err != nil {
+		return err
+	}
+	watch.Formats = formats
+	return nil
+}
+
+// requestScan wakes the watcher without waiting for the next interval.
+func (m *Manager) requestScan() {
+	select {
+	case m.rescan <- struct{}{}:
+	default:
+	}
+}
+
+// watchLoop scans the watched folders until the manager stops. Only this
+// goroutine touches the observed files.
+func (m *Manager) watchLoop() {
+	ticker := time.NewTicker(m.watchInterval)
+	defer ticker.Stop()
+	observed := make(map[string]observedFile)
+	m.scanWatches(observed)
+	for {
+		select {
+		case <-m.quit:
+			return
+		case <-ticker.C:
+		case <-m.rescan:
+		}
+		m.scanWatches(observed)
+	}
+}
+
+// scanWatches queues the stable, untranscribed media files of every enabled
+// watched folder and forgets files that disappeared.
+func (m *Manager) scanWatches(observed map[string]observedFile) {
+	watches, err := m.watches.ListEnabled()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("failed to list watched folders")
+		return
+	}
+	seen := make(map[string]bool)
+	for _, watch := range watches {
+		files, err := findMediaFiles(watch.Path, watch.Recursive, watch.OutputDir)
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("path", watch.Path).Msg("failed to scan watched folder")
+			continue
+		}
+		for _, path := range files {
+			if seen[path] {
+				continue // Nested watched folders see the same file twice
+			}
+			seen[path] = true
+			m.checkWatchedFile(watch, path, observed)
+		}
+	}
+	for path := range observed {
+		if !seen[path] {
+			delete(observed, path)
+		}
+	}
+}
+
+func (m *Manager) checkWatchedFile(watch *storage.TranscriptionWatch, path string, observed map[string]observedFile) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	done, err := m.watches.IsFileTranscribed(path, info.Size(), info.ModTime())
+	if err != nil || done {
+		delete(observed, path)
+		return
+	}
+	current := observedFile{size: info.Size(), modTime: info.ModTime()}
+	if previous, ok := observed[path]; !ok || previous.size != current.size || !previous.modTime.Equal(current.modTime) || current.size == 0 {
+		observed[path] = current
+		return
+	}
+	delete(observed, path)
+	if _, err := m.queueMediaFile(path, info, watch.Path, watchBatchOptions(watch)); err != nil {
+		logger.Log.Error().Err(err).Str("path", path).Msg("failed to queue watched file")
+		return
+	}
+	logger.Log.Info().Str("path", path).Str("watch", watch.Path).Msg("watched file queued for transcription")
+}
+
+func watchBatchOptions(watch *storage.TranscriptionWatch) BatchOptions {
+	return BatchOptions{
+		Options: whisper.TranscribeOptions{
+			Model:    watch.Model,
+			Language: watch.Language,
+			UseVAD:   watch.UseVAD,
+			Task:     watch.Task,
+			Speakers: watch.Speakers,
+			Prompt:   watch.Prompt,
+		},
+		Formats:   watch.Formats,
+		OutputDir: watch.OutputDir,
+		Recursive: watch.Recursive,
+	}
+}
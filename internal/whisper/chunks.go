@@ -0,0 +1,393 @@
+package whisper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"kingo/internal/logger"
+)
+
+const (
+	// chunkThreshold is the audio length, in seconds, above which a
+	// transcription is split into checkpointed chunks.
+	chunkThreshold = 30 * 60
+	// chunkLength is the target length of each chunk.
+	chunkLength = 10 * 60
+	// chunkSearchWindow is how far from the target cut to look for silence.
+	chunkSearchWindow = 30.0
+	// chunkOverlap is the audio shared with each neighbouring chunk, so words
+	// at a cut that is not perfectly silent are heard whole by one of them.
+	chunkOverlap = 5.0
+	// silenceFrame is the analysis frame used to find the quietest cut point.
+	silenceFrame = 0.1
+	// checkpointMaxAge removes checkpoints of jobs that were never resumed.
+	checkpointMaxAge = 7 * 24 * time.Hour
+)
+
+// chunkCheckpoint identifies a transcription for resuming. Any change to the
+// input file or to the options that shape the output starts over.
+type chunkCheckpoint struct {
+	FilePath    string   `json:"filePath"`
+	Size        int64    `json:"size"`
+	ModTimeNano int64    `json:"modTimeNano"`
+	ModelSHA256 string   `json:"modelSha256"`
+	Args        []string `json:"args"`
+}
+
+func (c chunkCheckpoint) key() string {
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+type chunkPlan struct {
+	Cuts []float64 `json:"cuts"` // Chunk boundaries in seconds, from 0 to the duration
+}
+
+// chunkResult is the checkpoint of one finished chunk, already shifted to
+// the timeline of the full recording.
+type chunkResult struct {
+	Language string    `json:"language"`
+	Segments []Segment `json:"segments"`
+	Turns    []int     `json:"turns,omitempty"` // Segments followed by a tinydiarize speaker turn
+}
+
+// transcribeChunked transcribes long audio in chunks cut at the quietest point
+// near every chunkLength. Finished chunks are written to a checkpoint
+// directory, so a job interrupted by a crash or restart resumes from the last
+// completed chunk. The directory is removed once the whole job succeeds.
+func (c *Client) transcribeChunked(ctx context.Context, run whisperRun, wavPath, workDir string, checkpoint chunkCheckpoint) ([]Segment, string, error) {
+	wav, err := openWav(wavPath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer wav.Close()
+	duration := wav.duration()
+
+	root := filepath.Join(c.whisperDir, "checkpoints")
+	pruneCheckpoints(root, checkpointMaxAge)
+	dir := filepath.Join(root, checkpoint.key())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, "", err
+	}
+	cuts, err := loadChunkPlan(dir, wav, duration)
+	if err != nil {
+		return nil, "", err
+	}
+
+	chunks := len(cuts) - 1
+	passes := passesPerRun(run.task)
+	run.progress.setPasses(chunks * passes)
+	var segments []Segment
+	language := ""
+	for index := range chunks {
+		result, resumed := loadChunkResult(dir, index)
+		if resumed {
+			logger.Log.Info().Int("chunk", index+1).Int("chunks", chunks).Msg("whisper chunk restored from checkpoint")
+		} else {
+			start := math.Max(0, cuts[index]-chunkOverlap)
+			end := math.Min(duration, cuts[index+1]+chunkOverlap)
+			chunkPath := filepath.Join(workDir, fmt.Sprintf("chunk-%04d.wav", index))
+			if err := wav.writeRange(chunkPath, start, end); err != nil {
+				return nil, "", fmt.Errorf("could not prepare chunk %d: %w", index+1, err)
+			}
+			run.progress.duration = end - start
+			chunkSegments, detected, err := c.runPasses(ctx, run, chunkPath, workDir, index*passes)
+			_ = os.Remove(chunkPath)
+			if err != nil {
+				return nil, "", fmt.Errorf("chunk %d of %d: %w", index+1, chunks, err)
+			}
+			result = newChunkResult(chunkSegments, detected, start, cuts[index], cuts[index+1], index == chunks-1)
+			if err := saveChunkResult(dir, index, result); err != nil {
+				return nil, "", fmt.Errorf("could not checkpoint chunk %d: %w", index+1, err)
+			}
+		}
+		if language == "" {
+			language = result.Language
+		}
+		segments = appendDeduplicated(segments, result.segments())
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Log.Warn().Err(err).Str("dir", dir).Msg("failed to remove whisper checkpoint")
+	}
+	return segments, language, nil
+}
+
+// newChunkResult moves chunk segments onto the full timeline and keeps those
+// whose midpoint falls inside the chunk's own range; the overlap belongs to
+// the neighbour.
+func newChunkResult(segments []Segment, language string, offset, from, to float64, last bool) chunkResult {
+	result := chunkResult{Language: language, Segments: []Segment{}}
+	for _, segment := range segments {
+		segment.Start += offset
+		segment.End += offset
+		if len(segment.Words) > 0 {
+			words := make([]Word, len(segment.Words))
+			for index, word := range segment.Words {
+				words[index] = Word{Start: word.Start + offset, End: word.End + offset, Text: word.Text}
+			}
+			segment.Words = words
+		}
+		middle := (segment.Start + segment.End) / 2
+		if middle < from || (middle >= to && !last) {
+			continue
+		}
+		if segment.speakerTurnNext {
+			result.Turns = append(result.Turns, len(result.Segments))
+		}
+		result.Segments = append(result.Segments, segment)
+	}
+	return result
+}
+
+func (r chunkResult) segments() []Segment {
+	segments := r.Segments
+	for _, index := range r.Turns {
+		if index >= 0 && index < len(segments) {
+			segments[index].speakerTurnNext = true
+		}
+	}
+	return segments
+}
+
+// appendDeduplicated appends a chunk's segments to the stitched transcript.
+// Where the first incoming segments overlap the last kept one in time, words
+// already transcribed at the end of the previous chunk are dropped.
+func appendDeduplicated(kept, incoming []Segment) []Segment {
+	if len(kept) == 0 {
+		return append(kept, incoming...)
+	}
+	for len(incoming) > 0 {
+		last := kept[len(kept)-1]
+		segment := incoming[0]
+		if segment.Start >= last.End {
+			break
+		}
+		repeated := repeatedWords(last.Text, segment.Text)
+		fields := strings.Fields(segment.Text)
+		if repeated == 0 {
+			break
+		}
+		if repeated == len(fields) {
+			incoming = incoming[1:]
+			continue
+		}
+		segment.Text = strings.Join(fields[repeated:], " ")
+		if len(segment.Words) == len(fields) {
+			segment.Words = segment.Words[repeated:]
+			segment.Start = segment.Words[0].Start
+		} else {
+			segment.Words = nil
+			segment.Start = math.Min(segment.End, last.End)
+		}
+		incoming[0] = segment
+		break
+	}
+	return append(kept, incoming...)
+}
+
+// repeatedWords returns how many leading words of next repeat the trailing
+// words of previous, ignoring case and punctuation. A single shared word only
+// counts when it is the whole segment, since short words repeat by chance.
+func repeatedWords(previous, next string) int {
+	tail := normalizedWords(previous)
+	head := normalizedWords(next)
+	for count := min(len(tail), len(head)); count >= 1; count-- {
+		match := true
+		for index := range count {
+			if tail[len(tail)-count+index] != head[index] {
+				match = false
+				break
+			}
+		}
+		if match && (count >= 2 || count == len(head)) {
+			return count
+		}
+	}
+	return 0
+}
+
+func normalizedWords(text string) []string {
+	fields := strings.Fields(text)
+	for index, field := range fields {
+		fields[index] = strings.ToLower(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, field))
+	}
+	return fields
+}
+
+// planChunkCuts picks chunk boundaries near every chunkLength, moved to the
+// quietest moment within chunkSearchWindow. The last chunk absorbs a short
+// tail rather than leaving a tiny final chunk.
+func planChunkCuts(wav *wavFile, duration float64) ([]float64, error) {
+	cuts := []float64{0}
+	for duration-cuts[len(cuts)-1] > chunkLength*1.5 {
+		target := cuts[len(cuts)-1] + chunkLength
+		cut, err := wav.quietestPoint(target-chunkSearchWindow, target+chunkSearchWindow)
+		if err != nil {
+			return nil, err
+		}
+		cuts = append(cuts, cut)
+	}
+	return append(cuts, duration), nil
+}
+
+// loadChunkPlan reuses the cuts of an interrupted run so restored chunks
+// still line up, or plans and saves new ones.
+func loadChunkPlan(dir string, wav *wavFile, duration float64) ([]float64, error) {
+	path := filepath.Join(dir, "plan.json")
+	var plan chunkPlan
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &plan) == nil && len(plan.Cuts) >= 2 {
+		return plan.Cuts, nil
+	}
+	cuts, err := planChunkCuts(wav, duration)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSONAtomic(path, chunkPlan{Cuts: cuts}); err != nil {
+		return nil, err
+	}
+	return cuts, nil
+}
+
+func chunkResultPath(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("chunk-%04d.json", index))
+}
+
+func loadChunkResult(dir string, index int) (chunkResult, bool) {
+	var result chunkResult
+	data, err := os.ReadFile(chunkResultPath(dir, index))
+	if err != nil || json.Unmarshal(data, &result) != nil {
+		return chunkResult{}, false
+	}
+	return result, true
+}
+
+func saveChunkResult(dir string, index int, result chunkResult) error {
+	return writeJSONAtomic(chunkResultPath(dir, index), result)
+}
+
+// writeJSONAtomic writes through a temporary file so a crash never leaves a
+// half-written checkpoint behind.
+func writeJSONAtomic(path string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checkpoint-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// pruneCheckpoints removes checkpoint directories untouched for maxAge.
+func pruneCheckpoints(root string, maxAge time.Duration) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		_ = os.RemoveAll(filepath.Join(root, entry.Name()))
+	}
+}
+
+// duration is the length of the audio in seconds.
+func (w *wavFile) duration() float64 {
+	return float64(w.dataSize) / float64(w.sampleRate*w.channels*2)
+}
+
+// quietestPoint returns the centre of the quietest three-frame window between
+// from and to, the most likely pause between words.
+func (w *wavFile) quietestPoint(from, to float64) (float64, error) {
+	from = math.Max(0, from)
+	to = math.Min(w.duration(), to)
+	var energies []float64
+	for at := from; at+silenceFrame <= to; at += silenceFrame {
+		samples, err := w.monoSamples(at, at+silenceFrame)
+		if err != nil {
+			return 0, err
+		}
+		var sum float64
+		for _, sample := range samples {
+			sum += sample * sample
+		}
+		energies = append(energies, sum)
+	}
+	if len(energies) < 3 {
+		return (from + to) / 2, nil
+	}
+	best, bestEnergy := 1, math.Inf(1)
+	for index := 1; index < len(energies)-1; index++ {
+		energy := energies[index-1] + energies[index] + energies[index+1]
+		if energy < bestEnergy {
+			best, bestEnergy = index, energy
+		}
+	}
+	return from + (float64(best)+0.5)*silenceFrame, nil
+}
+
+// writeRange writes the audio between start and end seconds as a new WAV.
+func (w *wavFile) writeRange(path string, start, end float64) error {
+	frameBytes := int64(w.channels * 2)
+	from := min(w.dataSize, int64(start*float64(w.sampleRate))*frameBytes)
+	to := min(w.dataSize, int64(end*float64(w.sampleRate))*frameBytes)
+	if to <= from {
+		return fmt.Errorf("empty audio range %.1f-%.1f", start, end)
+	}
+	header := make([]byte, 0, 44)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(36+to-from))
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, 1)
+	header = binary.LittleEndian.AppendUint16(header, uint16(w.channels))
+	header = binary.LittleEndian.AppendUint32(header, uint32(w.sampleRate))
+	header = binary.LittleEndian.AppendUint32(header, uint32(int64(w.sampleRate)*frameBytes))
+	header = binary.LittleEndian.AppendUint16(header, uint16(frameBytes))
+	header = binary.LittleEndian.AppendUint16(header, 16)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(to-from))
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(header); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := io.Copy(file, io.NewSectionReader(w.file, w.dataOffset+from, to-from)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,112 @@
+package whisper
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuietestPointFindsPause(t *testing.T) {
+	path := writeTestWav(t, 1, 6, func(_ int, at float64) float64 {
+		if at >= 3.0 && at < 3.4 {
+			return 0
+		}
+		return 0.5 * math.Sin(2*math.Pi*220*at)
+	})
+	wav, err := openWav(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wav.Close()
+	cut, err := wav.quietestPoint(1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cut < 3.0 || cut > 3.4 {
+		t.Fatalf("cut = %v, want inside the pause", cut)
+	}
+}
+
+func TestWriteRangeCopiesAudio(t *testing.T) {
+	path := writeTestWav(t, 1, 4, func(_ int, at float64) float64 { return at / 10 })
+	wav, err := openWav(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wav.Close()
+	output := filepath.Join(t.TempDir(), "chunk.wav")
+	if err := wav.writeRange(output, 1, 2.5); err != nil {
+		t.Fatal(err)
+	}
+	chunk, err := openWav(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chunk.Close()
+	if got := chunk.duration(); math.Abs(got-1.5) > 0.001 {
+		t.Fatalf("chunk duration = %v", got)
+	}
+	samples, err := chunk.monoSamples(0, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(samples[0]-0.1) > 0.001 {
+		t.Fatalf("chunk starts with %v, want audio from 1s", samples[0])
+	}
+}
+
+func TestNewChunkResultShiftsAndKeepsOwnRange(t *testing.T) {
+	segments := []Segment{
+		{Start: 0, End: 4, Text: "overlap"},
+		{Start: 6, End: 8, Text: "own", Words: []Word{{Start: 6, End: 8, Text: "own"}}, speakerTurnNext: true},
+		{Start: 610, End: 614, Text: "next"},
+	}
+	result := newChunkResult(segments, "pt", 595, 600, 1200, false)
+	if len(result.Segments) != 1 || result.Segments[0].Start != 601 || result.Segments[0].Words[0].End != 603 {
+		t.Fatalf("unexpected chunk segments: %#v", result.Segments)
+	}
+	restored := result.segments()
+	if !restored[0].speakerTurnNext {
+		t.Fatalf("speaker turn was lost: %#v", result)
+	}
+}
+
+func TestAppendDeduplicatedTrimsRepeatedWords(t *testing.T) {
+	kept := []Segment{{Start: 590, End: 600.5, Text: "and that is why we left"}}
+	incoming := []Segment{
+		{Start: 600, End: 603, Text: "we left. The next morning"},
+		{Start: 603, End: 605, Text: "it rained"},
+	}
+	got := appendDeduplicated(kept, incoming)
+	if len(got) != 3 || got[1].Text != "The next morning" || got[1].Start != 600.5 {
+		t.Fatalf("overlap was not trimmed: %#v", got)
+	}
+
+	kept = []Segment{{Start: 590, End: 600.5, Text: "we left"}}
+	got = appendDeduplicated(kept, []Segment{{Start: 599, End: 600.5, Text: "We left."}, {Start: 601, End: 602, Text: "Then"}})
+	if len(got) != 2 || got[1].Text != "Then" {
+		t.Fatalf("duplicated segment was kept: %#v", got)
+	}
+}
+
+func TestChunkCheckpointResumes(t *testing.T) {
+	checkpoint := chunkCheckpoint{FilePath: "talk.mp3", Size: 10, ModTimeNano: 1, Args: []string{"-l", "pt"}}
+	changed := checkpoint
+	changed.Size = 11
+	if checkpoint.key() == changed.key() {
+		t.Fatal("a changed input must not reuse the checkpoint")
+	}
+
+	dir := t.TempDir()
+	if _, ok := loadChunkResult(dir, 0); ok {
+		t.Fatal("missing checkpoint was restored")
+	}
+	want := chunkResult{Language: "pt", Segments: []Segment{{Start: 1, End: 2, Text: "oi"}}}
+	if err := saveChunkResult(dir, 0, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := loadChunkResult(dir, 0)
+	if !ok || got.Language != "pt" || len(got.Segments) != 1 || got.Segments[0].Text != "oi" {
+		t.Fatalf("restored checkpoint = %#v", got)
+	}
+}
@@ -37,6 +37,25 @@ type TranscribeResult struct {
 	Segments []Segment `json:"segments"`
 	Language string    `json:"language"`
 	Duration float64   `json:"duration"`
+	Task     string    `json:"task,omitempty"` // transcribe, translate or bilingual
+	JobID    string    `json:"jobId,omitempty"`
+}
+
+// TranscribeOptions configures a single transcription run.
+type TranscribeOptions struct {
+	Model        string
+	Language     string // Source language, "auto" to detect
+	OutputFormat string // Any export format: txt, srt, vtt, json, tsv, csv, lrc, md, docx, docx-timestamped
+	UseVAD       bool
+	Task         string // transcribe (default), translate or bilingual
+	JobID        string // Scopes progress events; generated when empty
+	Speakers     string // Speaker detection: "" (off), auto, tinydiarize, stereo or energy
+
+	// Prompt is passed to whisper.cpp as the initial prompt. Use BuildPrompt
+	// to add glossary terms.
+	Prompt string
+	// Replacements run over the segments once whisper is done.
+	Replacements []Replacement
 }
 
 // Segment represents a timestamped segment of transcription.
@@ -44,13 +63,33 @@ type Segment struct {
 	Start float64 `json:"start"`
 	End   float64 `json:"end"`
 	Text  string  `json:"text"`
+	Words []Word  `json:"words,omitempty"` // Word-level timings, when whisper provides them
+
+	// Translation holds the English text paired with this segment by the
+	// bilingual task.
+	Translation string `json:"translation,omitempty"`
+
+	// Speaker labels the voice of this segment ("Speaker 1", "Speaker 2", ...)
+	// when speaker detection was requested.
+	Speaker string `json:"speaker,omitempty"`
+
+	speakerTurnNext bool // tinydiarize marked a speaker change after this segment
+}
+
+// Word is a single word of a segment with its own timestamps.
+type Word struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
 }
 
 // ModelInfo holds metadata about an installed Whisper model.
 type ModelInfo struct {
-	Name string `json:"name"`
-	Size int64  `json:"size"`
-	Path string `json:"path"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Path     string `json:"path"`
+	Imported bool   `json:"imported"`         // Supplied by the user through ImportModel
+	SHA256   string `json:"sha256,omitempty"` // Recorded at import time
 }
 
 // AvailableModel describes a model from the supported, checksummed catalog.
@@ -103,19 +142,53 @@ func (c *Client) EnsureDirectories() error {
 }
 
 func buildPlainText(segments []Segment) string {
+	if hasSpeakers(segments) {
+		return buildSpeakerText(segments)
+	}
 	parts := make([]string, 0, len(segments))
+	separator := " "
 	for _, segment := range segments {
-		if text := strings.TrimSpace(segment.Text); text != "" {
+		if segment.Translation != "" {
+			// Bilingual text reads as alternating original/English paragraphs.
+			separator = "\n\n"
+		}
+		if text := strings.TrimSpace(segmentText(segment)); text != "" {
 			parts = append(parts, text)
 		}
 	}
-	return strings.Join(parts, " ")
+	return strings.Join(parts, separator)
+}
+
+// buildSpeakerText writes one paragraph per speaker turn, like a script.
+func buildSpeakerText(segments []Segment) string {
+	var paragraphs []string
+	var current []string
+	speaker := ""
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, speaker+": "+strings.Join(current, " "))
+			current = nil
+		}
+	}
+	for _, segment := range segments {
+		text := strings.TrimSpace(segmentText(segment))
+		if text == "" {
+			continue
+		}
+		if segment.Speaker != speaker || segment.Translation != "" {
+			flush()
+			speaker = segment.Speaker
+		}
+		current = append(current, text)
+	}
+	flush()
+	return strings.Join(paragraphs, "\n\n")
 }
 
 func formatSRT(segments []Segment) string {
 	var sb strings.Builder
 	for i, segment := range segments {
-		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, formatSRTTime(segment.Start), formatSRTTime(segment.End), segment.Text)
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, formatSRTTime(segment.Start), formatSRTTime(segment.End), speakerText(segment))
 	}
 	return strings.TrimSpace(sb.String())
 }
@@ -124,7 +197,12 @@ func formatVTT(segments []Segment) string {
 	var sb strings.Builder
 	sb.WriteString("WEBVTT\n\n")
 	for _, segment := range segments {
-		fmt.Fprintf(&sb, "%s --> %s\n%s\n\n", formatVTTTime(segment.Start), formatVTTTime(segment.End), segment.Text)
+		text := segmentText(segment)
+		if segment.Speaker != "" {
+			// WebVTT voice spans let players style each speaker.
+			text = "<v " + segment.Speaker + ">" + text
+		}
+		fmt.Fprintf(&sb, "%s --> %s\n%s\n\n", formatVTTTime(segment.Start), formatVTTTime(segment.End), text)
 	}
 	return strings.TrimSpace(sb.String())
 }
@@ -33,6 +33,16 @@ const docxDocRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
 
 // GenerateDOCX creates a minimal .docx file from plain text.
 func GenerateDOCX(text string, savePath string) error {
+	return writeDOCX(buildDocumentXML(text), savePath)
+}
+
+// GenerateTimestampedDOCX creates a .docx with one paragraph per group of
+// segments, split on pauses, each opened by a bold timestamp run.
+func GenerateTimestampedDOCX(segments []Segment, savePath string) error {
+	return writeDOCX(buildTimestampedDocumentXML(segments), savePath)
+}
+
+func writeDOCX(documentXML string, savePath string) error {
 	f, err := os.Create(savePath)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
@@ -48,7 +58,7 @@ func GenerateDOCX(text string, savePath string) error {
 		{"[Content_Types].xml", docxContentTypes},
 		{"_rels/.rels", docxRels},
 		{"word/_rels/document.xml.rels", docxDocRels},
-		{"word/document.xml", buildDocumentXML(text)},
+		{"word/document.xml", documentXML},
 	}
 
 	for _, p := range parts {
@@ -70,12 +80,16 @@ func writeDocxPart(zw *zip.Writer, name, content string) error {
 	return err
 }
 
+const docxDocumentHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body>
+`
+
+const docxRunFont = `<w:rFonts w:ascii="Calibri" w:hAnsi="Calibri"/><w:sz w:val="22"/>`
+
 func buildDocumentXML(text string) string {
 	var sb strings.Builder
-	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
-	sb.WriteString("\n")
-	sb.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
-	sb.WriteString("\n<w:body>\n")
+	sb.WriteString(docxDocumentHeader)
 
 	lines := strings.Split(text, "\n")
 	for _, line := range lines {
@@ -84,10 +98,37 @@ func buildDocumentXML(text string) string {
 		if escaped == "" {
 			sb.WriteString("  <w:p/>\n")
 		} else {
-			fmt.Fprintf(&sb, "  <w:p><w:r><w:rPr><w:rFonts w:ascii=\"Calibri\" w:hAnsi=\"Calibri\"/><w:sz w:val=\"22\"/></w:rPr><w:t xml:space=\"preserve\">%s</w:t></w:r></w:p>\n", escaped)
+			fmt.Fprintf(&sb, "  <w:p><w:r><w:rPr>%s</w:rPr><w:t xml:space=\"preserve\">%s</w:t></w:r></w:p>\n", docxRunFont, escaped)
 		}
 	}
 
 	sb.WriteString("</w:body>\n</w:document>")
 	return sb.String()
 }
+
+func buildTimestampedDocumentXML(segments []Segment) string {
+	var sb strings.Builder
+	sb.WriteString(docxDocumentHeader)
+	for _, paragraph := range groupParagraphs(segments) {
+		fmt.Fprintf(&sb, "  <w:p><w:r><w:rPr>%s<w:b/><w:color w:val=\"808080\"/></w:rPr><w:t xml:space=\"preserve\">%s </w:t></w:r>",
+			docxRunFont, xmlEscaper.Replace(paragraphHeading(paragraph)))
+		for index, segment := range paragraph {
+			text := segmentText(segment)
+			if index > 0 {
+				text = " " + text
+			}
+			// Bilingual segments keep their translation on a line break
+			// inside the same paragraph.
+			lines := strings.Split(text, "\n")
+			for lineIndex, line := range lines {
+				if lineIndex > 0 {
+					sb.WriteString("<w:r><w:br/></w:r>")
+				}
+				fmt.Fprintf(&sb, "<w:r><w:rPr>%s</w:rPr><w:t xml:space=\"preserve\">%s</w:t></w:r>", docxRunFont, xmlEscaper.Replace(line))
+			}
+		}
+		sb.WriteString("</w:p>\n")
+	}
+	sb.WriteString("</w:body>\n</w:document>")
+	return sb.String()
+}
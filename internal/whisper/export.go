@@ -0,0 +1,281 @@
+package whisper
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Export formats. FormatDOCX keeps the original plain-paragraph document;
+// FormatDOCXTimestamped groups segments into paragraphs with timestamps.
+const (
+	FormatTXT             = "txt"
+	FormatSRT             = "srt"
+	FormatVTT             = "vtt"
+	FormatJSON            = "json"
+	FormatTSV             = "tsv"
+	FormatCSV             = "csv"
+	FormatLRC             = "lrc"
+	FormatMarkdown        = "md"
+	FormatDOCX            = "docx"
+	FormatDOCXTimestamped = "docx-timestamped"
+)
+
+// exportExtensions maps every export format to its file extension.
+var exportExtensions = map[string]string{
+	FormatTXT:             ".txt",
+	FormatSRT:             ".srt",
+	FormatVTT:             ".vtt",
+	FormatJSON:            ".json",
+	FormatTSV:             ".tsv",
+	FormatCSV:             ".csv",
+	FormatLRC:             ".lrc",
+	FormatMarkdown:        ".md",
+	FormatDOCX:            ".docx",
+	FormatDOCXTimestamped: ".docx",
+}
+
+const (
+	// paragraphPause starts a new paragraph when the speaker pauses this long.
+	paragraphPause = 2.0
+	// paragraphMaxChars keeps paragraphs readable when nobody pauses.
+	paragraphMaxChars = 700
+)
+
+// NormalizeExportFormat lower-cases a format and accepts "markdown" for "md".
+func NormalizeExportFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	switch format {
+	case "":
+		return FormatTXT
+	case "markdown":
+		return FormatMarkdown
+	}
+	return format
+}
+
+// ExportExtension returns the file extension for a format.
+func ExportExtension(format string) (string, bool) {
+	extension, ok := exportExtensions[NormalizeExportFormat(format)]
+	return extension, ok
+}
+
+// FormatTranscript renders segments in a text export format. Both DOCX
+// formats return plain text here; WriteTranscript builds the document.
+func FormatTranscript(segments []Segment, language, format string) (string, error) {
+	switch NormalizeExportFormat(format) {
+	case FormatSRT:
+		return formatSRT(segments), nil
+	case FormatVTT:
+		return formatVTT(segments), nil
+	case FormatTXT, FormatDOCX, FormatDOCXTimestamped:
+		return buildPlainText(segments), nil
+	case FormatJSON:
+		return formatJSON(segments, language)
+	case FormatTSV:
+		return formatTSV(segments), nil
+	case FormatCSV:
+		return formatCSV(segments)
+	case FormatLRC:
+		return formatLRC(segments), nil
+	case FormatMarkdown:
+		return formatMarkdown(segments), nil
+	default:
+		return "", fmt.Errorf("unsupported transcription output format: %s", format)
+	}
+}
+
+// WriteTranscript renders segments and writes them to outputPath.
+func WriteTranscript(segments []Segment, language, format, outputPath string) error {
+	format = NormalizeExportFormat(format)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	if format == FormatDOCXTimestamped {
+		return GenerateTimestampedDOCX(segments, outputPath)
+	}
+	text, err := FormatTranscript(segments, language, format)
+	if err != nil {
+		return err
+	}
+	if format == FormatDOCX {
+		return GenerateDOCX(text, outputPath)
+	}
+	return os.WriteFile(outputPath, []byte(text), 0644)
+}
+
+type jsonTranscript struct {
+	Language string    `json:"language,omitempty"`
+	Duration float64   `json:"duration"`
+	Segments []Segment `json:"segments"`
+}
+
+func formatJSON(segments []Segment, language string) (string, error) {
+	transcript := jsonTranscript{Language: language, Segments: segments}
+	if transcript.Segments == nil {
+		transcript.Segments = []Segment{}
+	}
+	if len(segments) > 0 {
+		transcript.Duration = segments[len(segments)-1].End
+	}
+	data, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// tableRows returns the header and rows shared by the TSV and CSV exports.
+// The speaker and translation columns only appear when the transcript has them.
+func tableRows(segments []Segment) [][]string {
+	bilingual := false
+	for _, segment := range segments {
+		if segment.Translation != "" {
+			bilingual = true
+			break
+		}
+	}
+	speakers := hasSpeakers(segments)
+	header := []string{"start", "end"}
+	if speakers {
+		header = append(header, "speaker")
+	}
+	header = append(header, "text")
+	if bilingual {
+		header = append(header, "translation")
+	}
+	rows := [][]string{header}
+	for _, segment := range segments {
+		row := []string{formatClockMillis(segment.Start), formatClockMillis(segment.End)}
+		if speakers {
+			row = append(row, segment.Speaker)
+		}
+		row = append(row, segment.Text)
+		if bilingual {
+			row = append(row, segment.Translation)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func formatTSV(segments []Segment) string {
+	// Tabs and line breaks inside a cell would shift the columns.
+	cleaner := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+	var sb strings.Builder
+	for _, row := range tableRows(segments) {
+		for index, cell := range row {
+			row[index] = cleaner.Replace(cell)
+		}
+		sb.WriteString(strings.Join(row, "\t"))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func formatCSV(segments []Segment) (string, error) {
+	var buffer bytes.Buffer
+	// The BOM makes Excel open the file as UTF-8.
+	buffer.WriteString("\ufeff")
+	writer := csv.NewWriter(&buffer)
+	if err := writer.WriteAll(tableRows(segments)); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// formatLRC writes one "[mm:ss.xx]" line per segment, as music players expect.
+func formatLRC(segments []Segment) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		centis := int64(segment.Start*100 + 0.5)
+		text := strings.ReplaceAll(speakerText(segment), "\n", " / ")
+		fmt.Fprintf(&sb, "[%02d:%02d.%02d]%s\n", centis/6000, (centis%6000)/100, centis%100, text)
+	}
+	if len(segments) > 0 {
+		// A blank line at the end clears the last lyric when the audio ends.
+		centis := int64(segments[len(segments)-1].End*100 + 0.5)
+		fmt.Fprintf(&sb, "[%02d:%02d.%02d]\n", centis/6000, (centis%6000)/100, centis%100)
+	}
+	return sb.String()
+}
+
+func formatMarkdown(segments []Segment) string {
+	var sb strings.Builder
+	for index, paragraph := range groupParagraphs(segments) {
+		if index > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "### %s\n\n", paragraphHeading(paragraph))
+		parts := make([]string, 0, len(paragraph))
+		translations := make([]string, 0, len(paragraph))
+		for _, segment := range paragraph {
+			parts = append(parts, strings.TrimSpace(segment.Text))
+			if segment.Translation != "" {
+				translations = append(translations, strings.TrimSpace(segment.Translation))
+			}
+		}
+		sb.WriteString(strings.Join(parts, " "))
+		sb.WriteString("\n")
+		if len(translations) > 0 {
+			fmt.Fprintf(&sb, "\n> %s\n", strings.Join(translations, " "))
+		}
+	}
+	return sb.String()
+}
+
+// paragraphHeading is the "[mm:ss]" label of a paragraph, followed by the
+// speaker when the transcript has one.
+func paragraphHeading(paragraph []Segment) string {
+	heading := "[" + formatClockTime(paragraph[0].Start) + "]"
+	if speaker := paragraph[0].Speaker; speaker != "" {
+		heading += " " + speaker + ":"
+	}
+	return heading
+}
+
+// groupParagraphs splits segments into paragraphs at speaker changes, at
+// pauses of at least paragraphPause seconds, or once a paragraph grows past
+// paragraphMaxChars.
+func groupParagraphs(segments []Segment) [][]Segment {
+	var paragraphs [][]Segment
+	var current []Segment
+	length := 0
+	for _, segment := range segments {
+		if strings.TrimSpace(segment.Text) == "" {
+			continue
+		}
+		if len(current) > 0 {
+			gap := segment.Start - current[len(current)-1].End
+			speakerChanged := segment.Speaker != current[len(current)-1].Speaker
+			if speakerChanged || gap >= paragraphPause || length >= paragraphMaxChars {
+				paragraphs = append(paragraphs, current)
+				current, length = nil, 0
+			}
+		}
+		current = append(current, segment)
+		length += len(segment.Text)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, current)
+	}
+	return paragraphs
+}
+
+// formatClockTime renders seconds as H:MM:SS, or MM:SS under an hour.
+func formatClockTime(seconds float64) string {
+	total := int64(max(0, seconds))
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, (total%3600)/60, total%60)
+	}
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
+
+// formatClockMillis renders seconds as HH:MM:SS.mmm for spreadsheets.
+func formatClockMillis(seconds float64) string {
+	return formatVTTTime(max(0, seconds))
+}
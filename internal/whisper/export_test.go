@@ -0,0 +1,82 @@
+package whisper
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var exportFixture = []Segment{
+	{Start: 0, End: 1.5, Text: "Olá,\tpessoal", Words: []Word{{Start: 0, End: 0.5, Text: "Olá,"}, {Start: 0.6, End: 1.5, Text: "pessoal"}}},
+	{Start: 1.6, End: 3, Text: `Ele disse "oi"`},
+	{Start: 65.25, End: 67, Text: "Depois da pausa"},
+}
+
+func TestFormatTranscriptStructuredFormats(t *testing.T) {
+	jsonText, err := FormatTranscript(exportFixture, "pt", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded jsonTranscript
+	if err := json.Unmarshal([]byte(jsonText), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Language != "pt" || decoded.Duration != 67 || len(decoded.Segments[0].Words) != 2 {
+		t.Fatalf("unexpected JSON export: %#v", decoded)
+	}
+
+	tsv, _ := FormatTranscript(exportFixture, "", "tsv")
+	if lines := strings.Split(tsv, "\n"); lines[0] != "start\tend\ttext" || lines[1] != "00:00:00.000\t00:00:01.500\tOlá, pessoal" {
+		t.Fatalf("unexpected TSV:\n%s", tsv)
+	}
+
+	csvText, _ := FormatTranscript(exportFixture, "", "CSV")
+	if !strings.HasPrefix(csvText, "\ufeffstart,end,text\n") || !strings.Contains(csvText, `"Ele disse ""oi"""`) {
+		t.Fatalf("unexpected CSV:\n%s", csvText)
+	}
+
+	lrc, _ := FormatTranscript(exportFixture, "", "lrc")
+	if !strings.Contains(lrc, "[01:05.25]Depois da pausa\n") || !strings.HasSuffix(lrc, "[01:07.00]\n") {
+		t.Fatalf("unexpected LRC:\n%s", lrc)
+	}
+
+	markdown, _ := FormatTranscript(exportFixture, "", "markdown")
+	if !strings.HasPrefix(markdown, "### [00:00]\n\nOlá,\tpessoal Ele disse \"oi\"\n\n### [01:05]\n\nDepois da pausa\n") {
+		t.Fatalf("unexpected Markdown:\n%s", markdown)
+	}
+
+	if _, err := FormatTranscript(exportFixture, "", "pdf"); err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+}
+
+func TestWriteTranscriptTimestampedDOCX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.docx")
+	if err := WriteTranscript(exportFixture, "pt", FormatDOCXTimestamped, path); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	var document string
+	for _, file := range archive.File {
+		if file.Name == "word/document.xml" {
+			reader, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(reader)
+			reader.Close()
+			document = string(data)
+		}
+	}
+	if strings.Count(document, "<w:p>") != 2 || !strings.Contains(document, "[01:05] </w:t>") ||
+		!strings.Contains(document, "Ele disse &quot;oi&quot;") {
+		t.Fatalf("unexpected document:\n%s", document)
+	}
+}
@@ -0,0 +1,179 @@
+package whisper
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxPromptChars keeps the initial prompt inside the ~224 tokens whisper.cpp
+// conditions on; anything past that would be silently dropped from the front.
+const maxPromptChars = 800
+
+// Replacement is a find/replace rule applied to segments after transcription.
+type Replacement struct {
+	Find      string `json:"find"`
+	Replace   string `json:"replace"`
+	MatchCase bool   `json:"matchCase"`
+	WholeWord bool   `json:"wholeWord"`
+}
+
+// BuildPrompt combines a free-text prompt with glossary terms. Whisper imitates
+// the spelling it sees in the prompt, so listing product names and jargon is
+// enough to bias the transcript towards them. Terms that do not fit are left
+// out rather than cut in half.
+func BuildPrompt(prompt string, terms []string) string {
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	if len(prompt) > maxPromptChars {
+		prompt = truncateRunes(prompt, maxPromptChars)
+	}
+	var list strings.Builder
+	for _, term := range terms {
+		term = strings.Join(strings.Fields(term), " ")
+		if term == "" || strings.Contains(prompt, term) {
+			continue
+		}
+		addition := term
+		if list.Len() > 0 {
+			addition = ", " + term
+		}
+		if len(prompt)+list.Len()+len(addition)+2 > maxPromptChars {
+			break
+		}
+		list.WriteString(addition)
+	}
+	if list.Len() == 0 {
+		return prompt
+	}
+	if prompt == "" {
+		return list.String() + "."
+	}
+	return prompt + " " + list.String() + "."
+}
+
+func truncateRunes(text string, limit int) string {
+	for len(text) > limit {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = text[:len(text)-size]
+	}
+	return text
+}
+
+// ApplyReplacements returns a copy of segments with every rule applied, in
+// order, to the text and translation. Single-word rules also rewrite the
+// matching word timings so karaoke captions stay in sync with the text.
+func ApplyReplacements(segments []Segment, rules []Replacement) []Segment {
+	compiled := compileReplacements(rules)
+	if len(compiled) == 0 {
+		return segments
+	}
+	result := make([]Segment, len(segments))
+	for index, segment := range segments {
+		for _, rule := range compiled {
+			segment.Text = rule.apply(segment.Text)
+			segment.Translation = rule.apply(segment.Translation)
+		}
+		if len(segment.Words) > 0 {
+			words := make([]Word, 0, len(segment.Words))
+			for _, word := range segment.Words {
+				for _, rule := range compiled {
+					if rule.singleWord {
+						word.Text = rule.apply(word.Text)
+					}
+				}
+				words = append(words, splitWord(word)...)
+			}
+			segment.Words = words
+		}
+		result[index] = segment
+	}
+	return result
+}
+
+// splitWord breaks a word that a replacement turned into several, such as
+// "gpt" into "G P T", into one word per token with interpolated timings.
+func splitWord(word Word) []Word {
+	tokens := strings.Fields(word.Text)
+	if len(tokens) <= 1 {
+		return []Word{word}
+	}
+	step := (word.End - word.Start) / float64(len(tokens))
+	words := make([]Word, len(tokens))
+	for index, token := range tokens {
+		words[index] = Word{Start: word.Start + step*float64(index), End: word.Start + step*float64(index+1), Text: token}
+	}
+	words[len(words)-1].End = word.End
+	return words
+}
+
+type compiledReplacement struct {
+	pattern    *regexp.Regexp
+	replace    string
+	wholeWord  bool
+	singleWord bool
+}
+
+func compileReplacements(rules []Replacement) []compiledReplacement {
+	compiled := make([]compiledReplacement, 0, len(rules))
+	for _, rule := range rules {
+		find := strings.TrimSpace(rule.Find)
+		if find == "" {
+			continue
+		}
+		expression := regexp.QuoteMeta(find)
+		if !rule.MatchCase {
+			expression = "(?i)" + expression
+		}
+		compiled = append(compiled, compiledReplacement{
+			pattern:    regexp.MustCompile(expression),
+			replace:    rule.Replace,
+			wholeWord:  rule.WholeWord,
+			singleWord: !strings.ContainsFunc(find, unicode.IsSpace),
+		})
+	}
+	return compiled
+}
+
+// apply replaces matches literally. Whole-word rules check the neighbouring
+// runes themselves because regexp's \b only knows ASCII letters and would
+// split words such as "ação".
+func (r compiledReplacement) apply(text string) string {
+	if text == "" {
+		return text
+	}
+	matches := r.pattern.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, match := range matches {
+		if r.wholeWord && !isWordBoundary(text, match[0], match[1]) {
+			continue
+		}
+		sb.WriteString(text[last:match[0]])
+		sb.WriteString(r.replace)
+		last = match[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+func isWordBoundary(text string, start, end int) bool {
+	if start > 0 {
+		if before, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(before) {
+			return false
+		}
+	}
+	if end < len(text) {
+		if after, _ := utf8.DecodeRuneInString(text[end:]); isWordRune(after) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
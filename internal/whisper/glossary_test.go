@@ -0,0 +1,63 @@
+package whisper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyReplacements(t *testing.T) {
+	segments := []Segment{{
+		Text:        "O down kingo usa o Yt DLP; não confunda com kingos.",
+		Translation: "Down Kingo uses yt dlp.",
+		Words:       []Word{{Text: "Kingo,"}, {Text: "ação"}},
+	}}
+	got := ApplyReplacements(segments, []Replacement{
+		{Find: "down kingo", Replace: "DownKingo"},
+		{Find: "yt dlp", Replace: "yt-dlp"},
+		{Find: "kingo", Replace: "Kingo", WholeWord: true, MatchCase: true},
+		{Find: "ação", Replace: "AÇÃO", WholeWord: true},
+	})
+	if got[0].Text != "O DownKingo usa o yt-dlp; não confunda com kingos." {
+		t.Fatalf("text = %q", got[0].Text)
+	}
+	if got[0].Translation != "DownKingo uses yt-dlp." {
+		t.Fatalf("translation = %q", got[0].Translation)
+	}
+	if got[0].Words[0].Text != "Kingo," || got[0].Words[1].Text != "AÇÃO" {
+		t.Fatalf("words = %#v", got[0].Words)
+	}
+	if segments[0].Words[1].Text != "ação" {
+		t.Fatal("ApplyReplacements modified its input")
+	}
+}
+
+func TestApplyReplacementsSplitsMultiWordReplacement(t *testing.T) {
+	segments := []Segment{{
+		Text:  "ask gpt now",
+		Words: []Word{{Start: 0, End: 0.5, Text: "ask"}, {Start: 0.5, End: 1.1, Text: "gpt"}, {Start: 1.1, End: 1.5, Text: "now"}},
+	}}
+	got := ApplyReplacements(segments, []Replacement{{Find: "gpt", Replace: "G P T", WholeWord: true}})
+	if got[0].Text != "ask G P T now" {
+		t.Fatalf("text = %q", got[0].Text)
+	}
+	words := got[0].Words
+	if len(words) != 5 || words[1].Text != "G" || words[3].Text != "T" {
+		t.Fatalf("words = %#v", words)
+	}
+	if words[1].Start != 0.5 || words[3].End != 1.1 || words[2].Start != words[1].End {
+		t.Fatalf("timings = %#v", words)
+	}
+}
+
+func TestBuildPromptSkipsTermsThatDoNotFit(t *testing.T) {
+	if got := BuildPrompt("", []string{"DownKingo", " yt-dlp "}); got != "DownKingo, yt-dlp." {
+		t.Fatalf("prompt = %q", got)
+	}
+	if got := BuildPrompt("Fala sobre DownKingo.", []string{"DownKingo", "FFmpeg"}); got != "Fala sobre DownKingo. FFmpeg." {
+		t.Fatalf("prompt = %q", got)
+	}
+	long := BuildPrompt(strings.Repeat("a", maxPromptChars-10), []string{"curto", "muito-mais-longo"})
+	if len(long) > maxPromptChars || !strings.HasSuffix(long, " curto.") {
+		t.Fatalf("prompt was not capped: %d %q", len(long), long[len(long)-20:])
+	}
+}
@@ -0,0 +1,244 @@
+package whisper
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"kingo/internal/logger"
+)
+
+// importedModelsDir holds user-supplied models under the models directory.
+const importedModelsDir = "imported"
+
+// ggmlMagic is the little-endian uint32 whisper.cpp writes at the start of
+// ggml model files.
+const ggmlMagic = 0x67676d6c
+
+var importedModelNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// importedModel is the manifest stored next to an imported model. The
+// SHA-256 recorded at import time is what every later use is checked against.
+type importedModel struct {
+	Name       string    `json:"name"`
+	FileName   string    `json:"fileName"`
+	Format     string    `json:"format"` // ggml or gguf
+	Bytes      int64     `json:"bytes"`
+	SHA256     string    `json:"sha256"`
+	Source     string    `json:"source"`
+	ImportedAt time.Time `json:"importedAt"`
+}
+
+func (m importedModel) spec() modelSpec {
+	return modelSpec{
+		Name:        m.Name,
+		FileName:    filepath.Join(importedModelsDir, m.FileName),
+		Bytes:       m.Bytes,
+		Description: "Imported " + m.Format + " model",
+		SHA256:      m.SHA256,
+	}
+}
+
+func importedManifestPath(modelsDir, name string) string {
+	return filepath.Join(modelsDir, importedModelsDir, name+".json")
+}
+
+// ImportModel copies a user-supplied ggml or gguf model into the models
+// directory under name, recording its SHA-256 so it is verified before each
+// use like the catalog models.
+func (c *Client) ImportModel(path, name string) (*ModelInfo, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		name = strings.TrimPrefix(name, "ggml-")
+	}
+	if !importedModelNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid model name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	if _, ok := findModelSpec(name); ok {
+		return nil, fmt.Errorf("model name %s is reserved for the built-in catalog", name)
+	}
+	if _, err := c.findImportedModel(name); err == nil {
+		return nil, fmt.Errorf("an imported model named %s already exists", name)
+	}
+	format, err := detectModelFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.EnsureDirectories(); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(c.modelsDir, importedModelsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	source, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+	tmp, err := os.CreateTemp(dir, ".import-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmp.Name()
+	ok := false
+	defer func() {
+		_ = tmp.Close()
+		if !ok {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), source)
+	if err != nil {
+		return nil, fmt.Errorf("copy model: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	manifest := importedModel{
+		Name:       name,
+		FileName:   "ggml-" + name + ".bin",
+		Format:     format,
+		Bytes:      size,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		Source:     path,
+		ImportedAt: time.Now(),
+	}
+	destination := filepath.Join(dir, manifest.FileName)
+	if err := os.Rename(tmpPath, destination); err != nil {
+		return nil, err
+	}
+	ok = true
+	if err := writeModelVerification(destination, manifest.spec()); err != nil {
+		_ = os.Remove(destination)
+		return nil, fmt.Errorf("could not persist model verification: %w", err)
+	}
+	payload, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(importedManifestPath(c.modelsDir, name), payload, 0644)
+	}
+	if err != nil {
+		_ = os.Remove(destination)
+		_ = os.Remove(modelVerificationPath(destination))
+		return nil, fmt.Errorf("could not record imported model: %w", err)
+	}
+	logger.Log.Info().Str("model", name).Str("format", format).Str("sha256", manifest.SHA256).Msg("whisper model imported")
+	return &ModelInfo{Name: name, Size: size, Path: destination, Imported: true, SHA256: manifest.SHA256}, nil
+}
+
+// detectModelFormat checks the file header. ggml files also carry the model
+// hyperparameters right after the magic, so those are sanity checked to
+// reject other ggml payloads such as LLaMA weights.
+func detectModelFormat(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	header := make([]byte, 48)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("model file is empty or unreadable: %w", err)
+	}
+	header = header[:n]
+	if len(header) >= 8 && string(header[:4]) == "GGUF" {
+		version := binary.LittleEndian.Uint32(header[4:8])
+		if version < 1 || version > 3 {
+			return "", fmt.Errorf("unsupported GGUF version %d", version)
+		}
+		return "gguf", nil
+	}
+	if len(header) >= 48 && binary.LittleEndian.Uint32(header[:4]) == ggmlMagic {
+		// n_vocab, n_audio_ctx, n_audio_state, n_audio_head, n_audio_layer,
+		// n_text_ctx, n_text_state, n_text_head, n_text_layer, n_mels, ftype
+		hparams := make([]int32, 11)
+		for index := range hparams {
+			hparams[index] = int32(binary.LittleEndian.Uint32(header[4+index*4:]))
+		}
+		nVocab, nAudioLayer, nMels := hparams[0], hparams[4], hparams[9]
+		if nVocab < 1 || nVocab > 1_000_000 || nAudioLayer < 1 || nAudioLayer > 128 || (nMels != 80 && nMels != 128) {
+			return "", fmt.Errorf("ggml file does not contain a whisper model")
+		}
+		return "ggml", nil
+	}
+	return "", fmt.Errorf("not a ggml or gguf model file")
+}
+
+// findImportedModel reads the manifest of an imported model.
+func (c *Client) findImportedModel(name string) (importedModel, error) {
+	if !importedModelNameRegex.MatchString(name) {
+		return importedModel{}, fmt.Errorf("invalid model name: %s", name)
+	}
+	data, err := os.ReadFile(importedManifestPath(c.modelsDir, name))
+	if err != nil {
+		return importedModel{}, err
+	}
+	var manifest importedModel
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return importedModel{}, fmt.Errorf("imported model record is corrupt: %w", err)
+	}
+	if manifest.Name != name || filepath.Base(manifest.FileName) != manifest.FileName || manifest.SHA256 == "" {
+		return importedModel{}, fmt.Errorf("imported model record is invalid: %s", name)
+	}
+	return manifest, nil
+}
+
+// importedModels lists every imported model manifest by name.
+func (c *Client) importedModels() []importedModel {
+	matches, _ := filepath.Glob(filepath.Join(c.modelsDir, importedModelsDir, "*.json"))
+	models := make([]importedModel, 0, len(matches))
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), ".json")
+		manifest, err := c.findImportedModel(name)
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("model", name).Msg("skipping imported whisper model")
+			continue
+		}
+		models = append(models, manifest)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models
+}
+
+// resolveModelSpec finds a catalog model or, failing that, an imported one.
+func (c *Client) resolveModelSpec(name string) (modelSpec, bool) {
+	if spec, ok := findModelSpec(name); ok {
+		return spec, true
+	}
+	manifest, err := c.findImportedModel(name)
+	if err != nil {
+		return modelSpec{}, false
+	}
+	return manifest.spec(), true
+}
+
+func (c *Client) deleteImportedModel(name string) error {
+	manifest, err := c.findImportedModel(name)
+	if err != nil {
+		return fmt.Errorf("unsupported whisper model: %s", name)
+	}
+	modelPath := filepath.Join(c.modelsDir, manifest.spec().FileName)
+	if err := os.Remove(modelPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = os.Remove(modelVerificationPath(modelPath))
+	if err := os.Remove(importedManifestPath(c.modelsDir, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	logger.Log.Info().Str("model", name).Msg("imported whisper model deleted")
+	return nil
+}
@@ -0,0 +1,96 @@
+package whisper
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeGGMLModel writes a whisper ggml header (tiny hyperparameters) followed
+// by filler bytes.
+func fakeGGMLModel(t *testing.T, nMels int32) string {
+	t.Helper()
+	data := binary.LittleEndian.AppendUint32(nil, ggmlMagic)
+	for _, value := range []int32{51865, 1500, 384, 6, 4, 448, 384, 6, 4, nMels, 1} {
+		data = binary.LittleEndian.AppendUint32(data, uint32(value))
+	}
+	data = append(data, make([]byte, 256)...)
+	path := filepath.Join(t.TempDir(), "ggml-tiny-finetuned.bin")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportModelRecordsAndVerifiesChecksum(t *testing.T) {
+	client := NewClient(t.TempDir(), "")
+	source := fakeGGMLModel(t, 80)
+
+	model, err := client.ImportModel(source, "")
+	if err != nil {
+		t.Fatalf("ImportModel() error: %v", err)
+	}
+	if model.Name != "tiny-finetuned" || !model.Imported || len(model.SHA256) != 64 {
+		t.Fatalf("unexpected imported model: %#v", model)
+	}
+	if _, err := client.ImportModel(source, "tiny-finetuned"); err == nil {
+		t.Fatal("expected a duplicate name to be rejected")
+	}
+	if _, err := client.ImportModel(source, "base"); err == nil {
+		t.Fatal("expected catalog names to be reserved")
+	}
+
+	models, err := client.ListModels()
+	if err != nil || len(models) != 1 || models[0].Name != "tiny-finetuned" {
+		t.Fatalf("ListModels() = %#v, %v", models, err)
+	}
+	spec, ok := client.resolveModelSpec("tiny-finetuned")
+	if !ok {
+		t.Fatal("imported model was not resolved")
+	}
+	modelPath := filepath.Join(client.modelsDir, spec.FileName)
+	if err := verifyInstalledModel(modelPath, spec); err != nil {
+		t.Fatalf("verifyInstalledModel() error: %v", err)
+	}
+
+	// Same size, different content: the recorded checksum must catch it.
+	data, _ := os.ReadFile(modelPath)
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(modelPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(modelPath, later, later)
+	if err := verifyInstalledModel(modelPath, spec); err == nil {
+		t.Fatal("expected a tampered model to fail verification")
+	}
+
+	if err := client.DeleteModel("tiny-finetuned"); err != nil {
+		t.Fatalf("DeleteModel() error: %v", err)
+	}
+	if _, ok := client.resolveModelSpec("tiny-finetuned"); ok {
+		t.Fatal("deleted model is still resolvable")
+	}
+}
+
+func TestDetectModelFormat(t *testing.T) {
+	if format, err := detectModelFormat(fakeGGMLModel(t, 128)); err != nil || format != "ggml" {
+		t.Fatalf("ggml = %q, %v", format, err)
+	}
+	if _, err := detectModelFormat(fakeGGMLModel(t, 4096)); err == nil {
+		t.Fatal("expected non-whisper ggml hyperparameters to be rejected")
+	}
+	dir := t.TempDir()
+	gguf := filepath.Join(dir, "model.gguf")
+	_ = os.WriteFile(gguf, append([]byte("GGUF\x03\x00\x00\x00"), make([]byte, 64)...), 0600)
+	if format, err := detectModelFormat(gguf); err != nil || format != "gguf" {
+		t.Fatalf("gguf = %q, %v", format, err)
+	}
+	other := filepath.Join(dir, "notes.bin")
+	_ = os.WriteFile(other, []byte("definitely not a model"), 0600)
+	if _, err := detectModelFormat(other); err == nil {
+		t.Fatal("expected an unknown header to be rejected")
+	}
+}
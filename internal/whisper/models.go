@@ -73,6 +73,14 @@ func (c *Client) ListModels() ([]ModelInfo, error) {
 		}
 		models = append(models, ModelInfo{Name: spec.Name, Size: info.Size(), Path: path})
 	}
+	for _, manifest := range c.importedModels() {
+		path := filepath.Join(c.modelsDir, manifest.spec().FileName)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || info.Size() != manifest.Bytes {
+			continue
+		}
+		models = append(models, ModelInfo{Name: manifest.Name, Size: info.Size(), Path: path, Imported: true, SHA256: manifest.SHA256})
+	}
 	return models, nil
 }
 
@@ -95,7 +103,7 @@ func (c *Client) DownloadModel(modelName string) error {
 func (c *Client) DeleteModel(modelName string) error {
 	spec, ok := findModelSpec(modelName)
 	if !ok {
-		return fmt.Errorf("unsupported whisper model: %s", modelName)
+		return c.deleteImportedModel(modelName)
 	}
 	if err := os.Remove(filepath.Join(c.modelsDir, spec.FileName)); err != nil && !os.IsNotExist(err) {
 		return err
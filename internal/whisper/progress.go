@@ -0,0 +1,188 @@
+package whisper
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// progressInterval throttles progress events so a fast model does not flood
+// the event bus with one event per segment.
+const progressInterval = 250 * time.Millisecond
+
+// wavBytesPerSecond is the data rate of the normalized 16 kHz mono s16 audio.
+const wavBytesPerSecond = 16000 * 2
+
+var (
+	// whisper.cpp prints "whisper_print_progress_callback: progress =  45%"
+	// on stderr when run with -pp.
+	whisperProgressRegex = regexp.MustCompile(`progress\s*=\s*(\d{1,3})%`)
+	// Segment lines on stdout look like "[00:01:02.340 --> 00:01:05.000]  text".
+	whisperSegmentRegex = regexp.MustCompile(`^\[(\d+):(\d{2}):(\d{2})[.,](\d{3})\s*-->\s*(\d+):(\d{2}):(\d{2})[.,](\d{3})\]`)
+)
+
+// TranscribeProgress is the payload of whisper:transcribe-progress.
+type TranscribeProgress struct {
+	JobID          string  `json:"jobId"`
+	Status         string  `json:"status"`
+	File           string  `json:"file,omitempty"`
+	Percent        float64 `json:"percent"`
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+	ETASeconds     float64 `json:"etaSeconds"` // -1 while unknown
+}
+
+// progressTracker turns whisper output into overall job progress. A
+// bilingual job runs two passes, and chunked audio runs them once per chunk;
+// each pass is worth an equal share of the total.
+type progressTracker struct {
+	mu       sync.Mutex
+	jobID    string
+	file     string
+	status   string
+	duration float64
+	passes   int
+	pass     int
+	percent  float64
+	started  time.Time
+	lastEmit time.Time
+	emit     func(TranscribeProgress)
+	now      func() time.Time
+}
+
+func newProgressTracker(jobID, file string, passes int, emit func(TranscribeProgress)) *progressTracker {
+	if passes < 1 {
+		passes = 1
+	}
+	return &progressTracker{
+		jobID:   jobID,
+		file:    file,
+		passes:  passes,
+		emit:    emit,
+		now:     time.Now,
+		started: time.Now(),
+	}
+}
+
+// setStatus emits a status change immediately, without throttling.
+func (p *progressTracker) setStatus(status string) {
+	p.mu.Lock()
+	p.status = status
+	event := p.snapshotLocked()
+	p.lastEmit = p.now()
+	p.mu.Unlock()
+	p.emit(event)
+}
+
+// setPasses changes the number of passes once it is known, e.g. after long
+// audio has been split into chunks.
+func (p *progressTracker) setPasses(passes int) {
+	p.mu.Lock()
+	p.passes = max(1, passes)
+	p.mu.Unlock()
+}
+
+// startPass moves to the given zero-based whisper pass.
+func (p *progressTracker) startPass(pass int, status string) {
+	p.mu.Lock()
+	p.pass = min(pass, p.passes-1)
+	p.percent = math.Max(p.percent, float64(p.pass)*100/float64(p.passes))
+	p.mu.Unlock()
+	p.setStatus(status)
+}
+
+// passPercent records progress (0-100) inside the current pass. Progress
+// never moves backwards, so a late segment line cannot undo a newer report.
+func (p *progressTracker) passPercent(value float64) {
+	value = math.Min(100, math.Max(0, value))
+	p.mu.Lock()
+	overall := (float64(p.pass)*100 + value) / float64(p.passes)
+	if overall <= p.percent {
+		p.mu.Unlock()
+		return
+	}
+	p.percent = overall
+	now := p.now()
+	if now.Sub(p.lastEmit) < progressInterval && overall < 100 {
+		p.mu.Unlock()
+		return
+	}
+	p.lastEmit = now
+	event := p.snapshotLocked()
+	p.mu.Unlock()
+	p.emit(event)
+}
+
+// handleLine parses one line of whisper output.
+func (p *progressTracker) handleLine(line string) {
+	if value, ok := parseProgressLine(line); ok {
+		p.passPercent(value)
+		return
+	}
+	if p.duration > 0 {
+		if end, ok := parseSegmentEnd(line); ok {
+			p.passPercent(end / p.duration * 100)
+		}
+	}
+}
+
+func (p *progressTracker) finish() {
+	p.mu.Lock()
+	p.percent = 100
+	p.mu.Unlock()
+	p.setStatus("complete")
+}
+
+func (p *progressTracker) snapshotLocked() TranscribeProgress {
+	elapsed := p.now().Sub(p.started).Seconds()
+	eta := -1.0
+	if p.percent >= 100 {
+		eta = 0
+	} else if p.percent >= 1 {
+		eta = elapsed * (100 - p.percent) / p.percent
+	}
+	return TranscribeProgress{
+		JobID:          p.jobID,
+		Status:         p.status,
+		File:           p.file,
+		Percent:        math.Round(p.percent*10) / 10,
+		ElapsedSeconds: math.Round(elapsed*10) / 10,
+		ETASeconds:     math.Round(eta*10) / 10,
+	}
+}
+
+func parseProgressLine(line string) (float64, bool) {
+	match := whisperProgressRegex.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return float64(value), true
+}
+
+// parseSegmentEnd returns the end timestamp of a whisper segment line.
+func parseSegmentEnd(line string) (float64, bool) {
+	match := whisperSegmentRegex.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	hours, _ := strconv.Atoi(match[5])
+	minutes, _ := strconv.Atoi(match[6])
+	seconds, _ := strconv.Atoi(match[7])
+	millis, _ := strconv.Atoi(match[8])
+	return float64(hours*3600+minutes*60+seconds) + float64(millis)/1000, true
+}
+
+// wavDuration estimates the length of the normalized audio from its size.
+func wavDuration(path string) float64 {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() <= 44 {
+		return 0
+	}
+	return float64(info.Size()-44) / wavBytesPerSecond
+}
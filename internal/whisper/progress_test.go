@@ -0,0 +1,55 @@
+package whisper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWhisperProgressOutput(t *testing.T) {
+	if value, ok := parseProgressLine("whisper_print_progress_callback: progress =  45%"); !ok || value != 45 {
+		t.Fatalf("progress = %v, %v", value, ok)
+	}
+	if end, ok := parseSegmentEnd("[00:01:02.340 --> 00:01:05.250]   Olá mundo"); !ok || end != 65.25 {
+		t.Fatalf("segment end = %v, %v", end, ok)
+	}
+	if _, ok := parseSegmentEnd("whisper_full: processing"); ok {
+		t.Fatal("non-segment line was parsed")
+	}
+}
+
+func TestProgressTrackerReportsPercentAndETAPerJob(t *testing.T) {
+	var events []TranscribeProgress
+	clock := time.Unix(0, 0)
+	tracker := newProgressTracker("job-1", "talk.mp3", 2, func(event TranscribeProgress) {
+		events = append(events, event)
+	})
+	tracker.now = func() time.Time { return clock }
+	tracker.started = clock
+	tracker.duration = 100
+
+	tracker.startPass(0, "processing")
+	clock = clock.Add(10 * time.Second)
+	tracker.handleLine("[00:00:00.000 --> 00:00:50.000]  first half")
+	got := events[len(events)-1]
+	if got.JobID != "job-1" || got.Percent != 25 || got.ElapsedSeconds != 10 || got.ETASeconds != 30 {
+		t.Fatalf("unexpected progress: %#v", got)
+	}
+
+	// Throttled updates and regressions are dropped.
+	count := len(events)
+	tracker.handleLine("progress = 10%")
+	clock = clock.Add(100 * time.Millisecond)
+	tracker.handleLine("progress = 60%")
+	if len(events) != count {
+		t.Fatalf("expected throttled events, got %#v", events[count:])
+	}
+
+	tracker.startPass(1, "translating")
+	if got := events[len(events)-1]; got.Status != "translating" || got.Percent != 50 {
+		t.Fatalf("second pass should start at 50%%: %#v", got)
+	}
+	tracker.finish()
+	if got := events[len(events)-1]; got.Status != "complete" || got.Percent != 100 || got.ETASeconds != 0 {
+		t.Fatalf("unexpected final event: %#v", got)
+	}
+}
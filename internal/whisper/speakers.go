@@ -0,0 +1,409 @@
+package whisper
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Speaker detection modes accepted by TranscribeOptions.Speakers.
+const (
+	SpeakersOff         = ""
+	SpeakersAuto        = "auto"
+	SpeakersTinydiarize = "tinydiarize"
+	SpeakersStereo      = "stereo"
+	SpeakersEnergy      = "energy"
+)
+
+const (
+	// stereoDominance is how much louder one channel must be to claim a segment.
+	stereoDominance = 1.1
+	// energyMinSeparation is the centroid distance, in standard deviations,
+	// below which the energy fallback decides there is only one speaker.
+	energyMinSeparation = 1.0
+	// energyMinSegment skips segments too short to measure reliably.
+	energyMinSegment = 0.3
+)
+
+// normalizeSpeakers validates the speaker mode against the selected model.
+// tinydiarize needs a model fine-tuned with speaker-turn tokens (*-tdrz).
+func normalizeSpeakers(mode, modelName string) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case SpeakersOff, "off", "none":
+		return SpeakersOff, nil
+	case SpeakersAuto, SpeakersStereo, SpeakersEnergy:
+		return mode, nil
+	case SpeakersTinydiarize:
+		if !isTinydiarizeModel(modelName) {
+			return "", fmt.Errorf("tinydiarize needs a speaker-turn model (for example small.en-tdrz); %s is not one", modelName)
+		}
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported speaker mode: %s", mode)
+	}
+}
+
+func isTinydiarizeModel(modelName string) bool {
+	return strings.Contains(strings.ToLower(modelName), "tdrz")
+}
+
+// speakerLabel is the display name of a zero-based speaker index.
+func speakerLabel(index int) string {
+	return fmt.Sprintf("Speaker %d", index+1)
+}
+
+// assignTurnSpeakers labels segments from tinydiarize's speaker-turn markers.
+// tinydiarize only marks where the speaker changes, so turns alternate
+// between two speakers; that matches the interview case it was trained for.
+func assignTurnSpeakers(segments []Segment) {
+	speaker := 0
+	for index := range segments {
+		segments[index].Speaker = speakerLabel(speaker)
+		if segments[index].speakerTurnNext {
+			speaker = 1 - speaker
+		}
+	}
+}
+
+// detectSpeakers fills Segment.Speaker using the stereo or energy analysis.
+// In auto mode the stereo split wins when the channels actually differ.
+func (c *Client) detectSpeakers(ctx context.Context, mode, inputPath, monoWav string, segments []Segment) error {
+	if mode == SpeakersStereo || mode == SpeakersAuto {
+		stereoPath := filepath.Join(filepath.Dir(monoWav), "stereo.wav")
+		if err := c.convertAudio(ctx, inputPath, stereoPath, 2); err != nil {
+			return err
+		}
+		wav, err := openWav(stereoPath)
+		if err != nil {
+			return err
+		}
+		assigned, err := stereoSpeakers(wav, segments)
+		wav.Close()
+		if err != nil {
+			return err
+		}
+		if assigned || mode == SpeakersStereo {
+			return nil
+		}
+	}
+	wav, err := openWav(monoWav)
+	if err != nil {
+		return err
+	}
+	defer wav.Close()
+	return energySpeakers(wav, segments)
+}
+
+// stereoSpeakers gives each segment to the louder channel, the layout of
+// interviews recorded with one microphone per side. Undecided segments keep
+// the previous speaker. It reports false when no segment favours a channel.
+func stereoSpeakers(wav *wavFile, segments []Segment) (bool, error) {
+	if wav.channels != 2 {
+		return false, nil
+	}
+	channelSpeaker := map[int]int{}
+	current := -1
+	decided := false
+	for index := range segments {
+		energy, err := wav.channelEnergy(segments[index].Start, segments[index].End)
+		if err != nil {
+			return false, err
+		}
+		channel := -1
+		if energy[0] > energy[1]*stereoDominance {
+			channel = 0
+		} else if energy[1] > energy[0]*stereoDominance {
+			channel = 1
+		}
+		if channel >= 0 {
+			if _, ok := channelSpeaker[channel]; !ok {
+				channelSpeaker[channel] = len(channelSpeaker)
+			}
+			current = channelSpeaker[channel]
+			decided = true
+		}
+		if current >= 0 {
+			segments[index].Speaker = speakerLabel(current)
+		}
+	}
+	if !decided {
+		return false, nil
+	}
+	// Segments before the first decided one belong to its speaker.
+	for index := range segments {
+		if segments[index].Speaker != "" {
+			break
+		}
+		segments[index].Speaker = speakerLabel(0)
+	}
+	return true, nil
+}
+
+// energySpeakers is the local fallback for mono audio. Each segment is
+// described by its loudness and zero-crossing rate (a rough proxy for voice
+// pitch) and the segments are split into two clusters. When the clusters are
+// not clearly apart everything is attributed to a single speaker.
+func energySpeakers(wav *wavFile, segments []Segment) error {
+	features := make([][2]float64, len(segments))
+	measured := make([]bool, len(segments))
+	for index, segment := range segments {
+		if segment.End-segment.Start < energyMinSegment {
+			continue
+		}
+		samples, err := wav.monoSamples(segment.Start, segment.End)
+		if err != nil {
+			return err
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		features[index] = voiceFeatures(samples)
+		measured[index] = true
+	}
+
+	labels := clusterSpeakers(features, measured)
+	current := 0
+	for index := range segments {
+		if measured[index] {
+			current = labels[index]
+		}
+		segments[index].Speaker = speakerLabel(current)
+	}
+	return nil
+}
+
+// voiceFeatures returns the log RMS energy and zero-crossing rate of samples.
+func voiceFeatures(samples []float64) [2]float64 {
+	var sum float64
+	crossings := 0
+	for index, sample := range samples {
+		sum += sample * sample
+		if index > 0 && (sample >= 0) != (samples[index-1] >= 0) {
+			crossings++
+		}
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+	return [2]float64{math.Log10(rms + 1e-6), float64(crossings) / float64(len(samples))}
+}
+
+// clusterSpeakers runs a two-centroid k-means over z-scored features and
+// returns speaker indices numbered by first appearance.
+func clusterSpeakers(features [][2]float64, measured []bool) []int {
+	labels := make([]int, len(features))
+	var points []int
+	for index, ok := range measured {
+		if ok {
+			points = append(points, index)
+		}
+	}
+	if len(points) < 2 {
+		return labels
+	}
+
+	// Standardise both dimensions so loudness does not dominate.
+	var mean, deviation [2]float64
+	for _, index := range points {
+		for d := range 2 {
+			mean[d] += features[index][d]
+		}
+	}
+	for d := range 2 {
+		mean[d] /= float64(len(points))
+	}
+	for _, index := range points {
+		for d := range 2 {
+			deviation[d] += math.Pow(features[index][d]-mean[d], 2)
+		}
+	}
+	normalized := make([][2]float64, len(features))
+	for d := range 2 {
+		deviation[d] = math.Sqrt(deviation[d] / float64(len(points)))
+		if deviation[d] == 0 {
+			deviation[d] = 1
+		}
+	}
+	for _, index := range points {
+		for d := range 2 {
+			normalized[index][d] = (features[index][d] - mean[d]) / deviation[d]
+		}
+	}
+
+	// Seed with the lowest and highest zero-crossing rates.
+	low, high := points[0], points[0]
+	for _, index := range points {
+		if normalized[index][1] < normalized[low][1] {
+			low = index
+		}
+		if normalized[index][1] > normalized[high][1] {
+			high = index
+		}
+	}
+	centroids := [2][2]float64{normalized[low], normalized[high]}
+	assignment := make([]int, len(features))
+	for range 20 {
+		var sums [2][2]float64
+		var counts [2]int
+		for _, index := range points {
+			cluster := 0
+			if featureDistance(normalized[index], centroids[1]) < featureDistance(normalized[index], centroids[0]) {
+				cluster = 1
+			}
+			assignment[index] = cluster
+			counts[cluster]++
+			for d := range 2 {
+				sums[cluster][d] += normalized[index][d]
+			}
+		}
+		for cluster := range 2 {
+			if counts[cluster] == 0 {
+				return labels // One cluster emptied out: a single speaker.
+			}
+			for d := range 2 {
+				centroids[cluster][d] = sums[cluster][d] / float64(counts[cluster])
+			}
+		}
+	}
+	if featureDistance(centroids[0], centroids[1]) < energyMinSeparation {
+		return labels
+	}
+
+	// Number speakers in order of appearance.
+	first := assignment[points[0]]
+	for _, index := range points {
+		if assignment[index] == first {
+			labels[index] = 0
+		} else {
+			labels[index] = 1
+		}
+	}
+	return labels
+}
+
+func featureDistance(a, b [2]float64) float64 {
+	return math.Hypot(a[0]-b[0], a[1]-b[1])
+}
+
+// wavFile reads 16-bit PCM ranges from a WAV file without loading it whole.
+type wavFile struct {
+	file       *os.File
+	channels   int
+	sampleRate int
+	dataOffset int64
+	dataSize   int64
+}
+
+// openWav parses the RIFF chunks written by ffmpeg. ffmpeg may add a LIST
+// chunk before the samples, so the data chunk is searched for.
+func openWav(path string) (*wavFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		file.Close()
+		return nil, errors.New("not a WAV file")
+	}
+	wav := &wavFile{file: file}
+	offset := int64(12)
+	chunk := make([]byte, 8)
+	for {
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			file.Close()
+			return nil, errors.New("WAV file has no data chunk")
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			format := make([]byte, 16)
+			if _, err := file.ReadAt(format, offset+8); err != nil {
+				file.Close()
+				return nil, err
+			}
+			if binary.LittleEndian.Uint16(format[0:2]) != 1 || binary.LittleEndian.Uint16(format[14:16]) != 16 {
+				file.Close()
+				return nil, errors.New("WAV file is not 16-bit PCM")
+			}
+			wav.channels = int(binary.LittleEndian.Uint16(format[2:4]))
+			wav.sampleRate = int(binary.LittleEndian.Uint32(format[4:8]))
+		case "data":
+			if wav.channels == 0 || wav.sampleRate == 0 {
+				file.Close()
+				return nil, errors.New("WAV data chunk precedes its format")
+			}
+			wav.dataOffset = offset + 8
+			wav.dataSize = size
+			return wav, nil
+		}
+		offset += 8 + size + size%2
+	}
+}
+
+func (w *wavFile) Close() error {
+	return w.file.Close()
+}
+
+// frames reads the interleaved samples between start and end seconds.
+func (w *wavFile) frames(start, end float64) ([]int16, error) {
+	frameBytes := int64(w.channels * 2)
+	from := min(w.dataSize, int64(max(0, start)*float64(w.sampleRate))*frameBytes)
+	to := min(w.dataSize, int64(max(0, end)*float64(w.sampleRate))*frameBytes)
+	if to <= from {
+		return nil, nil
+	}
+	buffer := make([]byte, to-from)
+	if _, err := w.file.ReadAt(buffer, w.dataOffset+from); err != nil && err != io.EOF {
+		return nil, err
+	}
+	samples := make([]int16, len(buffer)/2)
+	for index := range samples {
+		samples[index] = int16(binary.LittleEndian.Uint16(buffer[index*2:]))
+	}
+	return samples, nil
+}
+
+// monoSamples returns the range downmixed to mono in [-1, 1].
+func (w *wavFile) monoSamples(start, end float64) ([]float64, error) {
+	raw, err := w.frames(start, end)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]float64, len(raw)/w.channels)
+	for index := range samples {
+		var sum float64
+		for channel := range w.channels {
+			sum += float64(raw[index*w.channels+channel])
+		}
+		samples[index] = sum / float64(w.channels) / 32768
+	}
+	return samples, nil
+}
+
+// channelEnergy returns the mean square of the first two channels.
+func (w *wavFile) channelEnergy(start, end float64) ([2]float64, error) {
+	var energy [2]float64
+	raw, err := w.frames(start, end)
+	if err != nil || w.channels < 2 {
+		return energy, err
+	}
+	frames := len(raw) / w.channels
+	if frames == 0 {
+		return energy, nil
+	}
+	for index := range frames {
+		for channel := range 2 {
+			value := float64(raw[index*w.channels+channel]) / 32768
+			energy[channel] += value * value
+		}
+	}
+	energy[0] /= float64(frames)
+	energy[1] /= float64(frames)
+	return energy, nil
+}
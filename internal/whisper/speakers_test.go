@@ -0,0 +1,190 @@
+package whisper
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestWav writes 16 kHz 16-bit PCM with a LIST chunk before the data,
+// the layout ffmpeg produces. sample returns the value of each channel at t.
+func writeTestWav(t *testing.T, channels int, seconds float64, sample func(channel int, t float64) float64) string {
+	t.Helper()
+	const rate = 16000
+	frames := int(seconds * rate)
+	data := make([]byte, 0, frames*channels*2)
+	for frame := range frames {
+		for channel := range channels {
+			value := int16(math.Max(-1, math.Min(1, sample(channel, float64(frame)/rate))) * 32767)
+			data = binary.LittleEndian.AppendUint16(data, uint16(value))
+		}
+	}
+	list := []byte("LIST\x04\x00\x00\x00INFO")
+	format := make([]byte, 0, 24)
+	format = append(format, "fmt "...)
+	format = binary.LittleEndian.AppendUint32(format, 16)
+	format = binary.LittleEndian.AppendUint16(format, 1)
+	format = binary.LittleEndian.AppendUint16(format, uint16(channels))
+	format = binary.LittleEndian.AppendUint32(format, rate)
+	format = binary.LittleEndian.AppendUint32(format, uint32(rate*channels*2))
+	format = binary.LittleEndian.AppendUint16(format, uint16(channels*2))
+	format = binary.LittleEndian.AppendUint16(format, 16)
+
+	var file []byte
+	file = append(file, "RIFF"...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(4+len(format)+len(list)+8+len(data)))
+	file = append(file, "WAVE"...)
+	file = append(file, format...)
+	file = append(file, list...)
+	file = append(file, "data"...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(data)))
+	file = append(file, data...)
+
+	path := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(path, file, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func tone(frequency, amplitude, at float64) float64 {
+	return amplitude * math.Sin(2*math.Pi*frequency*at)
+}
+
+func TestAssignTurnSpeakersAlternatesOnTurnMarkers(t *testing.T) {
+	segments, _, err := parseWhisperSegments([]byte(`{"result":{"language":"en"},"transcription":[
+		{"offsets":{"from":0,"to":1000},"text":"Hi there.","speaker_turn_next":true},
+		{"offsets":{"from":1000,"to":2000},"text":"Hello."},
+		{"offsets":{"from":2000,"to":3000},"text":"How are you?","speaker_turn_next":true},
+		{"offsets":{"from":3000,"to":4000},"text":"Fine."}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignTurnSpeakers(segments)
+	got := []string{segments[0].Speaker, segments[1].Speaker, segments[2].Speaker, segments[3].Speaker}
+	if strings.Join(got, ",") != "Speaker 1,Speaker 2,Speaker 2,Speaker 1" {
+		t.Fatalf("speakers = %v", got)
+	}
+}
+
+func TestNormalizeSpeakersRequiresTinydiarizeModel(t *testing.T) {
+	if _, err := normalizeSpeakers("tinydiarize", "small.en"); err == nil {
+		t.Fatal("expected tinydiarize to require a tdrz model")
+	}
+	if mode, err := normalizeSpeakers(" Stereo ", "base"); err != nil || mode != SpeakersStereo {
+		t.Fatalf("mode = %q, err = %v", mode, err)
+	}
+	if _, err := normalizeSpeakers("pyannote", "base"); err == nil {
+		t.Fatal("expected an unsupported mode error")
+	}
+}
+
+func TestStereoSpeakersFollowLouderChannel(t *testing.T) {
+	// Left talks during the first and last second, right in between.
+	path := writeTestWav(t, 2, 3, func(channel int, at float64) float64 {
+		leftActive := at < 1 || at >= 2
+		if (channel == 0) == leftActive {
+			return tone(220, 0.5, at)
+		}
+		return tone(220, 0.02, at)
+	})
+	wav, err := openWav(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wav.Close()
+	segments := []Segment{{Start: 0, End: 1}, {Start: 1, End: 2}, {Start: 2, End: 3}}
+	assigned, err := stereoSpeakers(wav, segments)
+	if err != nil || !assigned {
+		t.Fatalf("assigned = %v, err = %v", assigned, err)
+	}
+	if segments[0].Speaker != "Speaker 1" || segments[1].Speaker != "Speaker 2" || segments[2].Speaker != "Speaker 1" {
+		t.Fatalf("unexpected speakers: %#v", segments)
+	}
+}
+
+func TestStereoSpeakersRejectIdenticalChannels(t *testing.T) {
+	path := writeTestWav(t, 2, 2, func(_ int, at float64) float64 { return tone(220, 0.5, at) })
+	wav, err := openWav(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wav.Close()
+	assigned, err := stereoSpeakers(wav, []Segment{{Start: 0, End: 1}, {Start: 1, End: 2}})
+	if err != nil || assigned {
+		t.Fatalf("assigned = %v, err = %v", assigned, err)
+	}
+}
+
+func TestEnergySpeakersSeparatesDistinctVoices(t *testing.T) {
+	// A loud low voice and a quiet high voice take turns every second.
+	path := writeTestWav(t, 1, 4, func(_ int, at float64) float64 {
+		if int(at)%2 == 0 {
+			return tone(150, 0.6, at)
+		}
+		return tone(1800, 0.1, at)
+	})
+	wav, err := openWav(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wav.Close()
+	segments := []Segment{{Start: 0, End: 1}, {Start: 1, End: 2}, {Start: 2, End: 3}, {Start: 3, End: 4}}
+	if err := energySpeakers(wav, segments); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{segments[0].Speaker, segments[1].Speaker, segments[2].Speaker, segments[3].Speaker}
+	if strings.Join(got, ",") != "Speaker 1,Speaker 2,Speaker 1,Speaker 2" {
+		t.Fatalf("speakers = %v", got)
+	}
+}
+
+func TestEnergySpeakersKeepsOneSpeakerForUniformAudio(t *testing.T) {
+	path := writeTestWav(t, 1, 3, func(_ int, at float64) float64 { return tone(200, 0.4, at) })
+	wav, err := openWav(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wav.Close()
+	segments := []Segment{{Start: 0, End: 1}, {Start: 1, End: 2}, {Start: 2, End: 3}}
+	if err := energySpeakers(wav, segments); err != nil {
+		t.Fatal(err)
+	}
+	for _, segment := range segments {
+		if segment.Speaker != "Speaker 1" {
+			t.Fatalf("unexpected speakers: %#v", segments)
+		}
+	}
+}
+
+func TestExportsLabelSpeakers(t *testing.T) {
+	segments := []Segment{
+		{Start: 0, End: 1, Text: "Bom dia.", Speaker: "Speaker 1"},
+		{Start: 1.2, End: 2, Text: "Tudo bem?", Speaker: "Speaker 1"},
+		{Start: 2.1, End: 3, Text: "Tudo.", Speaker: "Speaker 2"},
+	}
+	plain, _ := FormatTranscript(segments, "pt", "txt")
+	if plain != "Speaker 1: Bom dia. Tudo bem?\n\nSpeaker 2: Tudo." {
+		t.Fatalf("unexpected text:\n%s", plain)
+	}
+	srt, _ := FormatTranscript(segments, "pt", "srt")
+	if !strings.Contains(srt, "00:00:02,100 --> 00:00:03,000\nSpeaker 2: Tudo.") {
+		t.Fatalf("unexpected SRT:\n%s", srt)
+	}
+	vtt, _ := FormatTranscript(segments, "pt", "vtt")
+	if !strings.Contains(vtt, "<v Speaker 2>Tudo.") {
+		t.Fatalf("unexpected VTT:\n%s", vtt)
+	}
+	tsv, _ := FormatTranscript(segments, "pt", "tsv")
+	if lines := strings.Split(tsv, "\n"); lines[0] != "start\tend\tspeaker\ttext" || !strings.HasSuffix(lines[3], "\tSpeaker 2\tTudo.") {
+		t.Fatalf("unexpected TSV:\n%s", tsv)
+	}
+	markdown, _ := FormatTranscript(segments, "pt", "md")
+	if !strings.Contains(markdown, "### [00:00] Speaker 1:\n\nBom dia. Tudo bem?\n") || !strings.Contains(markdown, "### [00:02] Speaker 2:\n\nTudo.") {
+		t.Fatalf("unexpected Markdown:\n%s", markdown)
+	}
+}
@@ -9,11 +9,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"kingo/internal/logger"
+
+	"github.com/google/uuid"
 )
 
 const transcriptionTimeout = 2 * time.Hour
@@ -23,41 +26,99 @@ type whisperJSONResult struct {
 		Language string `json:"language"`
 	} `json:"result"`
 	Transcription []struct {
-		Offsets struct {
-			From int64 `json:"from"`
-			To   int64 `json:"to"`
-		} `json:"offsets"`
-		Text string `json:"text"`
+		Offsets         whisperJSONOffsets `json:"offsets"`
+		Text            string             `json:"text"`
+		Tokens          []whisperJSONToken `json:"tokens"`            // Only present with -ojf
+		SpeakerTurnNext bool               `json:"speaker_turn_next"` // Only present with -tdrz
 	} `json:"transcription"`
 }
 
+type whisperJSONOffsets struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+type whisperJSONToken struct {
+	Text    string             `json:"text"`
+	Offsets whisperJSONOffsets `json:"offsets"`
+}
+
+// wordsFromTokens rebuilds words from whisper's sub-word tokens. A token that
+// starts with a space opens a new word; the others (word pieces, punctuation)
+// extend the current one. Special tokens such as [_BEG_] are skipped.
+func wordsFromTokens(tokens []whisperJSONToken, segmentStart, segmentEnd float64) []Word {
+	words := make([]Word, 0, len(tokens))
+	startNew := true
+	for _, token := range tokens {
+		if strings.HasPrefix(token.Text, "[_") && strings.HasSuffix(token.Text, "]") {
+			continue
+		}
+		text := strings.TrimSpace(token.Text)
+		if text == "" {
+			startNew = true
+			continue
+		}
+		start := min(segmentEnd, max(segmentStart, float64(token.Offsets.From)/1000))
+		end := min(segmentEnd, max(start, float64(token.Offsets.To)/1000))
+		if startNew || strings.HasPrefix(token.Text, " ") || len(words) == 0 {
+			words = append(words, Word{Start: start, End: end, Text: text})
+			startNew = false
+			continue
+		}
+		last := &words[len(words)-1]
+		last.Text += text
+		last.End = max(last.End, end)
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return words
+}
+
 func (c *Client) convertToWav(ctx context.Context, inputPath, workDir string) (string, error) {
+	outputPath := filepath.Join(workDir, "input.wav")
+	if err := c.convertAudio(ctx, inputPath, outputPath, 1); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// convertAudio writes 16 kHz 16-bit PCM with the given number of channels.
+// Whisper reads the mono file; stereo speaker detection reads a second copy.
+func (c *Client) convertAudio(ctx context.Context, inputPath, outputPath string, channels int) error {
 	if c.ffmpegPath == "" {
-		return "", fmt.Errorf("ffmpeg not found; it is required to normalize audio for whisper")
+		return fmt.Errorf("ffmpeg not found; it is required to normalize audio for whisper")
 	}
 	if ctx == nil {
 		ctx = c.baseContext()
 	}
-	outputPath := filepath.Join(workDir, "input.wav")
-	args := []string{"-y", "-i", inputPath, "-vn", "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", outputPath}
+	args := []string{"-y", "-i", inputPath, "-vn", "-ar", "16000", "-ac", strconv.Itoa(channels), "-c:a", "pcm_s16le", outputPath}
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
 	hideWindow(cmd)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("ffmpeg conversion failed: %w: %s", err, strings.TrimSpace(string(output)))
+		return fmt.Errorf("ffmpeg conversion failed: %w: %s", err, strings.TrimSpace(string(output)))
 	}
 	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
-		return "", fmt.Errorf("ffmpeg did not create normalized audio")
+		return fmt.Errorf("ffmpeg did not create normalized audio")
 	}
-	return outputPath, nil
+	return nil
 }
 
 func parseWhisperJSON(data []byte, outputFormat string) (*TranscribeResult, error) {
+	segments, language, err := parseWhisperSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	return buildTranscribeResult(segments, language, outputFormat)
+}
+
+func parseWhisperSegments(data []byte) ([]Segment, string, error) {
 	var payload whisperJSONResult
 	if err := json.Unmarshal(data, &payload); err != nil {
-		return nil, fmt.Errorf("invalid whisper JSON output: %w", err)
+		return nil, "", fmt.Errorf("invalid whisper JSON output: %w", err)
 	}
 	segments := make([]Segment, 0, len(payload.Transcription))
 	for _, item := range payload.Transcription {
@@ -65,33 +126,52 @@ func parseWhisperJSON(data []byte, outputFormat string) (*TranscribeResult, erro
 		if text == "" {
 			continue
 		}
-		segments = append(segments, Segment{Start: float64(item.Offsets.From) / 1000, End: float64(item.Offsets.To) / 1000, Text: text})
-	}
-	var text string
-	switch outputFormat {
-	case "srt":
-		text = formatSRT(segments)
-	case "vtt":
-		text = formatVTT(segments)
-	case "", "txt", "docx":
-		text = buildPlainText(segments)
-	default:
-		return nil, fmt.Errorf("unsupported transcription output format: %s", outputFormat)
+		start := float64(item.Offsets.From) / 1000
+		end := float64(item.Offsets.To) / 1000
+		segments = append(segments, Segment{
+			Start:           start,
+			End:             end,
+			Text:            text,
+			Words:           wordsFromTokens(item.Tokens, start, end),
+			speakerTurnNext: item.SpeakerTurnNext,
+		})
+	}
+	return segments, strings.ToLower(payload.Result.Language), nil
+}
+
+func buildTranscribeResult(segments []Segment, language, outputFormat string) (*TranscribeResult, error) {
+	text, err := FormatTranscript(segments, language, outputFormat)
+	if err != nil {
+		return nil, err
 	}
 	var duration float64
 	if len(segments) > 0 {
 		duration = segments[len(segments)-1].End
 	}
-	return &TranscribeResult{Text: text, Segments: segments, Language: strings.ToLower(payload.Result.Language), Duration: duration}, nil
+	return &TranscribeResult{Text: text, Segments: segments, Language: language, Duration: duration}, nil
 }
 
 func (c *Client) TranscribeFile(filePath, modelName, language, outputFormat string, useVAD bool) (*TranscribeResult, error) {
-	return c.TranscribeFileContext(c.baseContext(), filePath, modelName, language, outputFormat, useVAD)
+	return c.TranscribeFileContext(c.baseContext(), filePath, modelName, language, outputFormat, useVAD, "")
 }
 
 // TranscribeFileContext is the cancellable variant used by queued downloads.
 // The public TranscribeFile method keeps the existing app-lifetime behavior.
-func (c *Client) TranscribeFileContext(ctx context.Context, filePath, modelName, language, outputFormat string, useVAD bool) (*TranscribeResult, error) {
+// prompt is the optional initial prompt (see BuildPrompt).
+func (c *Client) TranscribeFileContext(ctx context.Context, filePath, modelName, language, outputFormat string, useVAD bool, prompt string) (*TranscribeResult, error) {
+	return c.Transcribe(ctx, filePath, TranscribeOptions{
+		Model:        modelName,
+		Language:     language,
+		OutputFormat: outputFormat,
+		UseVAD:       useVAD,
+		Prompt:       prompt,
+	})
+}
+
+// Transcribe runs whisper with the given options. The translate task makes
+// whisper output English; the bilingual task runs both passes over the same
+// normalized audio and attaches the English text to each original segment.
+func (c *Client) Transcribe(ctx context.Context, filePath string, opts TranscribeOptions) (*TranscribeResult, error) {
 	if ctx == nil {
 		ctx = c.baseContext()
 	}
@@ -102,18 +182,29 @@ func (c *Client) TranscribeFileContext(ctx context.Context, filePath, modelName,
 	if err != nil || inputInfo.IsDir() {
 		return nil, fmt.Errorf("media file not found: %s", filePath)
 	}
-	spec, ok := findModelSpec(modelName)
+	spec, ok := c.resolveModelSpec(opts.Model)
 	if !ok {
-		return nil, fmt.Errorf("unsupported whisper model: %s", modelName)
+		return nil, fmt.Errorf("unsupported whisper model: %s", opts.Model)
+	}
+	task, err := normalizeTask(opts.Task, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	speakers, err := normalizeSpeakers(opts.Speakers, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	if speakers == SpeakersAuto && isTinydiarizeModel(spec.Name) {
+		speakers = SpeakersTinydiarize
 	}
 	modelPath := filepath.Join(c.modelsDir, spec.FileName)
 	if err := verifyInstalledModel(modelPath, spec); err != nil {
-		return nil, fmt.Errorf("whisper model is missing, incomplete, or corrupt (%s): %w", modelName, err)
+		return nil, fmt.Errorf("whisper model is missing, incomplete, or corrupt (%s): %w", opts.Model, err)
 	}
+	language := strings.ToLower(opts.Language)
 	if language == "" {
 		language = "auto"
 	}
-	language = strings.ToLower(language)
 	if !validLanguages[language] {
 		return nil, fmt.Errorf("unsupported language code: %s", language)
 	}
@@ -124,38 +215,146 @@ func (c *Client) TranscribeFileContext(ctx context.Context, filePath, modelName,
 	}
 	defer os.RemoveAll(workDir)
 
-	c.emitEvent("whisper:transcribe-progress", map[string]interface{}{"status": "converting", "file": filepath.Base(filePath)})
+	jobID := opts.JobID
+	if jobID == "" {
+		jobID = uuid.New().String()
+	}
+	progress := newProgressTracker(jobID, filepath.Base(filePath), passesPerRun(task), func(event TranscribeProgress) {
+		c.emitEvent("whisper:transcribe-progress", event)
+	})
+	progress.setStatus("converting")
 	wavPath, err := c.convertToWav(ctx, filePath, workDir)
 	if err != nil {
 		return nil, err
 	}
-	outputBase := filepath.Join(workDir, "result")
-	args := []string{"-m", modelPath, "-f", wavPath, "-l", language, "-oj", "-of", outputBase, "-np"}
-	if useVAD {
-		c.emitEvent("whisper:transcribe-progress", map[string]interface{}{"status": "preparing-vad", "file": filepath.Base(filePath)})
+	// -pp makes whisper report its own percentage on stderr; the segment lines
+	// on stdout are a fallback for builds that do not print it.
+	args := []string{"-m", modelPath, "-l", language, "-ojf", "-pp"}
+	if prompt := strings.TrimSpace(opts.Prompt); prompt != "" {
+		args = append(args, "--prompt", prompt)
+	}
+	if opts.UseVAD {
+		progress.setStatus("preparing-vad")
 		vadPath, err := c.ensureVADModel()
 		if err != nil {
 			return nil, fmt.Errorf("could not prepare VAD model: %w", err)
 		}
 		args = append(args, "--vad", "-vm", vadPath)
 	}
+	run := whisperRun{
+		args:        args,
+		task:        task,
+		tinydiarize: speakers == SpeakersTinydiarize,
+		progress:    progress,
+	}
+
+	var segments []Segment
+	var detected string
+	if duration := wavDuration(wavPath); duration > chunkThreshold {
+		checkpoint := chunkCheckpoint{
+			FilePath:    filePath,
+			Size:        inputInfo.Size(),
+			ModTimeNano: inputInfo.ModTime().UnixNano(),
+			ModelSHA256: spec.SHA256,
+			Args:        append(append([]string(nil), args[2:]...), task, speakers),
+		}
+		segments, detected, err = c.transcribeChunked(ctx, run, wavPath, workDir, checkpoint)
+	} else {
+		progress.duration = duration
+		segments, detected, err = c.runPasses(ctx, run, wavPath, workDir, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch speakers {
+	case SpeakersOff:
+	case SpeakersTinydiarize:
+		assignTurnSpeakers(segments)
+	default:
+		progress.setStatus("detecting-speakers")
+		if err := c.detectSpeakers(ctx, speakers, filePath, wavPath, segments); err != nil {
+			return nil, fmt.Errorf("speaker detection failed: %w", err)
+		}
+	}
+
+	segments = ApplyReplacements(segments, opts.Replacements)
 
-	c.emitEvent("whisper:transcribe-progress", map[string]interface{}{"status": "processing", "file": filepath.Base(filePath)})
+	result, err := buildTranscribeResult(segments, detected, opts.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	result.Task = task
+	result.JobID = jobID
+	c.emitEvent("whisper:transcribe-language", map[string]interface{}{"jobId": jobID, "language": result.Language})
+	progress.finish()
+	return result, nil
+}
+
+// whisperRun holds what every whisper invocation of a transcription shares.
+type whisperRun struct {
+	args        []string // Arguments without the input and output files
+	task        string
+	tinydiarize bool
+	progress    *progressTracker
+}
+
+// passesPerRun is the number of whisper passes a task needs per audio file.
+func passesPerRun(task string) int {
+	if task == TaskBilingual {
+		return 2
+	}
+	return 1
+}
+
+// runPasses runs every whisper pass of the task over one WAV file. firstPass
+// is the progress pass index of the first of them. Each call gets its own
+// transcriptionTimeout, so chunked jobs are limited per chunk.
+func (c *Client) runPasses(ctx context.Context, run whisperRun, wavPath, outputDir string, firstPass int) ([]Segment, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, transcriptionTimeout)
 	defer cancel()
+
+	args := append(append([]string(nil), run.args...), "-f", wavPath)
+	firstArgs := args
+	if run.tinydiarize {
+		// Speaker-turn tokens only matter for the pass whose segments are kept.
+		firstArgs = append(append([]string(nil), args...), "-tdrz")
+	}
+	run.progress.startPass(firstPass, "processing")
+	segments, detected, err := c.runWhisper(ctx, firstArgs, filepath.Join(outputDir, "result"), run.task == TaskTranslate, run.progress)
+	if err != nil {
+		return nil, "", err
+	}
+	if run.task == TaskBilingual {
+		run.progress.startPass(firstPass+1, "translating")
+		translated, _, err := c.runWhisper(ctx, args, filepath.Join(outputDir, "translation"), true, run.progress)
+		if err != nil {
+			return nil, "", err
+		}
+		segments = pairTranslations(segments, translated)
+	}
+	return segments, detected, nil
+}
+
+// runWhisper executes one whisper pass and parses its full JSON output.
+// Output lines are fed to progress as they arrive.
+func (c *Client) runWhisper(ctx context.Context, baseArgs []string, outputBase string, translate bool, progress *progressTracker) ([]Segment, string, error) {
+	args := append(append([]string(nil), baseArgs...), "-of", outputBase)
+	if translate {
+		args = append(args, "-tr")
+	}
 	cmd := exec.CommandContext(ctx, c.binaryPath(), args...)
 	cmd.Dir = c.supportedRuntimeDir()
 	hideWindow(cmd)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start whisper: %w", err)
+		return nil, "", fmt.Errorf("failed to start whisper: %w", err)
 	}
 
 	var wg sync.WaitGroup
@@ -163,6 +362,12 @@ func (c *Client) TranscribeFileContext(ctx context.Context, filePath, modelName,
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			progress.handleLine(scanner.Text())
+		}
+		// Keep draining so whisper never blocks on a full pipe.
 		_, _ = io.Copy(io.Discard, stdout)
 	}()
 	go func() {
@@ -172,31 +377,25 @@ func (c *Client) TranscribeFileContext(ctx context.Context, filePath, modelName,
 		for scanner.Scan() {
 			line := scanner.Text()
 			stderrLines = append(stderrLines, line)
+			progress.handleLine(line)
 			logger.Log.Debug().Str("whisper-stderr", line).Msg("")
 		}
 	}()
 	wg.Wait()
 	if err := cmd.Wait(); err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("whisper transcription timed out after %s", transcriptionTimeout)
+			return nil, "", fmt.Errorf("whisper transcription timed out after %s", transcriptionTimeout)
 		}
 		detail := ""
 		if len(stderrLines) > 0 {
 			detail = ": " + stderrLines[len(stderrLines)-1]
 		}
-		return nil, fmt.Errorf("whisper exited with error: %w%s", err, detail)
+		return nil, "", fmt.Errorf("whisper exited with error: %w%s", err, detail)
 	}
 
-	jsonPath := outputBase + ".json"
-	data, err := os.ReadFile(jsonPath)
+	data, err := os.ReadFile(outputBase + ".json")
 	if err != nil {
-		return nil, fmt.Errorf("whisper did not produce JSON output: %w", err)
+		return nil, "", fmt.Errorf("whisper did not produce JSON output: %w", err)
 	}
-	result, err := parseWhisperJSON(data, outputFormat)
-	if err != nil {
-		return nil, err
-	}
-	c.emitEvent("whisper:transcribe-language", map[string]interface{}{"language": result.Language})
-	c.emitEvent("whisper:transcribe-progress", map[string]interface{}{"status": "complete"})
-	return result, nil
+	return parseWhisperSegments(data)
 }
@@ -145,3 +145,56 @@ func TestModelVerificationDetectsChanges(t *testing.T) {
 		t.Fatal("tampered model was accepted")
 	}
 }
+
+func TestParseWhisperJSONBuildsWordsFromTokens(t *testing.T) {
+	fixture := []byte(`{"result":{"language":"en"},"transcription":[{
+  "offsets":{"from":1000,"to":3000},"text":" Hello wonderful world.",
+  "tokens":[
+    {"text":"[_BEG_]","offsets":{"from":1000,"to":1000}},
+    {"text":" Hello","offsets":{"from":1000,"to":1400}},
+    {"text":" wonder","offsets":{"from":1400,"to":1900}},
+    {"text":"ful","offsets":{"from":1900,"to":2200}},
+    {"text":" world","offsets":{"from":2200,"to":2800}},
+    {"text":".","offsets":{"from":2800,"to":3100}},
+    {"text":"[_TT_150]","offsets":{"from":3000,"to":3000}}
+  ]}]}`)
+	result, err := parseWhisperJSON(fixture, "srt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := result.Segments[0].Words
+	if len(words) != 3 {
+		t.Fatalf("words = %#v", words)
+	}
+	if words[1].Text != "wonderful" || words[1].Start != 1.4 || words[1].End != 2.2 {
+		t.Fatalf("word pieces were not joined: %#v", words[1])
+	}
+	if words[2].Text != "world." || words[2].End != 3 {
+		t.Fatalf("punctuation was not attached or clamped: %#v", words[2])
+	}
+}
+
+func TestNormalizeTaskRejectsModelsWithoutTranslation(t *testing.T) {
+	if task, err := normalizeTask("", "base"); err != nil || task != TaskTranscribe {
+		t.Fatalf("default task = %q, %v", task, err)
+	}
+	if task, err := normalizeTask("Bilingual", "small"); err != nil || task != TaskBilingual {
+		t.Fatalf("bilingual task = %q, %v", task, err)
+	}
+	if _, err := normalizeTask("translate", "large-v3-turbo-q5_0"); err == nil {
+		t.Fatal("expected turbo models to reject translation")
+	}
+}
+
+func TestPairTranslationsStacksBilingualSegments(t *testing.T) {
+	original := []Segment{{Start: 0, End: 2, Text: "Olá mundo."}, {Start: 2, End: 5, Text: "Tudo bem?"}}
+	translated := []Segment{{Start: 0, End: 1.8, Text: "Hello world."}, {Start: 2.1, End: 3, Text: "How"}, {Start: 3, End: 5, Text: "are you?"}}
+	result, err := buildTranscribeResult(pairTranslations(original, translated), "pt", "srt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:02,000\nOlá mundo.\nHello world.\n\n2\n00:00:02,000 --> 00:00:05,000\nTudo bem?\nHow are you?"
+	if result.Text != want {
+		t.Fatalf("bilingual SRT = %q", result.Text)
+	}
+}
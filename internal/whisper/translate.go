@@ -0,0 +1,100 @@
+package whisper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transcription tasks supported by Transcribe.
+const (
+	TaskTranscribe = "transcribe"
+	TaskTranslate  = "translate"
+	TaskBilingual  = "bilingual"
+)
+
+// normalizeTask validates the task against the selected model. English-only
+// models cannot translate, and the large-v3-turbo checkpoints were trained
+// without translation data, so whisper would silently return the source text.
+func normalizeTask(task, modelName string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(task)) {
+	case "", TaskTranscribe:
+		return TaskTranscribe, nil
+	case TaskTranslate, TaskBilingual:
+		if strings.HasSuffix(modelName, ".en") || strings.HasPrefix(modelName, "large-v3-turbo") {
+			return "", fmt.Errorf("model %s does not support translation; choose a multilingual model such as small or medium", modelName)
+		}
+		return strings.ToLower(strings.TrimSpace(task)), nil
+	default:
+		return "", fmt.Errorf("unsupported transcription task: %s", task)
+	}
+}
+
+// segmentText stacks the English translation under the original text when the
+// segment comes from a bilingual run.
+func segmentText(segment Segment) string {
+	if segment.Translation == "" {
+		return segment.Text
+	}
+	return segment.Text + "\n" + segment.Translation
+}
+
+// speakerText prefixes segmentText with the speaker label, if any.
+func speakerText(segment Segment) string {
+	if segment.Speaker == "" {
+		return segmentText(segment)
+	}
+	return segment.Speaker + ": " + segmentText(segment)
+}
+
+func hasSpeakers(segments []Segment) bool {
+	for _, segment := range segments {
+		if segment.Speaker != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pairTranslations attaches each translated segment to the original segment it
+// overlaps the most. The two passes segment speech independently, so several
+// English segments may land on one original segment or vice versa.
+func pairTranslations(original, translated []Segment) []Segment {
+	paired := make([]Segment, len(original))
+	copy(paired, original)
+	if len(paired) == 0 {
+		return paired
+	}
+	texts := make([][]string, len(paired))
+	for _, candidate := range translated {
+		text := strings.TrimSpace(candidate.Text)
+		if text == "" {
+			continue
+		}
+		best, bestOverlap := -1, 0.0
+		for index, segment := range paired {
+			overlap := min(segment.End, candidate.End) - max(segment.Start, candidate.Start)
+			if overlap > bestOverlap {
+				best, bestOverlap = index, overlap
+			}
+		}
+		if best < 0 {
+			best = nearestSegment(paired, (candidate.Start+candidate.End)/2)
+		}
+		texts[best] = append(texts[best], text)
+	}
+	for index := range paired {
+		paired[index].Translation = strings.Join(texts[index], " ")
+	}
+	return paired
+}
+
+func nearestSegment(segments []Segment, at float64) int {
+	best, bestDistance := 0, -1.0
+	for index, segment := range segments {
+		distance := max(segment.Start-at, at-segment.End, 0)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = index, distance
+		}
+	}
+	return best
+}
@@ -0,0 +1,75 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"kingo/internal/converter"
+)
+
+func normalizeAnimationFormat(format string) string {
+	if strings.EqualFold(strings.TrimSpace(format), "webp") {
+		return "webp"
+	}
+	return "gif"
+}
+
+func validAnimationOptions(options AnimationOptions) AnimationOptions {
+	options.Format = normalizeAnimationFormat(options.Format)
+	if options.FPS < 0 || options.FPS > 50 {
+		options.FPS = 0
+	}
+	if options.Width < 0 || options.Width > 1920 {
+		options.Width = 0
+	}
+	if options.Loop < 0 {
+		options.Loop = 0
+	}
+	if options.Quality < 0 || options.Quality > 100 {
+		options.Quality = 0
+	}
+	if options.TargetSizeKB < 0 {
+		options.TargetSizeKB = 0
+	}
+	return options
+}
+
+// exportAnimation turns the rendered edit into the final GIF/WebP. Captions
+// were already burned into sourcePath by the timeline render.
+func (c *Client) exportAnimation(
+	ctx context.Context,
+	sourcePath, outputPath string,
+	options AnimationOptions,
+	onLog LogCallback,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("replace animation output: %w", err)
+	}
+	result, err := converter.ExportAnimation(ctx, converter.AnimationExportOptions{
+		InputPath:       sourcePath,
+		OutputPath:      outputPath,
+		Format:          converter.AnimationFormat(options.Format),
+		FPS:             options.FPS,
+		Width:           options.Width,
+		Loop:            options.Loop,
+		Quality:         options.Quality,
+		TargetSizeBytes: int64(options.TargetSizeKB) * 1024,
+		FFmpegPath:      c.ffmpegPath,
+	})
+	if err != nil {
+		return fmt.Errorf("export animation: %w", err)
+	}
+	if onLog != nil {
+		onLog(fmt.Sprintf("[Animação] %s gerado: %.1f KB (qualidade %d, %dpx, %d tentativa(s)).",
+			strings.ToUpper(options.Format), float64(result.OutputSize)/1024, result.Quality, result.Width, result.Attempts))
+		if !result.TargetMet {
+			onLog(fmt.Sprintf("[Animação] ⚠ O arquivo ainda excede o limite de %d KB; reduza o trecho, a largura ou o FPS.", options.TargetSizeKB))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package youtube
+
+import "testing"
+
+func TestAnimationOptionsSelectOutputExtension(t *testing.T) {
+	opts := DownloadOptions{Format: "best"}
+	opts.Animation = validAnimationOptions(AnimationOptions{Enabled: true, Format: "WebP", FPS: 90, Quality: -1})
+	if opts.Animation.Format != "webp" || opts.Animation.FPS != 0 || opts.Animation.Quality != 0 {
+		t.Fatalf("animation options were not normalized: %#v", opts.Animation)
+	}
+	if ext := outputExtension(opts); ext != ".webp" {
+		t.Fatalf("output extension = %q", ext)
+	}
+	opts.Animation = validAnimationOptions(AnimationOptions{Enabled: true, Format: "apng"})
+	if ext := outputExtension(opts); ext != ".gif" {
+		t.Fatalf("unknown formats should fall back to gif, got %q", ext)
+	}
+}
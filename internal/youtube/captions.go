@@ -13,7 +13,23 @@ import (
 
 // SubtitleTranscriber is injected by the application so the download package
 // stays independent from a specific speech-to-text implementation.
-type SubtitleTranscriber func(ctx context.Context, mediaPath, model, language string) ([]SubtitleCue, error)
+type SubtitleTranscriber func(ctx context.Context, req SubtitleTranscription) ([]SubtitleCue, error)
+
+// SubtitleTranscription describes one local transcription requested by the
+// caption resolver.
+type SubtitleTranscription struct {
+	MediaPath   string
+	Model       string   // Empty picks an installed model
+	Language    string   // Whisper language code or "auto"
+	Task        string   // One of the Whisper tasks accepted by CaptionOptions.Task
+	Speakers    string   // Speaker detection mode; empty disables it
+	Prompt      string   // Initial prompt for Whisper
+	GlossaryIDs []string // Resolved by the application into prompt terms and find/replace rules
+}
+
+// speakerPalette colours the second and later speakers. The first speaker
+// keeps the style's text colour.
+var speakerPalette = []string{"#4FC3F7", "#FFB74D", "#81C784", "#F06292", "#BA68C8"}
 
 var hexColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
 
@@ -26,6 +42,15 @@ func normalizeCaptionSource(source string) string {
 	}
 }
 
+func normalizeCaptionTask(task string) string {
+	switch strings.ToLower(strings.TrimSpace(task)) {
+	case "translate", "bilingual":
+		return strings.ToLower(strings.TrimSpace(task))
+	default:
+		return "transcribe"
+	}
+}
+
 func normalizeHexColor(value, fallback string) string {
 	if hexColorRegex.MatchString(value) {
 		return strings.ToUpper(value)
@@ -56,6 +81,15 @@ func normalizeSubtitleStyle(style SubtitleStyle) SubtitleStyle {
 	default:
 		style.Position = "bottom"
 	}
+	switch style.KaraokeMode {
+	case "karaoke", "highlight":
+	default:
+		style.KaraokeMode = ""
+	}
+	style.HighlightColor = normalizeHexColor(style.HighlightColor, "#FFD400")
+	if style.WordsPerLine < 0 || style.WordsPerLine > 12 {
+		style.WordsPerLine = 0
+	}
 	return style
 }
 
@@ -73,6 +107,15 @@ func (c *Client) resolveCaptionCues(
 		return cues, nil
 	}
 
+	task := normalizeCaptionTask(opts.Task)
+	if task != "transcribe" {
+		// Translations only come from the local Whisper pass.
+		if source == "youtube" {
+			return nil, errors.New("a tradução offline exige o Whisper como fonte das legendas")
+		}
+		source = "whisper"
+	}
+
 	if source != "whisper" {
 		if subtitlePath, err := findSubtitleFileForLanguage(workspace, opts.Language); err == nil {
 			cues, parseErr := parseSubtitleFile(subtitlePath)
@@ -103,7 +146,15 @@ func (c *Client) resolveCaptionCues(
 	} else if separator := strings.IndexAny(language, "-_"); separator > 0 {
 		language = language[:separator]
 	}
-	cues, err := c.subtitleTranscriber(ctx, inputPath, opts.Model, language)
+	cues, err := c.subtitleTranscriber(ctx, SubtitleTranscription{
+		MediaPath:   inputPath,
+		Model:       opts.Model,
+		Language:    language,
+		Task:        task,
+		Speakers:    opts.Speakers,
+		Prompt:      opts.Prompt,
+		GlossaryIDs: opts.GlossaryIDs,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("transcrever legendas com Whisper: %w", err)
 	}
@@ -130,11 +181,18 @@ func rippleSubtitleCues(cues []SubtitleCue, segments []CutRange) []SubtitleCue {
 			if end-start < 0.05 {
 				continue
 			}
-			result = append(result, SubtitleCue{
-				Start: outputOffset + start - segment.Start,
-				End:   outputOffset + end - segment.Start,
-				Text:  cue.Text,
-			})
+			shift := outputOffset - segment.Start
+			piece := SubtitleCue{Start: start + shift, End: end + shift, Text: cue.Text, Translation: cue.Translation, Speaker: cue.Speaker}
+			if cueHasWordTimings(cue) {
+				// With word timings the cut also removes the words spoken in it.
+				words := wordsBetween(cue.Words, start, end)
+				if len(words) == 0 {
+					continue
+				}
+				piece.Words = mapSubtitleWords(words, func(t float64) float64 { return t + shift })
+				piece.Text = joinSubtitleWords(words)
+			}
+			result = append(result, piece)
 		}
 		outputOffset += segment.End - segment.Start
 	}
@@ -231,18 +289,67 @@ func buildASS(cues []SubtitleCue, rawStyle SubtitleStyle) string {
 	)
 	builder.WriteString("[Events]\n")
 	builder.WriteString("Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
-	for _, cue := range normalizeSubtitleCues(cues) {
-		text := escapeASSText(cue.Text)
+	cues = normalizeSubtitleCues(cues)
+	speakerColors := assignSpeakerColors(cues)
+	for _, cue := range cues {
+		speakerColor := speakerColors[cue.Speaker]
+		if style.KaraokeMode != "" && cueHasWordTimings(cue) {
+			writeKaraokeDialogues(&builder, cue, style, speakerColor)
+			continue
+		}
+		text := escapeASSText(cue.Text) + assTranslationSuffix(cue, style, "")
 		if style.BackgroundOpacity > 0.001 {
 			fmt.Fprintf(&builder, "Dialogue: 0,%s,%s,Background,,0,0,0,,%s\n",
 				assTimestamp(cue.Start), assTimestamp(cue.End), text)
 		}
-		fmt.Fprintf(&builder, "Dialogue: 1,%s,%s,Default,,0,0,0,,%s\n",
-			assTimestamp(cue.Start), assTimestamp(cue.End), text)
+		fmt.Fprintf(&builder, "Dialogue: 1,%s,%s,Default,,0,0,0,,%s%s\n",
+			assTimestamp(cue.Start), assTimestamp(cue.End), assSpeakerOverride(speakerColor), text)
 	}
 	return builder.String()
 }
 
+// assignSpeakerColors maps every speaker after the first, in order of
+// appearance, to a palette colour. The first speaker is left out so it keeps
+// the style's text colour.
+func assignSpeakerColors(cues []SubtitleCue) map[string]string {
+	colors := map[string]string{}
+	seen := 0
+	for _, cue := range cues {
+		if cue.Speaker == "" {
+			continue
+		}
+		if _, ok := colors[cue.Speaker]; ok {
+			continue
+		}
+		if seen == 0 {
+			colors[cue.Speaker] = ""
+		} else {
+			colors[cue.Speaker] = speakerPalette[(seen-1)%len(speakerPalette)]
+		}
+		seen++
+	}
+	return colors
+}
+
+// assSpeakerOverride switches the primary colour for a speaker's line.
+func assSpeakerOverride(speakerColor string) string {
+	if speakerColor == "" {
+		return ""
+	}
+	return `{\1c` + assInlineColor(speakerColor) + `}`
+}
+
+// assTranslationSuffix stacks the bilingual English line under the original
+// in a smaller italic font. lead is inserted before the overrides so karaoke
+// lines can end their last syllable first.
+func assTranslationSuffix(cue SubtitleCue, style SubtitleStyle, lead string) string {
+	translation := strings.TrimSpace(cue.Translation)
+	if translation == "" {
+		return ""
+	}
+	return fmt.Sprintf(`\N{%s\fs%d\i1}%s`, lead, max(16, style.FontSize*3/4), escapeASSText(translation))
+}
+
 func writeASSFile(workspace string, cues []SubtitleCue, style SubtitleStyle) (string, error) {
 	if len(cues) == 0 {
 		return "", errors.New("cannot render an empty subtitle track")
@@ -278,7 +385,9 @@ func appendASSFilter(filter, inputLabel, outputLabel, assPath string) string {
 
 func validCaptionOptions(options CaptionOptions) CaptionOptions {
 	options.Source = normalizeCaptionSource(options.Source)
+	options.Task = normalizeCaptionTask(options.Task)
 	options.Language = sanitizeSubtitleLanguage(options.Language)
+	options.Prompt = strings.TrimSpace(options.Prompt)
 	options.Model = strings.TrimSpace(options.Model)
 	if len(options.Model) > 64 || strings.ContainsAny(options.Model, `/\\`) {
 		options.Model = ""
@@ -289,6 +398,9 @@ func validCaptionOptions(options CaptionOptions) CaptionOptions {
 }
 
 func outputExtension(opts DownloadOptions) string {
+	if opts.Animation.Enabled && !opts.AudioOnly {
+		return "." + normalizeAnimationFormat(opts.Animation.Format)
+	}
 	if opts.AudioOnly {
 		allowed := map[string]bool{"mp3": true, "m4a": true, "opus": true, "flac": true, "wav": true, "ogg": true}
 		format := strings.ToLower(opts.AudioFormat)
@@ -0,0 +1,201 @@
+package youtube
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// karaokePopIn scales a caption group up from 70% as it appears.
+const karaokePopIn = `{\fscx70\fscy70\t(0,150,\fscx100\fscy100)}`
+
+func normalizeSubtitleWords(words []SubtitleWord, start, end float64) []SubtitleWord {
+	if len(words) == 0 {
+		return nil
+	}
+	normalized := make([]SubtitleWord, 0, len(words))
+	for _, word := range words {
+		word.Text = strings.TrimSpace(word.Text)
+		if word.Text == "" || math.IsNaN(word.Start) || math.IsNaN(word.End) {
+			continue
+		}
+		word.Start = math.Min(end, math.Max(start, word.Start))
+		word.End = math.Min(end, math.Max(word.Start, word.End))
+		normalized = append(normalized, splitSubtitleWord(word)...)
+	}
+	sort.SliceStable(normalized, func(i, j int) bool { return normalized[i].Start < normalized[j].Start })
+	if len(normalized) == 0 {
+		return nil
+	}
+	return normalized
+}
+
+// splitSubtitleWord turns a word holding several tokens, like "G P T", into
+// one word per token, sharing its timing in proportion to their length.
+func splitSubtitleWord(word SubtitleWord) []SubtitleWord {
+	tokens := strings.Fields(word.Text)
+	if len(tokens) == 1 {
+		return []SubtitleWord{word}
+	}
+	total := 0
+	for _, token := range tokens {
+		total += utf8.RuneCountInString(token)
+	}
+	words := make([]SubtitleWord, len(tokens))
+	cursor, consumed := word.Start, 0
+	for index, token := range tokens {
+		consumed += utf8.RuneCountInString(token)
+		end := word.Start + (word.End-word.Start)*float64(consumed)/float64(total)
+		if index == len(tokens)-1 {
+			end = word.End
+		}
+		words[index] = SubtitleWord{Start: cursor, End: end, Text: token}
+		cursor = end
+	}
+	return words
+}
+
+// wordsBetween keeps the words whose midpoint falls inside [start, end).
+func wordsBetween(words []SubtitleWord, start, end float64) []SubtitleWord {
+	var kept []SubtitleWord
+	for _, word := range words {
+		middle := (word.Start + word.End) / 2
+		if middle >= start && middle < end {
+			kept = append(kept, word)
+		}
+	}
+	return kept
+}
+
+func mapSubtitleWords(words []SubtitleWord, transform func(float64) float64) []SubtitleWord {
+	if len(words) == 0 {
+		return nil
+	}
+	mapped := make([]SubtitleWord, len(words))
+	for index, word := range words {
+		mapped[index] = SubtitleWord{Start: transform(word.Start), End: transform(word.End), Text: word.Text}
+	}
+	return mapped
+}
+
+// cueHasWordTimings reports whether the word list still matches the cue text.
+// Once a cue is edited by hand its words are stale and the cue is rendered
+// whole instead.
+func cueHasWordTimings(cue SubtitleCue) bool {
+	fields := strings.Fields(cue.Text)
+	if len(cue.Words) == 0 || len(cue.Words) != len(fields) {
+		return false
+	}
+	return joinSubtitleWords(cue.Words) == strings.Join(fields, " ")
+}
+
+func assInlineColor(hexColor string) string {
+	value := strings.TrimPrefix(normalizeHexColor(hexColor, "#FFFFFF"), "#")
+	return "&H" + value[4:6] + value[2:4] + value[0:2] + "&"
+}
+
+func assCentiseconds(seconds float64) int {
+	return int(math.Round(math.Max(0, seconds) * 100))
+}
+
+// karaokeGroups splits the cue words into display groups of wordsPerLine.
+func karaokeGroups(words []SubtitleWord, wordsPerLine int) [][]SubtitleWord {
+	if wordsPerLine <= 0 || wordsPerLine >= len(words) {
+		return [][]SubtitleWord{words}
+	}
+	groups := make([][]SubtitleWord, 0, len(words)/wordsPerLine+1)
+	for start := 0; start < len(words); start += wordsPerLine {
+		groups = append(groups, words[start:min(len(words), start+wordsPerLine)])
+	}
+	return groups
+}
+
+// writeKaraokeDialogues renders a cue word by word. "karaoke" uses \kf so
+// each word fills with the highlight colour as it is spoken; "highlight"
+// emits one event per word with only the active word coloured. A non-empty
+// speakerColor replaces the text colour for this cue.
+func writeKaraokeDialogues(builder *strings.Builder, cue SubtitleCue, style SubtitleStyle, speakerColor string) {
+	groups := karaokeGroups(cue.Words, style.WordsPerLine)
+	textColor := assInlineColor(style.TextColor)
+	if speakerColor != "" {
+		textColor = assInlineColor(speakerColor)
+	}
+	highlightColor := assInlineColor(style.HighlightColor)
+	for groupIndex, group := range groups {
+		start := group[0].Start
+		if groupIndex == 0 {
+			start = cue.Start
+		}
+		end := cue.End
+		if groupIndex < len(groups)-1 {
+			// Hold the group until the next one starts so short pauses do not
+			// make the caption flicker.
+			end = math.Max(group[len(group)-1].End, groups[groupIndex+1][0].Start)
+		}
+		if end-start < 0.05 {
+			continue
+		}
+
+		prefix := ""
+		if style.PopIn {
+			prefix = karaokePopIn
+		}
+		plain := make([]string, len(group))
+		for index, word := range group {
+			plain[index] = escapeASSText(word.Text)
+		}
+		translation := assTranslationSuffix(cue, style, "")
+		if style.BackgroundOpacity > 0.001 {
+			fmt.Fprintf(builder, "Dialogue: 0,%s,%s,Background,,0,0,0,,%s%s%s\n",
+				assTimestamp(start), assTimestamp(end), prefix, strings.Join(plain, " "), translation)
+		}
+
+		if style.KaraokeMode == "karaoke" {
+			var line strings.Builder
+			fmt.Fprintf(&line, `%s{\1c%s\2c%s}`, prefix, highlightColor, textColor)
+			cursor := start
+			for index, word := range group {
+				if gap := assCentiseconds(word.Start - cursor); gap > 0 {
+					fmt.Fprintf(&line, `{\k%d}`, gap)
+				}
+				separator := ""
+				if index > 0 {
+					separator = " "
+				}
+				fmt.Fprintf(&line, `{\kf%d}%s%s`, assCentiseconds(word.End-math.Max(cursor, word.Start)), separator, plain[index])
+				cursor = math.Max(cursor, word.End)
+			}
+			// A zero-length syllable in the text colour keeps the translation
+			// out of the karaoke fill.
+			line.WriteString(assTranslationSuffix(cue, style, `\k0\1c`+textColor))
+			fmt.Fprintf(builder, "Dialogue: 1,%s,%s,Default,,0,0,0,,%s\n",
+				assTimestamp(start), assTimestamp(end), line.String())
+			continue
+		}
+
+		for index := range group {
+			wordStart := group[index].Start
+			if index == 0 {
+				wordStart = start
+			}
+			wordEnd := end
+			if index < len(group)-1 {
+				wordEnd = group[index+1].Start
+			}
+			if wordEnd-wordStart < 0.01 {
+				continue
+			}
+			parts := make([]string, len(group))
+			copy(parts, plain)
+			parts[index] = fmt.Sprintf(`{\1c%s}%s{\1c%s}`, highlightColor, plain[index], textColor)
+			linePrefix := assSpeakerOverride(speakerColor)
+			if index == 0 {
+				linePrefix = prefix + linePrefix
+			}
+			fmt.Fprintf(builder, "Dialogue: 1,%s,%s,Default,,0,0,0,,%s%s\n",
+				assTimestamp(wordStart), assTimestamp(wordEnd), linePrefix, strings.Join(parts, " ")+translation)
+		}
+	}
+}
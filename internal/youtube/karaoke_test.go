@@ -0,0 +1,115 @@
+package youtube
+
+import (
+	"strings"
+	"testing"
+)
+
+func karaokeFixture() []SubtitleCue {
+	return []SubtitleCue{{
+		Start: 1,
+		End:   3,
+		Text:  "one two three",
+		Words: []SubtitleWord{
+			{Start: 1, End: 1.4, Text: "one"},
+			{Start: 1.6, End: 2, Text: "two"},
+			{Start: 2.2, End: 2.8, Text: "three"},
+		},
+	}}
+}
+
+func TestBuildASSKaraokeUsesFillTags(t *testing.T) {
+	content := buildASS(karaokeFixture(), SubtitleStyle{KaraokeMode: "karaoke", HighlightColor: "#FF0000", PopIn: true})
+	want := `Dialogue: 1,0:00:01.00,0:00:03.00,Default,,0,0,0,,{\fscx70\fscy70\t(0,150,\fscx100\fscy100)}{\1c&H0000FF&\2c&HFFFFFF&}{\kf40}one{\k20}{\kf40} two{\k20}{\kf60} three`
+	if !strings.Contains(content, want) {
+		t.Fatalf("karaoke dialogue missing:\n%s", content)
+	}
+}
+
+func TestBuildASSHighlightGroupsWords(t *testing.T) {
+	content := buildASS(karaokeFixture(), SubtitleStyle{KaraokeMode: "highlight", HighlightColor: "#00FF00", WordsPerLine: 2})
+	for _, want := range []string{
+		`Dialogue: 1,0:00:01.00,0:00:01.60,Default,,0,0,0,,{\1c&H00FF00&}one{\1c&HFFFFFF&} two`,
+		`Dialogue: 1,0:00:01.60,0:00:02.20,Default,,0,0,0,,one {\1c&H00FF00&}two{\1c&HFFFFFF&}`,
+		`Dialogue: 1,0:00:02.20,0:00:03.00,Default,,0,0,0,,{\1c&H00FF00&}three{\1c&HFFFFFF&}`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("missing %q in:\n%s", want, content)
+		}
+	}
+}
+
+func TestBuildASSFallsBackWhenWordsAreStale(t *testing.T) {
+	cues := karaokeFixture()
+	cues[0].Text = "edited by hand"
+	content := buildASS(cues, SubtitleStyle{KaraokeMode: "karaoke"})
+	if strings.Contains(content, `\kf`) || !strings.Contains(content, "Default,,0,0,0,,edited by hand") {
+		t.Fatalf("stale word timings should render the whole cue:\n%s", content)
+	}
+}
+
+func TestWordTimingsFollowEdits(t *testing.T) {
+	rippled := rippleSubtitleCues(karaokeFixture(), []CutRange{{Start: 0, End: 1.5}, {Start: 2.1, End: 5}})
+	if len(rippled) != 2 || rippled[0].Text != "one" || rippled[1].Text != "three" || rippled[1].Words[0].Start != 1.6 {
+		t.Fatalf("ripple words = %#v", rippled)
+	}
+
+	split, err := SplitSubtitleCue(karaokeFixture(), 0, 2.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if split[0].Text != "one two" || split[1].Text != "three" || len(split[1].Words) != 1 {
+		t.Fatalf("split by words = %#v", split)
+	}
+
+	wrapped := WrapSubtitleCues(karaokeFixture(), SubtitleWrapOptions{MaxCharsPerLine: 10, MaxLinesPerCue: 1})
+	if len(wrapped) != 2 || wrapped[0].End != 2.2 || wrapped[1].Start != 2.2 || !cueHasWordTimings(wrapped[1]) {
+		t.Fatalf("wrap by words = %#v", wrapped)
+	}
+}
+
+func TestWrapSplitsMultiTokenWords(t *testing.T) {
+	cues := []SubtitleCue{{
+		Start: 0,
+		End:   3,
+		Text:  "ask G P T now",
+		Words: []SubtitleWord{
+			{Start: 0, End: 0.5, Text: "ask"},
+			{Start: 0.5, End: 2, Text: "G P T"},
+			{Start: 2, End: 3, Text: "now"},
+		},
+	}}
+	wrapped := WrapSubtitleCues(cues, SubtitleWrapOptions{MaxCharsPerLine: 7, MaxLinesPerCue: 1})
+	if len(wrapped) != 2 || wrapped[1].Text != "T now" || wrapped[1].Start != 1.5 || len(wrapped[1].Words) != 2 {
+		t.Fatalf("wrap with a multi-token word = %#v", wrapped)
+	}
+
+	stale := SubtitleCue{Start: 0, End: 1, Text: "G P T", Words: []SubtitleWord{{Start: 0, End: 1, Text: "G P T"}}}
+	if cueHasWordTimings(stale) {
+		t.Fatal("a word list that does not match the cue tokens should not be used")
+	}
+}
+
+func TestBuildASSColoursSpeakers(t *testing.T) {
+	cues := []SubtitleCue{
+		{Start: 0, End: 1, Text: "Primeiro", Speaker: "Speaker 1"},
+		{Start: 1, End: 2, Text: "Segundo", Speaker: "Speaker 2"},
+		{Start: 2, End: 3, Text: "Terceiro", Speaker: "Speaker 3"},
+	}
+	content := buildASS(cues, SubtitleStyle{TextColor: "#FFFFFF"})
+	for _, expected := range []string{
+		",Default,,0,0,0,,Primeiro\n",
+		`,Default,,0,0,0,,{\1c&HF7C34F&}Segundo`,
+		`,Default,,0,0,0,,{\1c&H4DB7FF&}Terceiro`,
+	} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("ASS output is missing %q:\n%s", expected, content)
+		}
+	}
+
+	cues[1].Words = []SubtitleWord{{Start: 1, End: 1.5, Text: "Segundo"}}
+	highlight := buildASS(cues, SubtitleStyle{TextColor: "#FFFFFF", KaraokeMode: "highlight"})
+	if !strings.Contains(highlight, `{\1c&HF7C34F&}{\1c`) {
+		t.Fatalf("highlight line does not use the speaker colour:\n%s", highlight)
+	}
+}
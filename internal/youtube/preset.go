@@ -0,0 +1,74 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PresetConverter is injected by the application so downloads can finish
+// with a named conversion preset without this package knowing about presets.
+// It converts inputPath into outputDir and returns the converted file.
+type PresetConverter func(ctx context.Context, preset, inputPath, outputDir string) (string, error)
+
+// SetPresetConverter installs the converter used by DownloadOptions.ConversionPreset.
+func (c *Client) SetPresetConverter(converter PresetConverter) {
+	c.presetConverter = converter
+}
+
+// applyConversionPreset converts the finished download into the output
+// directory. sourcePath is the edited render, or empty to use the raw
+// download of the workspace.
+func (c *Client) applyConversionPreset(ctx context.Context, workspace, sourcePath, preset string, onLog LogCallback) error {
+	if sourcePath == "" {
+		var err error
+		sourcePath, err = findDownloadedMedia(workspace)
+		if err != nil {
+			return fmt.Errorf("prepare conversion preset: %w", err)
+		}
+	}
+	if onLog != nil {
+		onLog(fmt.Sprintf("[Conversor] Aplicando o preset \"%s\"...", preset))
+	}
+	outputPath, err := c.presetConverter(ctx, preset, sourcePath, c.outputDir)
+	if err != nil {
+		return fmt.Errorf("conversion preset %q: %w", preset, err)
+	}
+	if onLog != nil {
+		onLog(fmt.Sprintf("[Conversor] Arquivo final: %s", filepath.Base(outputPath)))
+	}
+	// Subtitle sidecars written next to the edit travel with the result.
+	return moveSidecars(sourcePath, outputPath)
+}
+
+// moveSidecars moves the subtitle files named after sourcePath next to
+// outputPath when they live in another directory.
+func moveSidecars(sourcePath, outputPath string) error {
+	dir := filepath.Dir(sourcePath)
+	if dir == filepath.Dir(outputPath) {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	prefix := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath)) + "."
+	target := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "."
+	for _, entry := range entries {
+		name := entry.Name()
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".srt", ".vtt", ".ass":
+		default:
+			continue
+		}
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, name), target+strings.TrimPrefix(name, prefix)); err != nil {
+			return fmt.Errorf("move subtitles: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package youtube
+
+import (
+	"fmt"
+	"strings"
+
+	"kingo/internal/converter"
+)
+
+// appendSpeedFilters retimes the video and audio ends of an edit graph and
+// returns the graph with the new labels. An empty label is a stream the
+// output does not have.
+func appendSpeedFilters(filter string, speed *converter.Speed, videoLabel, audioLabel string) (string, string, string) {
+	parts := []string{filter}
+	if filters := speed.VideoFilters(0); len(filters) > 0 && videoLabel != "" {
+		parts = append(parts, fmt.Sprintf("[%s]%s[vspeed]", videoLabel, strings.Join(filters, ",")))
+		videoLabel = "vspeed"
+	}
+	if filters := speed.AudioFilters(); len(filters) > 0 && audioLabel != "" {
+		parts = append(parts, fmt.Sprintf("[%s]%s[aspeed]", audioLabel, strings.Join(filters, ",")))
+		audioLabel = "aspeed"
+	}
+	return strings.Join(parts, ";"), videoLabel, audioLabel
+}
+
+// retimeSubtitleCues moves cues of an edit lasting duration seconds onto the
+// output timeline: mirrored when the edit plays backwards, then scaled by the
+// speed factor. Reversed cues drop their word timings since the words are
+// heard in reverse.
+func retimeSubtitleCues(cues []SubtitleCue, speed *converter.Speed, duration float64) []SubtitleCue {
+	if speed != nil && speed.Reverse {
+		mirrored := make([]SubtitleCue, 0, len(cues))
+		for _, cue := range cues {
+			cue.Start, cue.End = duration-cue.End, duration-cue.Start
+			cue.Words = nil
+			mirrored = append(mirrored, cue)
+		}
+		cues = sortSubtitleCues(mirrored)
+	}
+	if output := speed.OutputDuration(duration); output != duration {
+		stretched, err := StretchSubtitleCues(cues, SubtitleSyncPoint{}, SubtitleSyncPoint{From: duration, To: output})
+		if err == nil {
+			cues = stretched
+		}
+	}
+	return cues
+}
+
+// editedDuration is the length of the edit that keeps segments.
+func editedDuration(segments []CutRange) float64 {
+	total := 0.0
+	for _, segment := range segments {
+		total += segment.End - segment.Start
+	}
+	return total
+}
+
+// reverseSourceArgs renders the edit to the near-lossless intermediate that
+// converter.ReverseMedia reads.
+func reverseSourceArgs(video, audio string) []string {
+	var args []string
+	if video != "" {
+		args = append(args, "-map", video, "-c:v", "libx264", "-preset", "veryfast", "-crf", "12", "-pix_fmt", "yuv420p")
+	}
+	if audio != "" {
+		args = append(args, "-map", audio, "-c:a", "pcm_s16le")
+	}
+	return args
+}
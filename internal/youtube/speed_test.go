@@ -0,0 +1,60 @@
+package youtube
+
+import (
+	"strings"
+	"testing"
+
+	"kingo/internal/converter"
+)
+
+func TestAppendSpeedFilters(t *testing.T) {
+	filter, video, audio := appendSpeedFilters("[0:v:0]null[vout];[0:a:0]anull[aout]", &converter.Speed{Factor: 3}, "vout", "aout")
+	want := "[0:v:0]null[vout];[0:a:0]anull[aout];[vout]setpts=PTS/3[vspeed];[aout]atempo=2,atempo=1.5[aspeed]"
+	if filter != want || video != "vspeed" || audio != "aspeed" {
+		t.Fatalf("appendSpeedFilters() = %s, %s, %s", filter, video, audio)
+	}
+
+	filter, video, audio = appendSpeedFilters("[0:a:0]anull[aout]", &converter.Speed{Factor: 0.5, Interpolate: true}, "", "aout")
+	if filter != "[0:a:0]anull[aout];[aout]atempo=0.5[aspeed]" || video != "" || audio != "aspeed" {
+		t.Fatalf("audio-only appendSpeedFilters() = %s, %s, %s", filter, video, audio)
+	}
+
+	filter, video, _ = appendSpeedFilters("graph", &converter.Speed{Reverse: true}, "vout", "")
+	if filter != "graph" || video != "vout" {
+		t.Fatalf("reverse alone should not change the graph: %s, %s", filter, video)
+	}
+}
+
+func TestEditOutputArgs(t *testing.T) {
+	if got := strings.Join(editOutputArgs("", "[aspeed]", nil, ".mp3"), " "); got != "-map [aspeed]" {
+		t.Fatalf("audio-only editOutputArgs() = %s", got)
+	}
+	got := strings.Join(editOutputArgs("0:v:0", "0:a:0", nil, ".mp4"), " ")
+	want := "-map 0:v:0 -map 0:a:0 -c:a aac -b:a 192k -c:v libx264 -preset medium -crf 18 -pix_fmt yuv420p -map_metadata 0 -movflags +faststart"
+	if got != want {
+		t.Fatalf("editOutputArgs() =\n%s\nwant\n%s", got, want)
+	}
+	if got := strings.Join(reverseSourceArgs("[vout]", ""), " "); got != "-map [vout] -c:v libx264 -preset veryfast -crf 12 -pix_fmt yuv420p" {
+		t.Fatalf("reverseSourceArgs() = %s", got)
+	}
+}
+
+func TestRetimeSubtitleCuesFollowsSpeed(t *testing.T) {
+	cues := []SubtitleCue{
+		{Start: 2, End: 3, Text: "primeira", Words: []SubtitleWord{{Start: 2, End: 3, Text: "primeira"}}},
+		{Start: 5, End: 6, Text: "segunda"},
+	}
+	faster := retimeSubtitleCues(cues, &converter.Speed{Factor: 2}, 7)
+	if faster[0].Start != 1 || faster[0].End != 1.5 || faster[0].Words[0].End != 1.5 || faster[1].Start != 2.5 {
+		t.Fatalf("2x cues = %#v", faster)
+	}
+
+	reversed := retimeSubtitleCues(cues, &converter.Speed{Factor: 2, Reverse: true}, 7)
+	if reversed[0].Text != "segunda" || reversed[0].Start != 0.5 || reversed[0].End != 1 || reversed[1].Start != 2 || reversed[1].Words != nil {
+		t.Fatalf("reversed 2x cues = %#v", reversed)
+	}
+
+	if same := retimeSubtitleCues(cues, nil, 7); same[1].Start != 5 {
+		t.Fatalf("cues without a speed change moved: %#v", same)
+	}
+}
@@ -0,0 +1,384 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultMaxCharsPerLine = 42
+	defaultMaxLinesPerCue  = 2
+	defaultMaxCPS          = 17.0
+
+	// minSplitCueDuration keeps split and wrapped cues long enough to be read
+	// and above the 0.05s floor applied by normalizeSubtitleCues.
+	minSplitCueDuration = 0.2
+)
+
+// SubtitleSyncPoint pairs a timestamp in the current cues with the time it
+// should land on. Two points define a linear re-timing.
+type SubtitleSyncPoint struct {
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+// SubtitleWrapOptions limits how text is laid out inside each cue.
+type SubtitleWrapOptions struct {
+	MaxCharsPerLine int     `json:"maxCharsPerLine"` // default 42
+	MaxLinesPerCue  int     `json:"maxLinesPerCue"`  // default 2
+	MaxCPS          float64 `json:"maxCps"`          // Reading speed limit, default 17
+}
+
+// SubtitleWarning flags a cue that is likely too fast to read.
+type SubtitleWarning struct {
+	Index   int     `json:"index"`
+	CPS     float64 `json:"cps"`
+	Message string  `json:"message"`
+}
+
+// SubtitleEditResult is returned after each edit so the editor can refresh
+// the cue list and its reading-speed hints together.
+type SubtitleEditResult struct {
+	Cues     []SubtitleCue     `json:"cues"`
+	Warnings []SubtitleWarning `json:"warnings"`
+}
+
+// NewSubtitleEditResult pairs edited cues with their reading-speed warnings.
+func NewSubtitleEditResult(cues []SubtitleCue, maxCPS float64) *SubtitleEditResult {
+	if cues == nil {
+		cues = []SubtitleCue{}
+	}
+	return &SubtitleEditResult{Cues: cues, Warnings: CheckSubtitleReadingSpeed(cues, maxCPS)}
+}
+
+func normalizeWrapOptions(options SubtitleWrapOptions) SubtitleWrapOptions {
+	if options.MaxCharsPerLine <= 0 {
+		options.MaxCharsPerLine = defaultMaxCharsPerLine
+	}
+	options.MaxCharsPerLine = min(200, max(10, options.MaxCharsPerLine))
+	if options.MaxLinesPerCue <= 0 {
+		options.MaxLinesPerCue = defaultMaxLinesPerCue
+	}
+	options.MaxLinesPerCue = min(6, options.MaxLinesPerCue)
+	if options.MaxCPS <= 0 || math.IsNaN(options.MaxCPS) || math.IsInf(options.MaxCPS, 0) {
+		options.MaxCPS = defaultMaxCPS
+	}
+	return options
+}
+
+func sortSubtitleCues(cues []SubtitleCue) []SubtitleCue {
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+	return normalizeSubtitleCues(cues)
+}
+
+// ShiftSubtitleCues moves cues starting inside [from, to] by offset seconds.
+// A non-positive to extends the range to the end of the track, so shifting
+// everything is ShiftSubtitleCues(cues, offset, 0, 0).
+func ShiftSubtitleCues(cues []SubtitleCue, offset, from, to float64) []SubtitleCue {
+	shifted := make([]SubtitleCue, len(cues))
+	copy(shifted, cues)
+	for index, cue := range shifted {
+		if cue.Start < from || (to > 0 && cue.Start > to) {
+			continue
+		}
+		// Clamp as a block so cues pushed before zero keep their duration.
+		delta := math.Max(offset, -cue.Start)
+		shifted[index].Start += delta
+		shifted[index].End += delta
+		shifted[index].Words = mapSubtitleWords(cue.Words, func(t float64) float64 { return t + delta })
+	}
+	return sortSubtitleCues(shifted)
+}
+
+// StretchSubtitleCues re-times every cue along the line through two sync
+// points. This fixes tracks made for a different frame rate or cut, where a
+// constant shift only lines up one end.
+func StretchSubtitleCues(cues []SubtitleCue, first, second SubtitleSyncPoint) ([]SubtitleCue, error) {
+	if math.Abs(second.From-first.From) < 0.1 {
+		return nil, errors.New("os pontos de sincronia precisam estar separados por pelo menos 0,1s")
+	}
+	scale := (second.To - first.To) / (second.From - first.From)
+	if scale <= 0 {
+		return nil, errors.New("os pontos de sincronia invertem a ordem das legendas")
+	}
+	offset := first.To - first.From*scale
+	retime := func(t float64) float64 { return math.Max(0, t*scale+offset) }
+	stretched := make([]SubtitleCue, 0, len(cues))
+	for _, cue := range cues {
+		cue.Start = retime(cue.Start)
+		cue.End = retime(cue.End)
+		cue.Words = mapSubtitleWords(cue.Words, retime)
+		stretched = append(stretched, cue)
+	}
+	return sortSubtitleCues(stretched), nil
+}
+
+// SplitSubtitleCue splits cue index at the given time. The text is divided
+// at the line break or word boundary closest to the same relative position.
+func SplitSubtitleCue(cues []SubtitleCue, index int, at float64) ([]SubtitleCue, error) {
+	if index < 0 || index >= len(cues) {
+		return nil, fmt.Errorf("legenda %d não existe", index)
+	}
+	cue := cues[index]
+	if at-cue.Start < minSplitCueDuration || cue.End-at < minSplitCueDuration {
+		return nil, errors.New("o ponto de divisão precisa ficar dentro da legenda")
+	}
+	first := SubtitleCue{Start: cue.Start, End: at, Speaker: cue.Speaker}
+	second := SubtitleCue{Start: at, End: cue.End, Speaker: cue.Speaker}
+	if cueHasWordTimings(cue) {
+		// Word timings say exactly which words were spoken before the cut.
+		first.Words = wordsBetween(cue.Words, cue.Start, at)
+		second.Words = wordsBetween(cue.Words, at, math.Inf(1))
+		first.Text = joinSubtitleWords(first.Words)
+		second.Text = joinSubtitleWords(second.Words)
+	} else {
+		first.Text, second.Text = splitSubtitleText(cue.Text, (at-cue.Start)/(cue.End-cue.Start))
+	}
+	if first.Text == "" || second.Text == "" {
+		return nil, errors.New("a legenda tem apenas uma palavra e não pode ser dividida")
+	}
+	if cue.Translation != "" {
+		first.Translation, second.Translation = splitSubtitleText(cue.Translation, (at-cue.Start)/(cue.End-cue.Start))
+	}
+	result := make([]SubtitleCue, 0, len(cues)+1)
+	result = append(result, cues[:index]...)
+	result = append(result, first, second)
+	result = append(result, cues[index+1:]...)
+	return sortSubtitleCues(result), nil
+}
+
+func splitSubtitleText(text string, ratio float64) (string, string) {
+	words := strings.Fields(text)
+	if len(words) < 2 {
+		return strings.TrimSpace(text), ""
+	}
+	if lines := strings.Split(strings.TrimSpace(text), "\n"); len(lines) > 1 {
+		// Prefer the existing line break nearest the requested position.
+		best, bestDistance := 1, math.Inf(1)
+		total := float64(utf8.RuneCountInString(text))
+		consumed := 0
+		for split := 1; split < len(lines); split++ {
+			consumed += utf8.RuneCountInString(lines[split-1]) + 1
+			if distance := math.Abs(float64(consumed)/total - ratio); distance < bestDistance {
+				best, bestDistance = split, distance
+			}
+		}
+		return strings.Join(lines[:best], "\n"), strings.Join(lines[best:], "\n")
+	}
+	total := float64(utf8.RuneCountInString(strings.Join(words, " ")))
+	best, bestDistance := 1, math.Inf(1)
+	consumed := 0
+	for split := 1; split < len(words); split++ {
+		consumed += utf8.RuneCountInString(words[split-1]) + 1
+		if distance := math.Abs(float64(consumed)/total - ratio); distance < bestDistance {
+			best, bestDistance = split, distance
+		}
+	}
+	return strings.Join(words[:best], " "), strings.Join(words[best:], " ")
+}
+
+// MergeSubtitleCues joins cues first..last (inclusive) into one cue spanning
+// all of them.
+func MergeSubtitleCues(cues []SubtitleCue, first, last int) ([]SubtitleCue, error) {
+	if first < 0 || last >= len(cues) || first >= last {
+		return nil, fmt.Errorf("intervalo de legendas inválido: %d-%d", first, last)
+	}
+	merged := SubtitleCue{Start: cues[first].Start, End: cues[first].End, Speaker: cues[first].Speaker}
+	texts := make([]string, 0, last-first+1)
+	translations := make([]string, 0, last-first+1)
+	for _, cue := range cues[first : last+1] {
+		merged.Start = math.Min(merged.Start, cue.Start)
+		merged.End = math.Max(merged.End, cue.End)
+		if text := strings.Join(strings.Fields(cue.Text), " "); text != "" {
+			texts = append(texts, text)
+		}
+		merged.Words = append(merged.Words, cue.Words...)
+		if translation := strings.Join(strings.Fields(cue.Translation), " "); translation != "" {
+			translations = append(translations, translation)
+		}
+	}
+	merged.Text = strings.Join(texts, " ")
+	merged.Translation = strings.Join(translations, " ")
+	result := make([]SubtitleCue, 0, len(cues)-(last-first))
+	result = append(result, cues[:first]...)
+	result = append(result, merged)
+	result = append(result, cues[last+1:]...)
+	return sortSubtitleCues(result), nil
+}
+
+// WrapSubtitleCues re-flows every cue to the line limits. Text that does not
+// fit in MaxLinesPerCue lines is moved to follow-up cues, sharing the original
+// duration in proportion to their length.
+func WrapSubtitleCues(cues []SubtitleCue, options SubtitleWrapOptions) []SubtitleCue {
+	options = normalizeWrapOptions(options)
+	result := make([]SubtitleCue, 0, len(cues))
+	for _, cue := range normalizeSubtitleCues(cues) {
+		lines := wrapSubtitleWords(strings.Fields(cue.Text), options.MaxCharsPerLine)
+		if len(lines) <= options.MaxLinesPerCue {
+			cue.Text = strings.Join(lines, "\n")
+			result = append(result, cue)
+			continue
+		}
+
+		chunks := make([]string, 0, len(lines)/options.MaxLinesPerCue+1)
+		for start := 0; start < len(lines); start += options.MaxLinesPerCue {
+			end := min(len(lines), start+options.MaxLinesPerCue)
+			chunks = append(chunks, strings.Join(lines[start:end], "\n"))
+		}
+		translations := distributeTranslation(cue.Translation, chunks)
+		if cueHasWordTimings(cue) {
+			for index, chunk := range splitCueByWordTimings(cue, chunks) {
+				chunk.Translation = translations[index]
+				result = append(result, chunk)
+			}
+			continue
+		}
+		duration := cue.End - cue.Start
+		if duration/float64(len(chunks)) < minSplitCueDuration {
+			// Too short to divide; keep every line rather than lose timing.
+			cue.Text = strings.Join(lines, "\n")
+			result = append(result, cue)
+			continue
+		}
+		totalChars := 0
+		for _, chunk := range chunks {
+			totalChars += utf8.RuneCountInString(chunk)
+		}
+		cursor := cue.Start
+		for index, chunk := range chunks {
+			end := cue.End
+			if index < len(chunks)-1 {
+				end = cursor + duration*float64(utf8.RuneCountInString(chunk))/float64(totalChars)
+			}
+			result = append(result, SubtitleCue{Start: cursor, End: end, Text: chunk, Translation: translations[index], Speaker: cue.Speaker})
+			cursor = end
+		}
+	}
+	return normalizeSubtitleCues(result)
+}
+
+// splitCueByWordTimings times each wrapped chunk from its own words, so the
+// overflow cue appears when its first word is spoken.
+func splitCueByWordTimings(cue SubtitleCue, chunks []string) []SubtitleCue {
+	result := make([]SubtitleCue, 0, len(chunks))
+	consumed := 0
+	for index, chunk := range chunks {
+		count := len(strings.Fields(chunk))
+		words := cue.Words[consumed : consumed+count]
+		consumed += count
+		start, end := words[0].Start, cue.End
+		if index == 0 {
+			start = cue.Start
+		}
+		if index < len(chunks)-1 {
+			end = cue.Words[consumed].Start
+		}
+		result = append(result, SubtitleCue{Start: start, End: end, Text: chunk, Words: words, Speaker: cue.Speaker})
+	}
+	return result
+}
+
+// distributeTranslation spreads the words of a bilingual line over the
+// wrapped chunks in proportion to each chunk's length.
+func distributeTranslation(translation string, chunks []string) []string {
+	parts := make([]string, len(chunks))
+	words := strings.Fields(translation)
+	if len(words) == 0 {
+		return parts
+	}
+	total := 0
+	for _, chunk := range chunks {
+		total += utf8.RuneCountInString(chunk)
+	}
+	consumed, cumulative := 0, 0
+	for index, chunk := range chunks {
+		cumulative += utf8.RuneCountInString(chunk)
+		end := len(words)
+		if index < len(chunks)-1 && total > 0 {
+			end = min(len(words), int(math.Round(float64(len(words)*cumulative)/float64(total))))
+		}
+		end = max(end, consumed)
+		parts[index] = strings.Join(words[consumed:end], " ")
+		consumed = end
+	}
+	return parts
+}
+
+func joinSubtitleWords(words []SubtitleWord) string {
+	texts := make([]string, len(words))
+	for index, word := range words {
+		texts[index] = word.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+// wrapSubtitleWords balances lines instead of filling them greedily: the
+// number of lines is fixed by the greedy pass and the width is then reduced
+// as far as possible without adding a line.
+func wrapSubtitleWords(words []string, maxChars int) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	lines := greedyWrap(words, maxChars)
+	if len(lines) < 2 {
+		return lines
+	}
+	for width := maxChars - 1; width > 0; width-- {
+		candidate := greedyWrap(words, width)
+		if len(candidate) > len(lines) {
+			break
+		}
+		lines = candidate
+	}
+	return lines
+}
+
+func greedyWrap(words []string, maxChars int) []string {
+	lines := make([]string, 0, 2)
+	current := ""
+	for _, word := range words {
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= maxChars:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// SubtitleReadingSpeed returns characters per second, ignoring line breaks.
+func SubtitleReadingSpeed(cue SubtitleCue) float64 {
+	duration := cue.End - cue.Start
+	if duration <= 0 {
+		return 0
+	}
+	chars := utf8.RuneCountInString(strings.Join(strings.Fields(cue.Text), " "))
+	return float64(chars) / duration
+}
+
+// CheckSubtitleReadingSpeed lists cues above maxCPS characters per second.
+func CheckSubtitleReadingSpeed(cues []SubtitleCue, maxCPS float64) []SubtitleWarning {
+	if maxCPS <= 0 {
+		maxCPS = defaultMaxCPS
+	}
+	warnings := make([]SubtitleWarning, 0)
+	for index, cue := range cues {
+		cps := SubtitleReadingSpeed(cue)
+		if cps > maxCPS {
+			warnings = append(warnings, SubtitleWarning{
+				Index:   index,
+				CPS:     math.Round(cps*10) / 10,
+				Message: fmt.Sprintf("%.1f caracteres/s (limite %.0f); aumente a duração ou reduza o texto", cps, maxCPS),
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,83 @@
+package youtube
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShiftSubtitleCuesOnlyMovesRange(t *testing.T) {
+	cues := []SubtitleCue{{Start: 1, End: 2, Text: "a"}, {Start: 5, End: 6, Text: "b"}, {Start: 9, End: 10, Text: "c"}}
+	got := ShiftSubtitleCues(cues, 0.5, 4, 8)
+	if got[0].Start != 1 || got[1].Start != 5.5 || got[1].End != 6.5 || got[2].Start != 9 {
+		t.Fatalf("range shift = %#v", got)
+	}
+	got = ShiftSubtitleCues(cues, -3, 0, 0)
+	if got[0].Start != 0 || got[0].End != 1 || got[1].Start != 2 {
+		t.Fatalf("global shift = %#v", got)
+	}
+	if cues[1].Start != 5 {
+		t.Fatal("input cues were modified")
+	}
+}
+
+func TestStretchSubtitleCuesMapsSyncPoints(t *testing.T) {
+	cues := []SubtitleCue{{Start: 10, End: 12, Text: "a"}, {Start: 100, End: 102, Text: "b"}}
+	got, err := StretchSubtitleCues(cues, SubtitleSyncPoint{From: 10, To: 11}, SubtitleSyncPoint{From: 100, To: 105.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Start != 11 || math.Abs(got[1].Start-105.5) > 1e-9 || math.Abs(got[1].End-107.6) > 1e-9 {
+		t.Fatalf("stretch = %#v", got)
+	}
+	if _, err := StretchSubtitleCues(cues, SubtitleSyncPoint{From: 10, To: 20}, SubtitleSyncPoint{From: 20, To: 10}); err == nil {
+		t.Fatal("expected inverted sync points to fail")
+	}
+}
+
+func TestSplitAndMergeSubtitleCues(t *testing.T) {
+	cues := []SubtitleCue{{Start: 0, End: 4, Text: "one two three four"}}
+	split, err := SplitSubtitleCue(cues, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split) != 2 || split[0].Text != "one two" || split[1].Text != "three four" || split[1].Start != 2 {
+		t.Fatalf("split = %#v", split)
+	}
+	merged, err := MergeSubtitleCues(split, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 || merged[0].Text != "one two three four" || merged[0].End != 4 {
+		t.Fatalf("merge = %#v", merged)
+	}
+	if _, err := SplitSubtitleCue([]SubtitleCue{{Start: 0, End: 2, Text: "single"}}, 0, 1); err == nil {
+		t.Fatal("expected single-word split to fail")
+	}
+}
+
+func TestWrapSubtitleCuesBalancesLinesAndSplitsOverflow(t *testing.T) {
+	cues := []SubtitleCue{{Start: 0, End: 6, Text: "the quick brown fox jumps over the lazy dog and keeps running far away"}}
+	got := WrapSubtitleCues(cues, SubtitleWrapOptions{MaxCharsPerLine: 20, MaxLinesPerCue: 2})
+	if len(got) != 2 {
+		t.Fatalf("wrap = %#v", got)
+	}
+	if got[0].Text != "the quick brown\nfox jumps over the" || got[0].End != got[1].Start || got[1].End != 6 {
+		t.Fatalf("wrapped cues = %#v", got)
+	}
+
+	balanced := WrapSubtitleCues([]SubtitleCue{{Start: 0, End: 5, Text: "aaaa bbbb cccc dddd eeee"}}, SubtitleWrapOptions{MaxCharsPerLine: 20})
+	if balanced[0].Text != "aaaa bbbb cccc\ndddd eeee" {
+		t.Fatalf("lines were not balanced: %q", balanced[0].Text)
+	}
+}
+
+func TestCheckSubtitleReadingSpeed(t *testing.T) {
+	cues := []SubtitleCue{
+		{Start: 0, End: 1, Text: "this line is far too long for one second"},
+		{Start: 2, End: 5, Text: "comfortable"},
+	}
+	warnings := CheckSubtitleReadingSpeed(cues, 17)
+	if len(warnings) != 1 || warnings[0].Index != 0 || warnings[0].CPS != 40 {
+		t.Fatalf("warnings = %#v", warnings)
+	}
+}
@@ -0,0 +1,350 @@
+package youtube
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxSubtitleFileSize keeps a mistaken selection (a video, an archive) from
+// being loaded into memory as text.
+const maxSubtitleFileSize = 20 << 20
+
+// languageCodeRegex matches BCP 47-like tags such as "en", "pt-BR" or "zh-Hans".
+var languageCodeRegex = regexp.MustCompile(`^[A-Za-z]{2,3}(?:[-_][A-Za-z0-9]{2,8})*$`)
+
+// SubtitleExportOptions describes a sidecar export of edited cues.
+type SubtitleExportOptions struct {
+	Cues           []SubtitleCue `json:"cues"`
+	Format         string        `json:"format"`         // srt, vtt, ass, json
+	VideoPath      string        `json:"videoPath"`      // The sidecar is written next to this file
+	OutputPath     string        `json:"outputPath"`     // Explicit destination, overrides VideoPath
+	Language       string        `json:"language"`       // Added to the sidecar name (video.pt-BR.srt)
+	ExcludedRanges []CutRange    `json:"excludedRanges"` // Timeline cuts applied before export
+	Style          SubtitleStyle `json:"style"`          // Used by the ASS format
+}
+
+func normalizeSubtitleExportFormat(format string) (string, error) {
+	format = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(format)), ".")
+	switch format {
+	case "", "srt":
+		return "srt", nil
+	case "vtt", "ass", "json":
+		return format, nil
+	default:
+		return "", fmt.Errorf("formato de legenda não suportado: %s", format)
+	}
+}
+
+// subtitleSidecarPath names the subtitle after the video so players pick it up
+// automatically: "Video.mp4" becomes "Video.pt-BR.srt".
+func subtitleSidecarPath(videoPath, language, format string) string {
+	base := strings.TrimSuffix(videoPath, filepath.Ext(videoPath))
+	if language = sanitizeSubtitleLanguage(language); language != "" && language != "auto" {
+		base += "." + language
+	}
+	return base + "." + format
+}
+
+// ExportSubtitles writes the edited cues as a subtitle file and returns its
+// path. Cues are kept in source-video time by the editor, so the same cuts
+// used for the render are rippled out before writing.
+func ExportSubtitles(opts SubtitleExportOptions) (string, error) {
+	format, err := normalizeSubtitleExportFormat(opts.Format)
+	if err != nil {
+		return "", err
+	}
+	outputPath := strings.TrimSpace(opts.OutputPath)
+	if outputPath == "" {
+		if strings.TrimSpace(opts.VideoPath) == "" {
+			return "", errors.New("informe o vídeo ou o arquivo de destino da legenda")
+		}
+		outputPath = subtitleSidecarPath(opts.VideoPath, opts.Language, format)
+	}
+
+	cues := normalizeSubtitleCues(opts.Cues)
+	if cuts := normalizeCutRanges(opts.ExcludedRanges); len(cuts) > 0 {
+		duration := 0.0
+		for _, cue := range cues {
+			duration = math.Max(duration, cue.End)
+		}
+		cues = rippleSubtitleCues(cues, keptRanges(cuts, duration))
+	}
+	if len(cues) == 0 {
+		return "", errors.New("não há legendas para exportar")
+	}
+
+	var content []byte
+	switch format {
+	case "srt":
+		content = []byte(buildSRT(cues))
+	case "vtt":
+		content = []byte(buildVTT(cues))
+	case "ass":
+		content = []byte(buildASS(cues, opts.Style))
+	case "json":
+		content, err = json.MarshalIndent(SubtitleResult{
+			Cues:     cues,
+			Language: sanitizeSubtitleLanguage(opts.Language),
+			Source:   "editor",
+		}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", fmt.Errorf("create subtitle directory: %w", err)
+	}
+	if err := os.WriteFile(outputPath, content, 0644); err != nil {
+		return "", fmt.Errorf("write subtitle: %w", err)
+	}
+	return outputPath, nil
+}
+
+func subtitleTimestamp(seconds float64, separator string) string {
+	milliseconds := int64(math.Round(math.Max(0, seconds) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		milliseconds/3600000,
+		(milliseconds%3600000)/60000,
+		(milliseconds%60000)/1000,
+		separator,
+		milliseconds%1000,
+	)
+}
+
+// cueDisplayText stacks the bilingual translation under the original text.
+func cueDisplayText(cue SubtitleCue) string {
+	if translation := strings.TrimSpace(cue.Translation); translation != "" {
+		return cue.Text + "\n" + translation
+	}
+	return cue.Text
+}
+
+func buildSRT(cues []SubtitleCue) string {
+	var builder strings.Builder
+	for index, cue := range cues {
+		fmt.Fprintf(&builder, "%d\n%s --> %s\n%s\n\n",
+			index+1, subtitleTimestamp(cue.Start, ","), subtitleTimestamp(cue.End, ","), cueDisplayText(cue))
+	}
+	return builder.String()
+}
+
+func buildVTT(cues []SubtitleCue) string {
+	var builder strings.Builder
+	builder.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		// A blank line ends a WebVTT cue, so it must not appear in the payload.
+		text := strings.ReplaceAll(cueDisplayText(cue), "\n\n", "\n")
+		fmt.Fprintf(&builder, "%s --> %s\n%s\n\n",
+			subtitleTimestamp(cue.Start, "."), subtitleTimestamp(cue.End, "."), text)
+	}
+	return builder.String()
+}
+
+// ImportSubtitleFile loads a local subtitle into editable cues. The language
+// is taken from a sidecar-style name such as "video.en.srt" when present.
+func ImportSubtitleFile(path string) (*SubtitleResult, error) {
+	cues, err := parseSubtitleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("nenhuma legenda encontrada em %s", filepath.Base(path))
+	}
+	return &SubtitleResult{
+		Cues:     cues,
+		Language: subtitleLanguageFromPath(path),
+		Source:   "file",
+	}, nil
+}
+
+func subtitleLanguageFromPath(path string) string {
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	index := strings.LastIndex(stem, ".")
+	if index < 0 {
+		return ""
+	}
+	language := stem[index+1:]
+	if !languageCodeRegex.MatchString(language) {
+		return ""
+	}
+	return language
+}
+
+func readSubtitleText(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > maxSubtitleFileSize {
+		return "", fmt.Errorf("arquivo de legenda muito grande: %s", filepath.Base(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return decodeSubtitleText(data), nil
+}
+
+// decodeSubtitleText detects the encoding of a subtitle file. Byte order marks
+// are trusted first; without one, UTF-16 is recognised by its NUL bytes and
+// anything that is not valid UTF-8 is treated as Windows-1252, the usual
+// encoding of older SRT files.
+func decodeSubtitleText(data []byte) string {
+	switch {
+	case len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF:
+		return string(data[3:])
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE:
+		return decodeUTF16(data[2:], binary.LittleEndian)
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		return decodeUTF16(data[2:], binary.BigEndian)
+	}
+	if order, ok := detectUTF16(data); ok {
+		return decodeUTF16(data, order)
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return decodeWindows1252(data)
+}
+
+func detectUTF16(data []byte) (binary.ByteOrder, bool) {
+	sample := data[:min(len(data), 4096)&^1]
+	if len(sample) < 4 {
+		return nil, false
+	}
+	evenZeros, oddZeros := 0, 0
+	for index := 0; index < len(sample); index += 2 {
+		if sample[index] == 0 {
+			evenZeros++
+		}
+		if sample[index+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros*10 >= pairs*4 && evenZeros*10 < pairs:
+		return binary.LittleEndian, true
+	case evenZeros*10 >= pairs*4 && oddZeros*10 < pairs:
+		return binary.BigEndian, true
+	}
+	return nil, false
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, 0, len(data)/2)
+	for index := 0; index+1 < len(data); index += 2 {
+		units = append(units, order.Uint16(data[index:]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// windows1252High maps 0x80-0x9F; the rest of the range matches Latin-1.
+var windows1252High = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+func decodeWindows1252(data []byte) string {
+	var builder strings.Builder
+	builder.Grow(len(data) + len(data)/4)
+	for _, value := range data {
+		switch {
+		case value < 0x80:
+			builder.WriteByte(value)
+		case value < 0xA0:
+			builder.WriteRune(windows1252High[value-0x80])
+		default:
+			builder.WriteRune(rune(value))
+		}
+	}
+	return builder.String()
+}
+
+// parseASS reads Dialogue events from an ASS/SSA script. Override blocks are
+// dropped and the duplicated background layer written by buildASS is skipped.
+func parseASS(content string) ([]SubtitleCue, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	inEvents := false
+	fields := []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+	cues := make([]SubtitleCue, 0)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "format":
+			fields = fields[:0]
+			for _, field := range strings.Split(value, ",") {
+				fields = append(fields, strings.ToLower(strings.TrimSpace(field)))
+			}
+		case "dialogue":
+			// Text is always the last field and may itself contain commas.
+			parts := strings.SplitN(strings.TrimSpace(value), ",", len(fields))
+			if len(parts) != len(fields) {
+				continue
+			}
+			event := make(map[string]string, len(fields))
+			for index, field := range fields {
+				event[field] = parts[index]
+			}
+			if strings.EqualFold(strings.TrimSpace(event["style"]), "Background") {
+				continue
+			}
+			start, startErr := parseSubtitleTimestamp(event["start"])
+			end, endErr := parseSubtitleTimestamp(event["end"])
+			if startErr != nil || endErr != nil || end <= start {
+				continue
+			}
+			text := assOverrideRegex.ReplaceAllString(event["text"], "")
+			text = strings.NewReplacer("\\N", "\n", "\\n", "\n", "\\h", " ").Replace(text)
+			text = cleanSubtitleText(strings.Split(text, "\n"))
+			if text != "" {
+				cues = append(cues, SubtitleCue{Start: start, End: end, Text: text})
+				if len(cues) >= maxSubtitleCues {
+					return normalizeSubtitleCues(cues), nil
+				}
+			}
+		}
+	}
+	return normalizeSubtitleCues(cues), nil
+}
+
+// parseSubtitleJSON accepts both the exported SubtitleResult document and a
+// bare array of cues.
+func parseSubtitleJSON(content string) ([]SubtitleCue, error) {
+	content = strings.TrimSpace(strings.TrimPrefix(content, "\ufeff"))
+	var cues []SubtitleCue
+	if strings.HasPrefix(content, "[") {
+		if err := json.Unmarshal([]byte(content), &cues); err != nil {
+			return nil, err
+		}
+	} else {
+		var result SubtitleResult
+		if err := json.Unmarshal([]byte(content), &result); err != nil {
+			return nil, err
+		}
+		cues = result.Cues
+	}
+	return normalizeSubtitleCues(cues), nil
+}
@@ -0,0 +1,97 @@
+package youtube
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportSubtitlesRipplesCutsIntoSidecar(t *testing.T) {
+	videoPath := filepath.Join(t.TempDir(), "Clip.mp4")
+	cues := []SubtitleCue{
+		{Start: 1, End: 2, Text: "first"},
+		{Start: 5, End: 6.5, Text: "second"},
+	}
+	path, err := ExportSubtitles(SubtitleExportOptions{
+		Cues:           cues,
+		Format:         "srt",
+		VideoPath:      videoPath,
+		Language:       "pt-BR",
+		ExcludedRanges: []CutRange{{Start: 2.5, End: 4.5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "Clip.pt-BR.srt" {
+		t.Fatalf("sidecar path = %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,000\nfirst\n\n2\n00:00:03,000 --> 00:00:04,500\nsecond\n\n"
+	if string(data) != want {
+		t.Fatalf("srt content = %q", data)
+	}
+}
+
+func TestExportedSubtitlesRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cues := []SubtitleCue{{Start: 0.5, End: 2, Text: "Olá, mundo"}, {Start: 3, End: 4.25, Text: "two\nlines"}}
+	for _, format := range []string{"srt", "vtt", "ass", "json"} {
+		path, err := ExportSubtitles(SubtitleExportOptions{
+			Cues:       cues,
+			Format:     format,
+			OutputPath: filepath.Join(dir, "captions."+format),
+			Style:      SubtitleStyle{BackgroundOpacity: 0.6},
+		})
+		if err != nil {
+			t.Fatalf("%s export: %v", format, err)
+		}
+		result, err := ImportSubtitleFile(path)
+		if err != nil {
+			t.Fatalf("%s import: %v", format, err)
+		}
+		if len(result.Cues) != len(cues) {
+			t.Fatalf("%s round trip = %#v", format, result.Cues)
+		}
+		for index, cue := range result.Cues {
+			if cue.Text != cues[index].Text || cue.Start != cues[index].Start || cue.End != cues[index].End {
+				t.Fatalf("%s cue %d = %#v", format, index, cue)
+			}
+		}
+	}
+}
+
+func TestImportSubtitleFileDetectsEncoding(t *testing.T) {
+	dir := t.TempDir()
+	latin1 := filepath.Join(dir, "legacy.pt.srt")
+	if err := os.WriteFile(latin1, []byte("1\r\n00:00:01,000 --> 00:00:02,000\r\nA\xe7\xe3o \x93cita\xe7\xe3o\x94\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ImportSubtitleFile(latin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Language != "pt" || result.Cues[0].Text != "Ação “citação”" {
+		t.Fatalf("windows-1252 import = %#v", result)
+	}
+
+	utf16Path := filepath.Join(dir, "wide.vtt")
+	text := "WEBVTT\n\n00:01.000 --> 00:02.000\nçé\n"
+	encoded := []byte{0xFF, 0xFE}
+	for _, char := range text {
+		encoded = append(encoded, byte(char), byte(char>>8))
+	}
+	if err := os.WriteFile(utf16Path, encoded, 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err = ImportSubtitleFile(utf16Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Language != "" || !strings.Contains(result.Cues[0].Text, "çé") {
+		t.Fatalf("utf-16 import = %#v", result)
+	}
+}
@@ -0,0 +1,197 @@
+package youtube
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"kingo/internal/converter"
+)
+
+// maxSubtitleTracks bounds how many languages one download can request so a
+// pasted list cannot turn into hundreds of yt-dlp subtitle requests.
+const maxSubtitleTracks = 12
+
+var defaultSubtitleLanguages = []string{"pt", "pt-BR", "en"}
+
+// iso639Part2 maps the two-letter codes used by YouTube to the three-letter
+// codes expected in MP4/MKV stream metadata. Unknown codes become "und".
+var iso639Part2 = map[string]string{
+	"ar": "ara", "bg": "bul", "bn": "ben", "ca": "cat", "cs": "ces", "da": "dan",
+	"de": "deu", "el": "ell", "en": "eng", "es": "spa", "et": "est", "fa": "fas",
+	"fi": "fin", "fil": "fil", "fr": "fra", "he": "heb", "hi": "hin", "hr": "hrv",
+	"hu": "hun", "id": "ind", "it": "ita", "ja": "jpn", "ko": "kor", "lt": "lit",
+	"lv": "lav", "ms": "msa", "nl": "nld", "no": "nor", "nb": "nob", "pl": "pol",
+	"pt": "por", "ro": "ron", "ru": "rus", "sk": "slk", "sl": "slv", "sr": "srp",
+	"sv": "swe", "sw": "swa", "ta": "tam", "th": "tha", "tr": "tur", "uk": "ukr",
+	"ur": "urd", "vi": "vie", "zh": "zho",
+}
+
+// subtitleTrack is one language sidecar prepared for the edited render.
+type subtitleTrack struct {
+	Language string
+	Path     string
+}
+
+// requestedSubtitleLanguages merges the language list, the legacy single
+// language field (which may hold a comma-separated list) and the burned-in
+// caption language, keeping the first occurrence of each code.
+func requestedSubtitleLanguages(opts DownloadOptions) []string {
+	candidates := make([]string, 0, len(opts.SubtitleLanguages)+4)
+	candidates = append(candidates, opts.SubtitleLanguages...)
+	candidates = append(candidates, strings.Split(opts.SubtitleLanguage, ",")...)
+	if opts.Captions.Enabled {
+		candidates = append(candidates, opts.Captions.Language)
+	}
+
+	languages := make([]string, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		language := sanitizeSubtitleLanguage(candidate)
+		key := strings.ToLower(language)
+		if language == "" || key == "auto" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		languages = append(languages, language)
+		if len(languages) >= maxSubtitleTracks {
+			break
+		}
+	}
+	if len(languages) == 0 {
+		return append([]string(nil), defaultSubtitleLanguages...)
+	}
+	return languages
+}
+
+// subtitleStreamLanguage converts a BCP 47 code such as "pt-BR" into the
+// ISO 639-2 code written to the subtitle stream.
+func subtitleStreamLanguage(code string) string {
+	base := strings.ToLower(code)
+	if separator := strings.IndexAny(base, "-_"); separator > 0 {
+		base = base[:separator]
+	}
+	if len(base) == 3 {
+		return base
+	}
+	if mapped, ok := iso639Part2[base]; ok {
+		return mapped
+	}
+	return "und"
+}
+
+// findSubtitleFilesByLanguage returns one SRT/VTT sidecar per requested
+// language, matching the ".<lang>" suffix yt-dlp adds to subtitle files.
+func findSubtitleFilesByLanguage(root string, languages []string) ([]subtitleTrack, error) {
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil || info.IsDir() {
+			return err
+		}
+		extension := strings.ToLower(filepath.Ext(path))
+		if extension != ".srt" && extension != ".vtt" {
+			return nil
+		}
+		stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if index := strings.LastIndex(stem, "."); index >= 0 {
+			key := strings.ToLower(stem[index+1:])
+			// SRT wins over VTT when yt-dlp leaves both behind.
+			if existing, ok := files[key]; !ok || strings.EqualFold(filepath.Ext(existing), ".vtt") {
+				files[key] = path
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]subtitleTrack, 0, len(languages))
+	for _, language := range languages {
+		if path, ok := files[strings.ToLower(language)]; ok {
+			tracks = append(tracks, subtitleTrack{Language: language, Path: path})
+		}
+	}
+	return tracks, nil
+}
+
+// prepareSubtitleTracks ripples every downloaded language through the
+// timeline cuts so embedded streams and sidecars stay in sync with the
+// edited render. Rippled SRT files are written into the workspace.
+func prepareSubtitleTracks(workspace string, languages []string, segments []CutRange, speed *converter.Speed, onLog LogCallback) ([]subtitleTrack, error) {
+	sources, err := findSubtitleFilesByLanguage(workspace, languages)
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]subtitleTrack, 0, len(sources))
+	for _, source := range sources {
+		cues, err := parseSubtitleFile(source.Path)
+		if err != nil {
+			return nil, err
+		}
+		cues = retimeSubtitleCues(rippleSubtitleCues(cues, segments), speed, editedDuration(segments))
+		if len(cues) == 0 {
+			if onLog != nil {
+				onLog(fmt.Sprintf("[Legendas] A faixa %s ficou vazia após os cortes e foi ignorada.", source.Language))
+			}
+			continue
+		}
+		path := filepath.Join(workspace, "downkingo-track-"+strconv.Itoa(len(tracks))+".srt")
+		if err := os.WriteFile(path, []byte(buildSRT(cues)), 0600); err != nil {
+			return nil, fmt.Errorf("prepare subtitle track %s: %w", source.Language, err)
+		}
+		tracks = append(tracks, subtitleTrack{Language: source.Language, Path: path})
+	}
+	return tracks, nil
+}
+
+// subtitleTrackInputs adds one FFmpeg input per prepared track.
+func subtitleTrackInputs(tracks []subtitleTrack) []string {
+	args := make([]string, 0, len(tracks)*2)
+	for _, track := range tracks {
+		args = append(args, "-i", track.Path)
+	}
+	return args
+}
+
+// subtitleTrackMaps maps tracks read from inputs firstInput.. as separate
+// subtitle streams, each tagged with its language. MP4 only accepts mov_text.
+func subtitleTrackMaps(tracks []subtitleTrack, firstInput int, extension string) []string {
+	if len(tracks) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(tracks)*6+2)
+	for index, track := range tracks {
+		stream := strconv.Itoa(index)
+		args = append(args,
+			"-map", strconv.Itoa(firstInput+index)+":s:0",
+			"-metadata:s:s:"+stream, "language="+subtitleStreamLanguage(track.Language),
+			"-metadata:s:s:"+stream, "title="+track.Language,
+		)
+	}
+	codec := "srt"
+	if extension == ".mp4" {
+		codec = "mov_text"
+	}
+	return append(args, "-c:s", codec)
+}
+
+// writeSubtitleSidecars copies the rippled tracks next to the rendered video,
+// named by language code ("Video.pt-BR.srt") so players pick them up.
+func writeSubtitleSidecars(outputPath string, tracks []subtitleTrack) ([]string, error) {
+	paths := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		data, err := os.ReadFile(track.Path)
+		if err != nil {
+			return paths, err
+		}
+		path := subtitleSidecarPath(outputPath, track.Language, "srt")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return paths, fmt.Errorf("write subtitle sidecar: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,75 @@
+package youtube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequestedSubtitleLanguagesMergesAndDeduplicates(t *testing.T) {
+	opts := DownloadOptions{
+		SubtitleLanguages: []string{"en", "es", "EN", "bad lang"},
+		SubtitleLanguage:  "pt-BR, fr",
+		Captions:          CaptionOptions{Enabled: true, Language: "ja"},
+	}
+	got := requestedSubtitleLanguages(opts)
+	if want := []string{"en", "es", "pt-BR", "fr", "ja"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("languages = %#v, want %#v", got, want)
+	}
+	if got := requestedSubtitleLanguages(DownloadOptions{}); !reflect.DeepEqual(got, defaultSubtitleLanguages) {
+		t.Fatalf("default languages = %#v", got)
+	}
+}
+
+func TestSubtitleTrackMapsTagsEachLanguage(t *testing.T) {
+	tracks := []subtitleTrack{{Language: "pt-BR", Path: "a.srt"}, {Language: "xx", Path: "b.srt"}}
+	got := strings.Join(subtitleTrackMaps(tracks, 1, ".mp4"), " ")
+	want := "-map 1:s:0 -metadata:s:s:0 language=por -metadata:s:s:0 title=pt-BR " +
+		"-map 2:s:0 -metadata:s:s:1 language=und -metadata:s:s:1 title=xx -c:s mov_text"
+	if got != want {
+		t.Fatalf("maps = %q", got)
+	}
+	if args := subtitleTrackMaps(tracks, 1, ".mkv"); args[len(args)-1] != "srt" {
+		t.Fatalf("mkv codec = %q", args[len(args)-1])
+	}
+}
+
+func TestPrepareSubtitleTracksRipplesEveryLanguage(t *testing.T) {
+	workspace := t.TempDir()
+	fixtures := map[string]string{
+		"Video.en.srt":    "1\n00:00:05,000 --> 00:00:06,000\nHello\n",
+		"Video.pt-BR.vtt": "WEBVTT\n\n00:00:05.000 --> 00:00:06.000\nOlá\n",
+		"Video.de.srt":    "1\n00:00:01,000 --> 00:00:02,000\nHallo\n",
+	}
+	for name, content := range fixtures {
+		if err := os.WriteFile(filepath.Join(workspace, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	segments := []CutRange{{Start: 0, End: 1}, {Start: 4, End: 10}}
+	tracks, err := prepareSubtitleTracks(workspace, []string{"pt-BR", "en", "es"}, segments, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 2 || tracks[0].Language != "pt-BR" || tracks[1].Language != "en" {
+		t.Fatalf("tracks = %#v", tracks)
+	}
+
+	outputDir := t.TempDir()
+	paths, err := writeSubtitleSidecars(filepath.Join(outputDir, "Video.mp4"), tracks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(paths[0]) != "Video.pt-BR.srt" || filepath.Base(paths[1]) != "Video.en.srt" {
+		t.Fatalf("sidecars = %#v", paths)
+	}
+	data, err := os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "00:00:02,000 --> 00:00:03,000") {
+		t.Fatalf("sidecar was not rippled: %q", data)
+	}
+}
@@ -248,15 +248,23 @@ func findSubtitleFileForLanguage(root, language string) (string, error) {
 }
 
 func parseSubtitleFile(path string) ([]SubtitleCue, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
 	extension := strings.ToLower(filepath.Ext(path))
-	if extension != ".srt" && extension != ".vtt" {
+	var parse func(string) ([]SubtitleCue, error)
+	switch extension {
+	case ".srt", ".vtt":
+		parse = parseTimedText
+	case ".ass", ".ssa":
+		parse = parseASS
+	case ".json":
+		parse = parseSubtitleJSON
+	default:
 		return nil, fmt.Errorf("unsupported subtitle format: %s", extension)
 	}
-	cues, err := parseTimedText(string(data))
+	content, err := readSubtitleText(path)
+	if err != nil {
+		return nil, err
+	}
+	cues, err := parse(content)
 	if err != nil {
 		return nil, fmt.Errorf("parse subtitle %s: %w", filepath.Base(path), err)
 	}
@@ -266,6 +274,7 @@ func parseSubtitleFile(path string) ([]SubtitleCue, error) {
 var (
 	timestampLineRegex = regexp.MustCompile(`^\s*((?:\d{1,3}:)?\d{1,2}:\d{2}[,.]\d{3})\s+-->\s+((?:\d{1,3}:)?\d{1,2}:\d{2}[,.]\d{3})`)
 	subtitleTagRegex   = regexp.MustCompile(`<[^>]*>`)
+	assOverrideRegex   = regexp.MustCompile(`\{[^}]*\}`)
 )
 
 func parseSubtitleTimestamp(value string) (float64, error) {
@@ -348,6 +357,7 @@ func normalizeSubtitleCues(cues []SubtitleCue) []SubtitleCue {
 		if cue.End-cue.Start < 0.05 || cue.Text == "" {
 			continue
 		}
+		cue.Words = normalizeSubtitleWords(cue.Words, cue.Start, cue.End)
 		if len(cue.Text) > 2000 {
 			runes := []rune(cue.Text)
 			if len(runes) > 2000 {
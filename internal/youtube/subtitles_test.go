@@ -39,10 +39,10 @@ func TestVideoInfoCollectsCompactSubtitleLanguages(t *testing.T) {
 func TestCaptionResolverFallsBackToInjectedWhisper(t *testing.T) {
 	client := NewClient("yt-dlp", "ffmpeg", t.TempDir())
 	called := false
-	client.SetSubtitleTranscriber(func(_ context.Context, mediaPath, model, language string) ([]SubtitleCue, error) {
+	client.SetSubtitleTranscriber(func(_ context.Context, req SubtitleTranscription) ([]SubtitleCue, error) {
 		called = true
-		if filepath.Base(mediaPath) != "video.mp4" || model != "base" || language != "pt" {
-			t.Fatalf("unexpected transcriber arguments: %s, %s, %s", mediaPath, model, language)
+		if filepath.Base(req.MediaPath) != "video.mp4" || req.Model != "base" || req.Language != "pt" {
+			t.Fatalf("unexpected transcriber arguments: %#v", req)
 		}
 		return []SubtitleCue{{Start: 0, End: 2, Text: "Fala detectada"}}, nil
 	})
@@ -60,7 +60,7 @@ func TestCaptionResolverFallsBackToInjectedWhisper(t *testing.T) {
 
 func TestCaptionResolverHonorsYouTubeOnlyMode(t *testing.T) {
 	client := NewClient("yt-dlp", "ffmpeg", t.TempDir())
-	client.SetSubtitleTranscriber(func(_ context.Context, _, _, _ string) ([]SubtitleCue, error) {
+	client.SetSubtitleTranscriber(func(_ context.Context, _ SubtitleTranscription) ([]SubtitleCue, error) {
 		t.Fatal("Whisper must not run in YouTube-only mode")
 		return nil, nil
 	})
@@ -71,6 +71,50 @@ func TestCaptionResolverHonorsYouTubeOnlyMode(t *testing.T) {
 	}
 }
 
+func TestCaptionResolverTranslationSkipsSourceTrack(t *testing.T) {
+	client := NewClient("yt-dlp", "ffmpeg", t.TempDir())
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "video.pt.srt"), []byte("1\n00:00:00,000 --> 00:00:01,000\nOlá\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client.SetSubtitleTranscriber(func(_ context.Context, req SubtitleTranscription) ([]SubtitleCue, error) {
+		if req.Task != "bilingual" {
+			t.Fatalf("task = %q", req.Task)
+		}
+		return []SubtitleCue{{Start: 0, End: 1, Text: "Olá", Translation: "Hello"}}, nil
+	})
+	cues, err := client.resolveCaptionCues(context.Background(), workspace, filepath.Join(workspace, "video.mp4"), CaptionOptions{
+		Source: "auto", Language: "pt", Task: "bilingual",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 1 || cues[0].Translation != "Hello" {
+		t.Fatalf("unexpected bilingual cues: %#v", cues)
+	}
+	if _, err := client.resolveCaptionCues(context.Background(), workspace, filepath.Join(workspace, "video.mp4"), CaptionOptions{
+		Source: "youtube", Task: "translate",
+	}, nil); err == nil {
+		t.Fatal("expected translation to require Whisper")
+	}
+}
+
+func TestBilingualCuesRenderStacked(t *testing.T) {
+	cues := []SubtitleCue{{Start: 1, End: 2, Text: "Olá", Translation: "Hello"}}
+	if got := buildSRT(cues); got != "1\n00:00:01,000 --> 00:00:02,000\nOlá\nHello\n\n" {
+		t.Fatalf("srt = %q", got)
+	}
+	content := buildASS(cues, SubtitleStyle{FontSize: 64})
+	if !strings.Contains(content, `Default,,0,0,0,,Olá\N{\fs48\i1}Hello`) {
+		t.Fatalf("ass translation line missing:\n%s", content)
+	}
+	wrapped := WrapSubtitleCues([]SubtitleCue{{Start: 0, End: 4, Text: "um dois três quatro", Translation: "one two three four"}},
+		SubtitleWrapOptions{MaxCharsPerLine: 12, MaxLinesPerCue: 1})
+	if len(wrapped) != 2 || wrapped[0].Translation != "one two" || wrapped[1].Translation != "three four" {
+		t.Fatalf("translation was not distributed: %#v", wrapped)
+	}
+}
+
 func TestParseTimedTextSupportsSRTAndVTT(t *testing.T) {
 	fixture := `WEBVTT
 
@@ -20,6 +20,8 @@ import (
 	"unicode/utf8"
 
 	aria2runtime "kingo/internal/aria2"
+	"kingo/internal/converter"
+	"kingo/internal/mediainfo"
 )
 
 // ═══════════════════════════════════════════════════════════════════════════════
@@ -285,6 +287,7 @@ type Client struct {
 	aria2cPath          string // Opcional: para downloads multi-thread
 	outputDir           string
 	subtitleTranscriber SubtitleTranscriber
+	presetConverter     PresetConverter
 	optionsProvider     OptionsProvider
 	authBrowsers        sync.Map
 }
@@ -647,6 +650,11 @@ type DownloadOptions struct {
 	SubtitleLanguage  string `json:"subtitleLanguage"`  // pt-BR, en, es, etc.
 	EmbedSubtitles    bool   `json:"embedSubtitles"`    // Embed subs in video
 
+	// SubtitleLanguages requests several tracks at once. Each one is embedded
+	// as its own language-tagged stream and kept as "<title>.<lang>.srt";
+	// Captions.Language picks the one burned into the picture.
+	SubtitleLanguages []string `json:"subtitleLanguages"`
+
 	// New Options
 	RemuxVideo          bool   `json:"remuxVideo"`          // Remux to mp4/mkv
 	RemuxFormat         string `json:"remuxFormat"`         // mp4, mkv (default mp4)
@@ -670,6 +678,22 @@ type DownloadOptions struct {
 	// Captions are visually burned into the rendered video. This is separate
 	// from EmbedSubtitles, which only adds a selectable subtitle stream.
 	Captions CaptionOptions `json:"captions"`
+
+	// Animation replaces the video output with a looping GIF or animated WebP
+	// rendered from the trimmed and edited range.
+	Animation AnimationOptions `json:"animation"`
+
+	// ConversionPreset names a conversion preset applied to the finished
+	// download, after any edits. Empty keeps the downloaded file as is.
+	ConversionPreset string `json:"conversionPreset"`
+
+	// Watermark overlays a logo or text on the rendered video. Ignored for
+	// audio-only downloads.
+	Watermark *converter.Watermark `json:"watermark,omitempty"`
+
+	// Speed changes the playback speed (0.25x-4x) of the rendered media or
+	// plays it backwards. Captions and subtitle tracks are retimed to match.
+	Speed *converter.Speed `json:"speed,omitempty"`
 }
 
 type CutRange struct {
@@ -679,16 +703,45 @@ type CutRange struct {
 
 // CaptionOptions controls subtitle acquisition and visual rendering.
 type CaptionOptions struct {
-	Enabled  bool          `json:"enabled"`
-	Source   string        `json:"source"` // auto, youtube, whisper
-	Language string        `json:"language"`
-	Model    string        `json:"model"`
-	Cues     []SubtitleCue `json:"cues"`
-	Style    SubtitleStyle `json:"style"`
+	Enabled     bool          `json:"enabled"`
+	Source      string        `json:"source"` // auto, youtube, whisper
+	Language    string        `json:"language"`
+	Model       string        `json:"model"`
+	Task        string        `json:"task"`        // transcribe, translate (Whisper to English) or bilingual
+	Speakers    string        `json:"speakers"`    // Whisper speaker detection: "", auto, tinydiarize, stereo, energy
+	Prompt      string        `json:"prompt"`      // Whisper initial prompt: names and jargon to spell right
+	GlossaryIDs []string      `json:"glossaryIds"` // Stored glossaries; the download queue adds attached ones
+	Cues        []SubtitleCue `json:"cues"`
+	Style       SubtitleStyle `json:"style"`
+}
+
+// AnimationOptions controls GIF/animated WebP export from the edit pipeline.
+type AnimationOptions struct {
+	Enabled      bool   `json:"enabled"`
+	Format       string `json:"format"`       // gif, webp
+	FPS          int    `json:"fps"`          // default 12
+	Width        int    `json:"width"`        // default 480, height keeps aspect ratio
+	Loop         int    `json:"loop"`         // 0 = forever, N = play N times
+	Quality      int    `json:"quality"`      // 1-100
+	TargetSizeKB int    `json:"targetSizeKb"` // 0 = no size limit
 }
 
 // SubtitleCue is one editable, timestamped caption in source-video time.
 type SubtitleCue struct {
+	Start float64        `json:"start"`
+	End   float64        `json:"end"`
+	Text  string         `json:"text"`
+	Words []SubtitleWord `json:"words,omitempty"` // Word timings from Whisper, used by karaoke styles
+
+	// Translation is the English line stacked under Text in bilingual output.
+	Translation string `json:"translation,omitempty"`
+
+	// Speaker labels the voice of the cue; buildASS colours each speaker.
+	Speaker string `json:"speaker,omitempty"`
+}
+
+// SubtitleWord is one word of a cue with its own source-video timing.
+type SubtitleWord struct {
 	Start float64 `json:"start"`
 	End   float64 `json:"end"`
 	Text  string  `json:"text"`
@@ -706,6 +759,13 @@ type SubtitleStyle struct {
 	Position          string  `json:"position"` // top, center, bottom
 	Bold              bool    `json:"bold"`
 	Italic            bool    `json:"italic"`
+
+	// Word-level styles need cues with Whisper word timings; other cues are
+	// rendered whole.
+	KaraokeMode    string `json:"karaokeMode"`    // "", karaoke (progressive fill), highlight (active word)
+	HighlightColor string `json:"highlightColor"` // Colour of sung/active words
+	WordsPerLine   int    `json:"wordsPerLine"`   // Short-form grouping, 0 = whole cue
+	PopIn          bool   `json:"popIn"`          // Scale each group in as it appears
 }
 
 // SubtitleResult is returned to the editor after importing a remote track.
@@ -720,13 +780,33 @@ type SubtitleResult struct {
 func (c *Client) Download(ctx context.Context, opts DownloadOptions, onProgress ProgressCallback, onLog LogCallback) error {
 	cutRanges := normalizeCutRanges(opts.ExcludedRanges)
 	opts.Captions = validCaptionOptions(opts.Captions)
+	opts.Animation = validAnimationOptions(opts.Animation)
 	if opts.Captions.Enabled && opts.AudioOnly {
 		return errors.New("legendas visuais só podem ser aplicadas a downloads de vídeo")
 	}
-	needsRender := len(cutRanges) > 0 || opts.Captions.Enabled
+	if opts.Animation.Enabled && opts.AudioOnly {
+		return errors.New("GIF/WebP animado só pode ser gerado a partir de downloads de vídeo")
+	}
+	opts.ConversionPreset = strings.TrimSpace(opts.ConversionPreset)
+	if opts.ConversionPreset != "" {
+		if opts.Animation.Enabled {
+			return errors.New("presets de conversão não podem ser combinados com GIF/WebP animado")
+		}
+		if c.presetConverter == nil {
+			return errors.New("presets de conversão não estão disponíveis")
+		}
+	}
+	if err := opts.Watermark.Validate(); err != nil {
+		return fmt.Errorf("marca d'água inválida: %w", err)
+	}
+	if err := opts.Speed.Validate(); err != nil {
+		return fmt.Errorf("velocidade inválida: %w", err)
+	}
+	needsRender := len(cutRanges) > 0 || opts.Captions.Enabled || opts.Animation.Enabled ||
+		(opts.Watermark.Enabled() && !opts.AudioOnly) || opts.Speed.Enabled()
 	outputTemplate := fmt.Sprintf("%s/%%(title)s.%%(ext)s", c.outputDir)
 	var editTempDir string
-	if needsRender {
+	if needsRender || opts.ConversionPreset != "" {
 		var err error
 		editTempDir, err = os.MkdirTemp(c.outputDir, ".downkingo-edit-")
 		if err != nil {
@@ -842,19 +922,12 @@ func (c *Client) Download(ctx context.Context, opts DownloadOptions, onProgress
 			(opts.Captions.Enabled && opts.Captions.Source != "whisper" && len(opts.Captions.Cues) == 0)
 		if needsSourceSubtitles {
 			args = append(args, "--write-subs", "--write-auto-subs")
-
-			subtitleLanguage := opts.SubtitleLanguage
-			if opts.Captions.Enabled && opts.Captions.Language != "" && opts.Captions.Language != "auto" {
-				subtitleLanguage = opts.Captions.Language
-			}
-			if subtitleLanguage != "" {
-				args = append(args, "--sub-langs", subtitleLanguage)
-			} else {
-				args = append(args, "--sub-langs", "pt,pt-BR,en")
-			}
+			args = append(args, "--sub-langs", strings.Join(requestedSubtitleLanguages(opts), ","))
 			args = append(args, "--sub-format", "srt/vtt/best", "--convert-subs", "srt")
 
-			if opts.EmbedSubtitles && !opts.Captions.Enabled {
+			// Edited renders embed the rippled tracks themselves; yt-dlp would
+			// embed the original timings into a file that is re-encoded anyway.
+			if opts.EmbedSubtitles && !needsRender {
 				args = append(args, "--embed-subs")
 			}
 		}
@@ -1038,6 +1111,7 @@ func (c *Client) Download(ctx context.Context, opts DownloadOptions, onProgress
 		}
 	}
 
+	var renderedPath string
 	if needsRender {
 		if onProgress != nil {
 			onProgress(DownloadProgress{Percent: 100, Status: "merging"})
@@ -1048,7 +1122,35 @@ func (c *Client) Download(ctx context.Context, opts DownloadOptions, onProgress
 		if onLog != nil && opts.Captions.Enabled {
 			onLog("[Legendas] Preparando a faixa visual e o estilo do editor...")
 		}
-		if err := c.renderEditedMedia(ctx, editTempDir, cutRanges, opts, onLog); err != nil {
+		if onLog != nil && opts.Animation.Enabled {
+			onLog(fmt.Sprintf("[Animação] Gerando %s otimizado do trecho...", strings.ToUpper(opts.Animation.Format)))
+		}
+		if onLog != nil && opts.Speed != nil && opts.Speed.Factor > 0 && opts.Speed.Factor != 1 {
+			onLog(fmt.Sprintf("[Velocidade] Aplicando velocidade %gx...", opts.Speed.Factor))
+		}
+		renderDir := c.outputDir
+		if opts.ConversionPreset != "" {
+			// The preset writes the final file; the edit stays in the workspace.
+			renderDir = filepath.Join(editTempDir, "rendered")
+			if err := os.MkdirAll(renderDir, 0755); err != nil {
+				return fmt.Errorf("create edit workspace: %w", err)
+			}
+		}
+		var err error
+		renderedPath, err = c.renderEditedMedia(ctx, editTempDir, renderDir, cutRanges, opts, onLog)
+		if err != nil {
+			if onProgress != nil {
+				onProgress(DownloadProgress{Status: "failed"})
+			}
+			return err
+		}
+	}
+
+	if opts.ConversionPreset != "" {
+		if onProgress != nil {
+			onProgress(DownloadProgress{Percent: 100, Status: "merging"})
+		}
+		if err := c.applyConversionPreset(ctx, editTempDir, renderedPath, opts.ConversionPreset, onLog); err != nil {
 			if onProgress != nil {
 				onProgress(DownloadProgress{Status: "failed"})
 			}
@@ -1137,46 +1239,14 @@ func findDownloadedMedia(root string) (string, error) {
 }
 
 func (c *Client) probeMedia(ctx context.Context, inputPath string) (float64, bool, error) {
-	probeName := "ffprobe"
-	if strings.EqualFold(filepath.Ext(c.ffmpegPath), ".exe") {
-		probeName += ".exe"
-	}
-	probePath := filepath.Join(filepath.Dir(c.ffmpegPath), probeName)
-	if _, err := os.Stat(probePath); err != nil {
-		probePath = probeName
-	}
-
-	cmd := exec.CommandContext(ctx, probePath,
-		"-v", "error", "-show_entries", "format=duration:stream=codec_type", "-of", "json", inputPath,
-	)
-	setSysProcAttr(cmd)
-	output, err := cmd.Output()
+	info, err := mediainfo.Inspect(ctx, mediainfo.FFprobePath(c.ffmpegPath), inputPath)
 	if err != nil {
 		return 0, false, fmt.Errorf("ffprobe edit input: %w", err)
 	}
-	var result struct {
-		Format struct {
-			Duration string `json:"duration"`
-		} `json:"format"`
-		Streams []struct {
-			CodecType string `json:"codec_type"`
-		} `json:"streams"`
-	}
-	if err := json.Unmarshal(output, &result); err != nil {
-		return 0, false, fmt.Errorf("parse ffprobe output: %w", err)
-	}
-	duration, err := strconv.ParseFloat(result.Format.Duration, 64)
-	if err != nil || duration <= 0 {
+	if info.Duration <= 0 {
 		return 0, false, errors.New("invalid media duration for timeline editing")
 	}
-	hasAudio := false
-	for _, stream := range result.Streams {
-		if stream.CodecType == "audio" {
-			hasAudio = true
-			break
-		}
-	}
-	return duration, hasAudio, nil
+	return info.Duration, info.HasAudio(), nil
 }
 
 func buildTimelineFilter(segments []CutRange, audioOnly, hasAudio bool) string {
@@ -1229,46 +1299,53 @@ func buildTimelineFilter(segments []CutRange, audioOnly, hasAudio bool) string {
 }
 
 func (c *Client) renderTimelineCuts(ctx context.Context, workspace string, cuts []CutRange, opts DownloadOptions) error {
-	return c.renderEditedMedia(ctx, workspace, cuts, opts, nil)
+	_, err := c.renderEditedMedia(ctx, workspace, c.outputDir, cuts, opts, nil)
+	return err
 }
 
+// renderEditedMedia renders the downloaded media of workspace into outputDir
+// and returns the rendered file.
 func (c *Client) renderEditedMedia(
 	ctx context.Context,
 	workspace string,
+	outputDir string,
 	cuts []CutRange,
 	opts DownloadOptions,
 	onLog LogCallback,
-) error {
+) (string, error) {
 	inputPath, err := findDownloadedMedia(workspace)
 	if err != nil {
-		return fmt.Errorf("prepare timeline edit: %w", err)
+		return "", fmt.Errorf("prepare timeline edit: %w", err)
 	}
 	extension := outputExtension(opts)
-	outputPath := filepath.Join(c.outputDir, strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))+extension)
+	outputPath := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))+extension)
 	if opts.SkipExisting {
 		if _, err := os.Stat(outputPath); err == nil {
-			return nil
+			return outputPath, nil
 		}
 	}
 
 	duration, hasAudio, err := c.probeMedia(ctx, inputPath)
 	if err != nil {
-		return err
+		return "", err
 	}
 	segments := keptRanges(cuts, duration)
 	if len(segments) == 0 {
-		return errors.New("the cuts remove the entire media")
+		return "", errors.New("the cuts remove the entire media")
 	}
 	if opts.AudioOnly && !hasAudio {
-		return errors.New("downloaded media has no audio stream to edit")
+		return "", errors.New("downloaded media has no audio stream to edit")
 	}
+	// Animated outputs are silent; dropping audio from the graph avoids an
+	// unconnected [aout] pad.
+	keepAudio := hasAudio && !opts.Animation.Enabled
 
-	filter := buildTimelineFilter(segments, opts.AudioOnly, hasAudio)
+	filter := buildTimelineFilter(segments, opts.AudioOnly, keepAudio)
 	videoOutputLabel := "vout"
 	if opts.Captions.Enabled {
 		cues, err := c.resolveCaptionCues(ctx, workspace, inputPath, opts.Captions, onLog)
 		if err != nil {
-			return err
+			return "", err
 		}
 		cues = rippleSubtitleCues(cues, segments)
 		if len(cues) == 0 {
@@ -1278,35 +1355,118 @@ func (c *Client) renderEditedMedia(
 		} else {
 			assPath, err := writeASSFile(workspace, cues, opts.Captions.Style)
 			if err != nil {
-				return fmt.Errorf("prepare styled subtitles: %w", err)
+				return "", fmt.Errorf("prepare styled subtitles: %w", err)
 			}
 			filter = appendASSFilter(filter, "vout", "vfinal", assPath)
 			videoOutputLabel = "vfinal"
 		}
 	}
 
-	args := []string{"-hide_banner", "-loglevel", "error"}
-	if opts.SkipExisting {
-		args = append(args, "-n")
-	} else {
-		args = append(args, "-y")
+	var tracks []subtitleTrack
+	wantsTracks := (opts.DownloadSubtitles || opts.EmbedSubtitles) && !opts.AudioOnly && !opts.Animation.Enabled
+	if wantsTracks {
+		tracks, err = prepareSubtitleTracks(workspace, requestedSubtitleLanguages(opts), segments, opts.Speed, onLog)
+		if err != nil {
+			return "", err
+		}
+	}
+	var embedded []subtitleTrack
+	if opts.EmbedSubtitles {
+		embedded = tracks
+	}
+
+	var watermarkInputs []string
+	if opts.Watermark.Enabled() && !opts.AudioOnly {
+		filter = opts.Watermark.AppendFilter(filter, videoOutputLabel, "vmarked", 1+len(embedded))
+		videoOutputLabel = "vmarked"
+		watermarkInputs = opts.Watermark.InputArgs()
+	}
+
+	// Speed changes run last, so captions and the watermark follow the video.
+	audioOutputLabel := ""
+	if opts.AudioOnly || keepAudio {
+		audioOutputLabel = "aout"
 	}
-	args = append(args, "-i", inputPath, "-filter_complex", filter)
 	if opts.AudioOnly {
-		args = append(args, "-map", "[aout]")
-	} else {
-		args = append(args, "-map", "["+videoOutputLabel+"]")
-		if hasAudio {
-			args = append(args, "-map", "[aout]", "-c:a", "aac", "-b:a", "192k")
+		videoOutputLabel = ""
+	}
+	filter, videoOutputLabel, audioOutputLabel = appendSpeedFilters(filter, opts.Speed, videoOutputLabel, audioOutputLabel)
+
+	args := []string{"-i", inputPath}
+	args = append(args, subtitleTrackInputs(embedded)...)
+	args = append(args, watermarkInputs...)
+	args = append(args, "-filter_complex", filter)
+	reverse := opts.Speed != nil && opts.Speed.Reverse
+	renderPath := outputPath
+	switch {
+	case opts.Animation.Enabled:
+		// The edited range is rendered to a near-lossless intermediate first so
+		// the target-size search only repeats the cheap animation encode.
+		renderPath = filepath.Join(workspace, "downkingo-animation-source.mp4")
+		args = append(args, "-map", "["+videoOutputLabel+"]", "-an",
+			"-c:v", "libx264", "-preset", "veryfast", "-crf", "12", "-pix_fmt", "yuv420p")
+	case reverse:
+		// Reversing needs the finished edit, so it is rendered to a
+		// near-lossless intermediate and encoded again once reversed.
+		renderPath = filepath.Join(workspace, "downkingo-reverse-source.mkv")
+		args = append(args, reverseSourceArgs(streamLabel(videoOutputLabel), streamLabel(audioOutputLabel))...)
+	default:
+		args = append(args, editOutputArgs(streamLabel(videoOutputLabel), streamLabel(audioOutputLabel), embedded, extension)...)
+	}
+	args = append(args, renderPath)
+	if err := c.runEditFFmpeg(ctx, args, opts.SkipExisting && renderPath == outputPath); err != nil {
+		return "", err
+	}
+
+	if reverse {
+		if onLog != nil {
+			onLog("[Velocidade] Invertendo o trecho editado...")
+		}
+		reversedPath := filepath.Join(workspace, "downkingo-reversed.mkv")
+		if err := converter.ReverseMedia(ctx, c.ffmpegPath, renderPath, reversedPath, converter.ReverseAll, nil); err != nil {
+			return "", fmt.Errorf("reverse edited media: %w", err)
 		}
-		args = append(args, "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-pix_fmt", "yuv420p", "-map_metadata", "0")
-		if extension == ".mp4" {
-			args = append(args, "-movflags", "+faststart")
+		renderPath = reversedPath
+		if !opts.Animation.Enabled {
+			video, audio := "", ""
+			if videoOutputLabel != "" {
+				video = "0:v:0"
+			}
+			if audioOutputLabel != "" {
+				audio = "0:a:0"
+			}
+			final := append([]string{"-i", reversedPath}, subtitleTrackInputs(embedded)...)
+			final = append(final, editOutputArgs(video, audio, embedded, extension)...)
+			if err := c.runEditFFmpeg(ctx, append(final, outputPath), opts.SkipExisting); err != nil {
+				return "", err
+			}
+		}
+	}
+	if opts.Animation.Enabled {
+		if err := c.exportAnimation(ctx, renderPath, outputPath, opts.Animation, onLog); err != nil {
+			return "", err
+		}
+		return outputPath, nil
+	}
+	if len(embedded) > 0 && onLog != nil {
+		onLog(fmt.Sprintf("[Legendas] %d faixa(s) de legenda incorporada(s) ao vídeo.", len(embedded)))
+	}
+	if opts.DownloadSubtitles && len(tracks) > 0 {
+		if _, err := writeSubtitleSidecars(outputPath, tracks); err != nil {
+			return "", err
 		}
 	}
-	args = append(args, outputPath)
+	return outputPath, nil
+}
 
-	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+// runEditFFmpeg runs one step of the edit render. noOverwrite keeps an
+// existing output, for SkipExisting.
+func (c *Client) runEditFFmpeg(ctx context.Context, args []string, noOverwrite bool) error {
+	overwrite := "-y"
+	if noOverwrite {
+		overwrite = "-n"
+	}
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, append([]string{"-hide_banner", "-loglevel", "error", overwrite}, args...)...)
 	setSysProcAttr(cmd)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("ffmpeg timeline edit: %w: %s", err, strings.TrimSpace(string(output)))
@@ -1314,6 +1474,34 @@ func (c *Client) renderEditedMedia(
 	return nil
 }
 
+// streamLabel turns a filter graph output into a -map argument; an empty
+// label means the stream is absent.
+func streamLabel(label string) string {
+	if label == "" {
+		return ""
+	}
+	return "[" + label + "]"
+}
+
+// editOutputArgs maps the edited streams into the final file. video or audio
+// is empty when the output lacks that stream; audio-only outputs keep the
+// encoder FFmpeg picks for the extension.
+func editOutputArgs(video, audio string, embedded []subtitleTrack, extension string) []string {
+	if video == "" {
+		return []string{"-map", audio}
+	}
+	args := []string{"-map", video}
+	if audio != "" {
+		args = append(args, "-map", audio, "-c:a", "aac", "-b:a", "192k")
+	}
+	args = append(args, subtitleTrackMaps(embedded, 1, extension)...)
+	args = append(args, "-c:v", "libx264", "-preset", "medium", "-crf", "18", "-pix_fmt", "yuv420p", "-map_metadata", "0")
+	if extension == ".mp4" {
+		args = append(args, "-movflags", "+faststart")
+	}
+	return args
+}
+
 // HasAria2 retorna true se aria2c está configurado e disponível
 func (c *Client) HasAria2() bool {
 	return c.aria2cPath != ""